@@ -2,7 +2,10 @@ package app_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/livebud/bud/internal/cli/testcli"
 	"github.com/livebud/bud/internal/is"
@@ -30,3 +33,140 @@ func TestWelcome(t *testing.T) {
 	is.NoErr(td.Exists("bud/app"))
 	is.NoErr(app.Close())
 }
+
+func TestBuildErrorPage(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "hello" }
+	`
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	// Break the controller
+	controllerFile := filepath.Join(dir, "controller", "controller.go")
+	is.NoErr(os.WriteFile(controllerFile, []byte(`
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return hello }
+	`), 0644))
+	is.True(app.Ready(ctx) != nil)
+	// The build error is shown instead of the stale app
+	for i := 0; ; i++ {
+		res, err = app.Get("/")
+		is.NoErr(err)
+		if res.Status() == 500 || i == 10 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	is.Equal(res.Status(), 500)
+	is.In(res.Body().String(), "Build failed")
+	is.In(res.Body().String(), "undefined: hello")
+	// Fix the controller
+	is.NoErr(os.WriteFile(controllerFile, []byte(`
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "goodbye" }
+	`), 0644))
+	is.NoErr(app.Ready(ctx))
+	// Requests are held until the restarted app is ready
+	res, err = app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), "goodbye")
+	is.NoErr(app.Close())
+}
+
+func TestInitialBuildError(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return hello }
+	`
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.True(err != nil)
+	is.In(err.Error(), "undefined: hello")
+	defer app.Close()
+	// The build error is shown while bud waits for changes
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 500)
+	is.In(res.Body().String(), "Build failed")
+	is.In(res.Body().String(), "undefined: hello")
+	// Fix the controller
+	controllerFile := filepath.Join(dir, "controller", "controller.go")
+	is.NoErr(os.WriteFile(controllerFile, []byte(`
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "hello" }
+	`), 0644))
+	is.NoErr(app.Ready(ctx))
+	res, err = app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), "hello")
+	is.NoErr(app.Close())
+}
+
+func TestCrashRestart(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "hello" }
+		func (c *Controller) Show(id string) string {
+			if id == "crash" {
+				go func() { panic("boom") }()
+			}
+			return id
+		}
+	`
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	hot, err := app.Hot("/bud/hot")
+	is.NoErr(err)
+	defer hot.Close()
+	// Crash the app
+	app.Get("/crash")
+	// The panic is sent to the browser's overlay
+	for {
+		event, err := hot.Next(ctx)
+		is.NoErr(err)
+		if event.Type != "overlay" {
+			continue
+		}
+		is.In(string(event.Data), `"title":"App crashed"`)
+		is.In(string(event.Data), "app crashed with exit status 2")
+		is.In(string(event.Data), "panic: boom")
+		break
+	}
+	// The app is restarted
+	is.NoErr(app.Ready(ctx))
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), "hello")
+	is.NoErr(app.Close())
+}
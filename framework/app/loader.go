@@ -74,7 +74,7 @@ func (l *loader) loadProvider() *di.Provider {
 		},
 	}
 	if l.flag.Embed {
-		fn.Aliases[jsVM] = di.ToType("github.com/livebud/bud/package/js/v8", "*VM")
+		fn.Aliases[jsVM] = l.loadVM()
 		fn.Aliases[publicServer] = di.ToType("github.com/livebud/bud/framework/public/publicrt", "*StaticServer")
 	}
 	provider, err := l.injector.Wire(fn)
@@ -85,3 +85,22 @@ func (l *loader) loadProvider() *di.Provider {
 	}
 	return provider
 }
+
+// vms that can be embedded into the app
+var vms = map[string]string{
+	"v8":   "github.com/livebud/bud/package/js/v8",
+	"goja": "github.com/livebud/bud/package/js/goja",
+}
+
+// loadVM that renders the views in the built app
+func (l *loader) loadVM() di.Dependency {
+	name := l.flag.JS
+	if name == "" {
+		name = "v8"
+	}
+	importPath, ok := vms[name]
+	if !ok {
+		l.Bail(fmt.Errorf("app: unknown javascript VM %q", name))
+	}
+	return di.ToType(importPath, "*VM")
+}
@@ -26,6 +26,21 @@ func Accepts(r *http.Request) Acceptable {
 	return Acceptable(accept.Parse(r.Header.Get("Accept")))
 }
 
+// PageType is the media type the livebud runtime accepts during client-side
+// navigation. Views respond with the page's data instead of HTML.
+const PageType = "application/vnd.bud.page+json"
+
+// AcceptsPage is true if the request explicitly asks for page data. Wildcards
+// don't count, so browsers still get HTML.
+func AcceptsPage(r *http.Request) bool {
+	for _, accept := range accept.Parse(r.Header.Get("Accept")) {
+		if accept.Type+"/"+accept.Subtype == PageType && accept.Q > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // Acceptable types
 type Acceptable accept.AcceptSlice
 
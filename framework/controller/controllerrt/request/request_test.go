@@ -0,0 +1,27 @@
+package request_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/livebud/bud/framework/controller/controllerrt/request"
+	"github.com/livebud/bud/internal/is"
+)
+
+func TestAcceptsPage(t *testing.T) {
+	is := is.New(t)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", PageType)
+	is.True(AcceptsPage(r))
+	r.Header.Set("Accept", "application/json, "+PageType+";q=0.5")
+	is.True(AcceptsPage(r))
+	// Wildcards still get HTML
+	r.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	is.True(!AcceptsPage(r))
+	r.Header.Set("Accept", "application/*")
+	is.True(!AcceptsPage(r))
+	r.Header.Set("Accept", PageType+";q=0")
+	is.True(!AcceptsPage(r))
+	r.Header.Del("Accept")
+	is.True(!AcceptsPage(r))
+}
@@ -21,6 +21,10 @@ var _ http.Handler = (*Format)(nil)
 func (f *Format) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	acceptable := request.Accepts(r)
 	switch {
+	// Views respond to page requests with the data needed to render the page
+	// in the browser
+	case f.HTML != nil && request.AcceptsPage(r):
+		f.HTML.ServeHTTP(w, r)
 	case f.HTML != nil && acceptable.Accepts("text/html"):
 		f.HTML.ServeHTTP(w, r)
 	case f.JSON != nil && acceptable.Accepts("application/json"):
@@ -143,7 +147,6 @@ func (res *Response) HTML(body string) http.Handler {
 	})
 }
 
-// TODO: make hot reload configurable
 func wrapHTML(body string) string {
 	return `
 		<!DOCTYPE html>
@@ -154,8 +157,7 @@ func wrapHTML(body string) string {
 		<body>
 			` + body + `
 			<script>
-				// TODO: host should be dynamic
-				const sse = new EventSource("http://127.0.0.1:35729/bud/hot")
+				const sse = new EventSource("/bud/hot")
 				sse.addEventListener("message", () => { location.reload() })
 			</script>
 		</body>
@@ -13,6 +13,7 @@ import (
 	"github.com/livebud/bud/internal/valid"
 
 	"github.com/livebud/bud/internal/bail"
+	"github.com/livebud/bud/internal/entrypoint"
 	"github.com/livebud/bud/internal/imports"
 	"github.com/livebud/bud/package/di"
 	"github.com/livebud/bud/package/gomod"
@@ -254,7 +255,7 @@ func (l *loader) loadView(controllerKey, actionKey, actionRoute string) *View {
 	for _, de := range des {
 		name := de.Name()
 		ext := path.Ext(name)
-		if ext != ".svelte" {
+		if _, ok := entrypoint.Type(ext); !ok {
 			continue
 		}
 		base := strings.TrimSuffix(path.Base(name), ext)
@@ -5,4 +5,14 @@ type Flag struct {
 	Embed  bool
 	Minify bool
 	Hot    bool
+	// Dev is true for bud run and false for bud build. Development builds
+	// compile components with runtime checks, independent of minifying.
+	Dev bool
+	// Widths to resize the public images to ahead of time when embedding
+	ImageWidths []int
+	// Fail on compiler warnings instead of logging them
+	Strict bool
+	// JS is the VM that renders the views: "v8" or "goja". Goja is written in
+	// pure Go, so apps can be built without cgo.
+	JS string
 }
@@ -0,0 +1,62 @@
+package public
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+
+	"github.com/livebud/bud/framework"
+	"github.com/livebud/bud/internal/resize"
+	"github.com/livebud/bud/package/budfs"
+)
+
+// Images resizes public images on-demand in development. Resized images are
+// cached until the original image changes.
+//
+//	e.g. bud/public/img/hero.w800.png => public/img/hero.png resized to 800px
+//
+// Like embedded images, only the widths in the flags are resized when there
+// are any. Images aren't resized to widths they already fit within, so the
+// server falls back to the original.
+func Images(flag *framework.Flag) budfs.FileGenerator {
+	return budfs.GenerateFile(func(fsys budfs.FS, file *budfs.File) error {
+		original, width, ok := resize.Parse(file.Target())
+		if !ok || !resize.Supported(original) {
+			return fmt.Errorf("public: %q isn't a resized image. %w", file.Target(), fs.ErrNotExist)
+		}
+		if width > resize.MaxWidth || !allowedWidth(flag.ImageWidths, width) {
+			return fmt.Errorf("public: %q isn't resized to a configured width. %w", file.Target(), fs.ErrNotExist)
+		}
+		data, err := fs.ReadFile(fsys, original)
+		if err != nil {
+			return err
+		}
+		srcWidth, _, err := resize.Size(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("public: unable to read the size of %q. %w", original, err)
+		}
+		if width >= srcWidth {
+			return fmt.Errorf("public: %q is already narrower than %dpx. %w", original, width, fs.ErrNotExist)
+		}
+		resized, err := resize.File(data, width)
+		if err != nil {
+			return err
+		}
+		file.Data = resized
+		return nil
+	})
+}
+
+// allowedWidth returns true if width is one of the configured widths. Any width
+// is allowed when there aren't any.
+func allowedWidth(widths []int, width int) bool {
+	if len(widths) == 0 {
+		return true
+	}
+	for _, w := range widths {
+		if w == width {
+			return true
+		}
+	}
+	return false
+}
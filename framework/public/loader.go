@@ -1,17 +1,25 @@
 package public
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io/fs"
 	"path"
+	"sort"
+	"strings"
 
 	"github.com/livebud/bud/framework"
+	"github.com/livebud/bud/framework/public/publicrt"
 	"github.com/livebud/bud/package/vfs"
 
 	"github.com/livebud/bud/internal/bail"
 	"github.com/livebud/bud/internal/embed"
 	"github.com/livebud/bud/internal/embedded"
+	"github.com/livebud/bud/internal/fingerprint"
 	"github.com/livebud/bud/internal/imports"
+	"github.com/livebud/bud/internal/precompress"
+	"github.com/livebud/bud/internal/resize"
 )
 
 func Load(fsys fs.FS, flag *framework.Flag) (*State, error) {
@@ -52,6 +60,17 @@ func (l *loader) Load() (state *State, err error) {
 	// Load default public files. Out of convenience, these defaults are embedded
 	// regardless of flag.Embed
 	state.Embeds = append(state.Embeds, l.loadDefaults()...)
+	// Fingerprint and compress the embedded files ahead of time, so they can be
+	// cached forever and aren't compressed on every request
+	if l.flag.Embed {
+		state.Assets = loadAssets(state.Embeds)
+		state.Embeds = append(state.Embeds, l.loadImages(state.Embeds)...)
+		variants, err := precompress.Compress(state.Embeds)
+		if err != nil {
+			return nil, err
+		}
+		state.Embeds = append(state.Embeds, variants...)
+	}
 	// Add the imports
 	state.Imports = l.imports.List()
 	return state, nil
@@ -99,3 +118,74 @@ func (l *loader) loadDefaults() (files []*embed.File) {
 	}
 	return files
 }
+
+// loadImages resizes the embedded images to the widths in the flags. Images
+// aren't resized to widths they already fit within.
+func (l *loader) loadImages(embeds []*embed.File) (images []*embed.File) {
+	if len(l.flag.ImageWidths) == 0 {
+		return nil
+	}
+	for _, file := range embeds {
+		if !resize.Supported(file.Path) {
+			continue
+		}
+		width, _, err := resize.Size(bytes.NewReader(file.Data))
+		if err != nil {
+			l.Bail(fmt.Errorf("public: unable to read the size of %q. %w", file.Path, err))
+		}
+		for _, resizeWidth := range l.flag.ImageWidths {
+			if resizeWidth <= 0 || resizeWidth >= width {
+				continue
+			}
+			data, err := resize.File(file.Data, resizeWidth)
+			if err != nil {
+				l.Bail(err)
+			}
+			images = append(images, &embed.File{
+				Path: resize.Path(file.Path, resizeWidth),
+				Data: data,
+			})
+		}
+	}
+	return images
+}
+
+// loadAssets hashes the embedded files for fingerprinting
+func loadAssets(embeds []*embed.File) (assets []*Asset) {
+	for _, file := range embeds {
+		assets = append(assets, &Asset{
+			Path: strings.TrimPrefix(file.Path, "public"),
+			Hash: fingerprint.Hash(file.Data),
+		})
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].Path < assets[j].Path
+	})
+	return assets
+}
+
+// LoadAssets loads the fingerprinted public files, so views can link to them.
+// Public files are only fingerprinted when they're embedded.
+func LoadAssets(fsys fs.FS, flag *framework.Flag) (assets publicrt.Assets, err error) {
+	assets = publicrt.Assets{}
+	if !flag.Embed {
+		return assets, nil
+	}
+	l := &loader{
+		fsys:    fsys,
+		flag:    flag,
+		imports: imports.New(),
+	}
+	defer l.Recover(&err)
+	var embeds []*embed.File
+	if err := vfs.Exist(fsys, "public"); err == nil {
+		embeds = l.loadEmbedsFrom("public", ".")
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	embeds = append(embeds, l.loadDefaults()...)
+	for _, asset := range loadAssets(embeds) {
+		assets[asset.Path] = asset.Hash
+	}
+	return assets, nil
+}
@@ -1,13 +1,24 @@
 package public_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
 	"testing"
 
+	"github.com/andybalholm/brotli"
 	"github.com/livebud/bud/internal/cli/testcli"
 	"github.com/livebud/bud/internal/embedded"
+	"github.com/livebud/bud/internal/fingerprint"
 	"github.com/livebud/bud/internal/is"
 	"github.com/livebud/bud/internal/testdir"
+	"github.com/livebud/bud/internal/versions"
 )
 
 func TestNoProject(t *testing.T) {
@@ -203,3 +214,235 @@ func TestDefaults(t *testing.T) {
 	is.Equal(res.Body().Bytes(), embedded.Favicon())
 	is.NoErr(app.Close())
 }
+
+func TestEmbedCompressed(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	script := strings.Repeat("console.log('hello world')\n", 100)
+	td.Files["public/app.js"] = script
+	td.Files["public/small.js"] = `console.log('hi')`
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run", "--embed", "--hot=false")
+	is.NoErr(err)
+	defer app.Close()
+	get := func(path, acceptEncoding string) *testcli.Response {
+		req, err := app.GetRequest(path)
+		is.NoErr(err)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		res, err := app.Do(req)
+		is.NoErr(err)
+		is.Equal(200, res.Status())
+		return res
+	}
+	// Brotli
+	res := get("/app.js", "gzip, br")
+	is.Equal(res.Header("Content-Encoding"), "br")
+	is.Equal(res.Header("Vary"), "Accept-Encoding")
+	is.In(res.Header("Content-Type"), "/javascript")
+	data, err := io.ReadAll(brotli.NewReader(res.Body()))
+	is.NoErr(err)
+	is.Equal(string(data), script)
+	// Gzip
+	res = get("/app.js", "gzip")
+	is.Equal(res.Header("Content-Encoding"), "gzip")
+	gz, err := gzip.NewReader(res.Body())
+	is.NoErr(err)
+	data, err = io.ReadAll(gz)
+	is.NoErr(err)
+	is.Equal(string(data), script)
+	// Uncompressed
+	res = get("/app.js", "identity")
+	is.Equal(res.Header("Content-Encoding"), "")
+	is.Equal(res.Header("Vary"), "Accept-Encoding")
+	is.Equal(res.Body().String(), script)
+	// Small files aren't compressed
+	res = get("/small.js", "gzip, br")
+	is.Equal(res.Header("Content-Encoding"), "")
+	is.Equal(res.Header("Vary"), "")
+	is.Equal(res.Body().String(), `console.log('hi')`)
+	is.NoErr(app.Close())
+}
+
+func TestEmbedFingerprinted(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	logo := `<svg xmlns="http://www.w3.org/2000/svg"></svg>`
+	td.Files["public/logo.svg"] = logo
+	td.Files["view/index.svelte"] = `
+		<script>
+			import { asset } from "bud/public"
+		</script>
+		<img src={asset("/logo.svg")} alt="logo" />
+	`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run", "--embed", "--hot=false")
+	is.NoErr(err)
+	defer app.Close()
+	hash := fingerprint.Hash([]byte(logo))
+	// Views link to the fingerprinted path
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.In(res.Body().String(), `<img src="/logo.`+hash+`.svg" alt="logo">`)
+	// Fingerprinted paths are cached forever
+	res, err = app.Get("/logo." + hash + ".svg")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Body().String(), logo)
+	is.Equal(res.Header("ETag"), `"`+hash+`"`)
+	is.Equal(res.Header("Cache-Control"), "public, max-age=31536000, immutable")
+	// The original path still works
+	res, err = app.Get("/logo.svg")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Body().String(), logo)
+	is.Equal(res.Header("ETag"), `"`+hash+`"`)
+	is.NoErr(app.Close())
+}
+
+// pngImage encodes a solid image of the given size
+func pngImage(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResizeImage(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.BFiles["public/img/hero.png"] = pngImage(400, 200)
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	// Original
+	res, err := app.Get("/img/hero.png")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Header("X-Image-Width"), "400")
+	is.Equal(res.Header("X-Image-Height"), "200")
+	// Resized on-demand
+	res, err = app.Get("/img/hero.png?w=100")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Header("Content-Type"), "image/png")
+	is.Equal(res.Header("X-Image-Width"), "100")
+	is.Equal(res.Header("X-Image-Height"), "50")
+	config, err := png.DecodeConfig(res.Body())
+	is.NoErr(err)
+	is.Equal(config.Width, 100)
+	is.Equal(config.Height, 50)
+	// Images aren't scaled up
+	res, err = app.Get("/img/hero.png?w=800")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Header("X-Image-Width"), "400")
+	// Invalid widths
+	for _, width := range []string{"abc", "0", "-100", "100000"} {
+		res, err = app.Get("/img/hero.png?w=" + width)
+		is.NoErr(err)
+		is.Equal(400, res.Status())
+	}
+	is.NoErr(app.Close())
+}
+
+func TestResizeImageWidths(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.BFiles["public/img/hero.png"] = pngImage(400, 200)
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run", "--image-widths=100,200")
+	is.NoErr(err)
+	defer app.Close()
+	// Configured width
+	res, err := app.Get("/img/hero.png?w=100")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Header("X-Image-Width"), "100")
+	// Widths that weren't configured fallback to the original
+	res, err = app.Get("/img/hero.png?w=150")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Header("X-Image-Width"), "400")
+	is.NoErr(app.Close())
+}
+
+func TestEmbedResizeImage(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	hero := pngImage(400, 200)
+	td.BFiles["public/img/hero.png"] = hero
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run", "--embed", "--hot=false", "--image-widths=100,200")
+	is.NoErr(err)
+	defer app.Close()
+	hash := fingerprint.Hash(hero)
+	// Resized ahead of time
+	res, err := app.Get("/img/hero.png?w=100")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Header("X-Image-Width"), "100")
+	is.Equal(res.Header("X-Image-Height"), "50")
+	is.Equal(res.Header("ETag"), `"`+hash+`-w100"`)
+	res, err = app.Get("/img/hero." + hash + ".png?w=200")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Header("X-Image-Width"), "200")
+	is.Equal(res.Header("Cache-Control"), "public, max-age=31536000, immutable")
+	// Widths that weren't declared fallback to the original
+	res, err = app.Get("/img/hero.png?w=150")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.Equal(res.Header("X-Image-Width"), "400")
+	is.Equal(res.Header("ETag"), `"`+hash+`"`)
+	is.Equal(res.Body().Bytes(), hero)
+	is.NoErr(app.Close())
+}
+
+func TestImageView(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.BFiles["public/img/hero.png"] = pngImage(400, 200)
+	td.Files["view/index.svelte"] = `
+		<script>
+			import { image } from "bud/public"
+			const hero = image("/img/hero.png", 100)
+		</script>
+		<img src={hero.src} width={hero.width} height={hero.height} alt="hero" />
+	`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(200, res.Status())
+	is.In(res.Body().String(), `<img src="/img/hero.png?w=100" width="100" height="50" alt="hero">`)
+	is.NoErr(app.Close())
+}
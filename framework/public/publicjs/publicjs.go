@@ -0,0 +1,112 @@
+// Package publicjs links views to the public files.
+package publicjs
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+	"github.com/livebud/bud/internal/fingerprint"
+	"github.com/livebud/bud/internal/resize"
+)
+
+// Plugin resolves `import { asset, image } from "bud/public"` within views to a
+// module that links public files to their fingerprinted URLs and knows the size
+// of the public images. Assets map the URL paths of the public files to their
+// hashes. Paths are returned as-is when assets is empty.
+func Plugin(fsys fs.FS, assets map[string]string) esbuild.Plugin {
+	return esbuild.Plugin{
+		Name: "bud_public",
+		Setup: func(epb esbuild.PluginBuild) {
+			epb.OnResolve(esbuild.OnResolveOptions{Filter: `^bud\/public$`}, func(args esbuild.OnResolveArgs) (result esbuild.OnResolveResult, err error) {
+				result.Namespace = "bud_public"
+				result.Path = args.Path
+				return result, nil
+			})
+			epb.OnLoad(esbuild.OnLoadOptions{Filter: `.*`, Namespace: "bud_public"}, func(args esbuild.OnLoadArgs) (result esbuild.OnLoadResult, err error) {
+				images, err := imageSizes(fsys)
+				if err != nil {
+					return result, err
+				}
+				contents, err := publicModule(assets, images)
+				if err != nil {
+					return result, err
+				}
+				result.Contents = &contents
+				result.Loader = esbuild.LoaderJS
+				return result, nil
+			})
+		},
+	}
+}
+
+// imageSizes reads the width and height of the public images
+func imageSizes(fsys fs.FS) (map[string][2]int, error) {
+	images := map[string][2]int{}
+	err := fs.WalkDir(fsys, "public", func(fpath string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if de.IsDir() || !resize.Supported(fpath) {
+			return nil
+		}
+		file, err := fsys.Open(fpath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		width, height, err := resize.Size(file)
+		if err != nil {
+			// Skip images we can't read
+			return nil
+		}
+		images[fpath[len("public"):]] = [2]int{width, height}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return images, nil
+}
+
+// publicModule generates the "bud/public" module
+func publicModule(assets map[string]string, images map[string][2]int) (string, error) {
+	urls := make(map[string]string, len(assets))
+	for path, hash := range assets {
+		urls[path] = fingerprint.Join(path, hash)
+	}
+	// Keys are sorted, so the module is the same each time
+	urlData, err := json.Marshal(urls)
+	if err != nil {
+		return "", err
+	}
+	imageData, err := json.Marshal(images)
+	if err != nil {
+		return "", err
+	}
+	return `const assets = ` + string(urlData) + `
+const images = ` + string(imageData) + `
+
+// Returns the URL of a public file
+export function asset(path) {
+  return assets[path] || path
+}
+
+// Returns the src, width and height of a public image. Images are resized when
+// given a width that's smaller than the image.
+export function image(path, width) {
+  const src = asset(path)
+  const size = images[path]
+  if (!size) return { src }
+  const [w, h] = size
+  if (!width || width >= w) return { src, width: w, height: h }
+  return {
+    src: src + "?w=" + width,
+    width,
+    height: Math.max(1, Math.round((h * width) / w)),
+  }
+}
+
+export default asset
+`, nil
+}
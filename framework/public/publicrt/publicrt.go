@@ -2,12 +2,17 @@ package publicrt
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
 	"path"
+	"strconv"
 	"time"
 
+	"github.com/livebud/bud/internal/fingerprint"
+	"github.com/livebud/bud/internal/precompress"
+	"github.com/livebud/bud/internal/resize"
 	"github.com/livebud/bud/package/budhttp"
 	"github.com/livebud/bud/package/gomod"
 	"github.com/livebud/bud/package/middleware"
@@ -46,7 +51,36 @@ func LoadFS(module *gomod.Module) (fsys fs.FS, err error) {
 }
 
 type Server interface {
-	Serve(fsys fs.FS) middleware.Middleware
+	Serve(fsys fs.FS, assets Assets) middleware.Middleware
+}
+
+// Assets maps the URL paths of the public files to the hash of their contents.
+// Assets are only fingerprinted when they're embedded.
+type Assets map[string]string
+
+// Path returns the fingerprinted URL path of a public file, so it can be cached
+// forever. Paths that aren't fingerprinted are returned as-is.
+//
+//	e.g. /logo.png => /logo.3f9a2c1d.png
+func (a Assets) Path(urlPath string) string {
+	hash, ok := a[urlPath]
+	if !ok {
+		return urlPath
+	}
+	return fingerprint.Join(urlPath, hash)
+}
+
+// Lookup the original path and hash of the public file at urlPath. Files can be
+// requested by either their original or fingerprinted path.
+func (a Assets) lookup(urlPath string) (original, hash string, fingerprinted bool) {
+	if hash, ok := a[urlPath]; ok {
+		return urlPath, hash, false
+	}
+	original, hash, ok := fingerprint.Split(urlPath)
+	if !ok || a[original] != hash {
+		return urlPath, "", false
+	}
+	return original, hash, true
 }
 
 func Live(client budhttp.Client) (*LiveServer, error) {
@@ -57,9 +91,9 @@ type LiveServer struct {
 	fsys fs.FS
 }
 
-func (l *LiveServer) Serve(fsys fs.FS) middleware.Middleware {
+func (l *LiveServer) Serve(fsys fs.FS, assets Assets) middleware.Middleware {
 	fsys = mergefs.Merge(l.fsys, fsys)
-	return serve(fsys, serveContent)
+	return serve(fsys, assets, open, serveContent)
 }
 
 func Static() StaticServer {
@@ -68,44 +102,139 @@ func Static() StaticServer {
 
 type StaticServer struct{}
 
-// TODO: serve gzipped content
-func (StaticServer) Serve(fsys fs.FS) middleware.Middleware {
-	return serve(fsys, serveContent)
+// Serve the embedded files, along with the variants that were compressed when
+// the app was built
+func (StaticServer) Serve(fsys fs.FS, assets Assets) middleware.Middleware {
+	return serve(fsys, assets, precompress.Open, serveContent)
+}
+
+func serve(fsys fs.FS, assets Assets, open openFunc, serveContent func(w http.ResponseWriter, req *http.Request, name string, modtime time.Time, content io.ReadSeeker)) *fileServer {
+	return &fileServer{fsys, http.FS(fsys), assets, open, serveContent}
+}
+
+// openFunc opens the file to respond with
+type openFunc func(w http.ResponseWriter, r *http.Request, hfs http.FileSystem, name string) (http.File, error)
+
+func open(w http.ResponseWriter, r *http.Request, hfs http.FileSystem, name string) (http.File, error) {
+	return hfs.Open(name)
 }
 
-func serve(fsys fs.FS, serveContent func(w http.ResponseWriter, req *http.Request, name string, modtime time.Time, content io.ReadSeeker)) middleware.Middleware {
-	hfs := http.FS(fsys)
-	return middleware.Function(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			urlPath := r.URL.Path
-			if r.Method != http.MethodGet || path.Ext(urlPath) == "" {
+// fileServer serves the public files as middleware
+type fileServer struct {
+	fsys         fs.FS
+	hfs          http.FileSystem
+	assets       Assets
+	open         openFunc
+	serveContent func(w http.ResponseWriter, req *http.Request, name string, modtime time.Time, content io.ReadSeeker)
+}
+
+var _ middleware.Middleware = (*fileServer)(nil)
+var _ fs.FS = (*fileServer)(nil)
+
+// Open a public file. This allows the public files to be exported.
+func (f *fileServer) Open(name string) (fs.File, error) {
+	return f.fsys.Open(name)
+}
+
+func (f *fileServer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urlPath := r.URL.Path
+		if r.Method != http.MethodGet || path.Ext(urlPath) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		original, hash, fingerprinted := f.assets.lookup(urlPath)
+		name := path.Join("public", original)
+		var file http.File
+		// Serve the resized image if a width was requested
+		width, err := requestedWidth(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if width > 0 && resize.Supported(name) {
+			file, err = f.hfs.Open(resize.Path(name, width))
+			if err != nil {
+				// Fallback to the original image
+				width = 0
+			}
+		}
+		if file == nil {
+			file, err = f.open(w, r, f.hfs, name)
+		}
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
 				next.ServeHTTP(w, r)
 				return
 			}
-			file, err := hfs.Open(path.Join("public", urlPath))
-			if err != nil {
-				if errors.Is(err, fs.ErrNotExist) {
-					next.ServeHTTP(w, r)
-					return
-				}
-				http.Error(w, err.Error(), 500)
-				return
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		defer file.Close()
+		stat, err := file.Stat()
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if stat.IsDir() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		header := w.Header()
+		if hash != "" {
+			// Resized and compressed variants are different representations of
+			// the file
+			if width > 0 {
+				hash += "-w" + strconv.Itoa(width)
 			}
-			defer file.Close()
-			stat, err := file.Stat()
-			if err != nil {
-				http.Error(w, err.Error(), 500)
-				return
+			if encoding := header.Get("Content-Encoding"); encoding != "" {
+				hash += "-" + encoding
 			}
-			if stat.IsDir() {
-				next.ServeHTTP(w, r)
+			header.Set("ETag", strconv.Quote(hash))
+		}
+		// Include the size of images, so views can reserve space for them
+		if resize.Supported(name) {
+			if err := setImageSize(header, file); err != nil {
+				http.Error(w, err.Error(), 500)
 				return
 			}
-			serveContent(w, r, urlPath, stat.ModTime(), file)
-		})
+		}
+		// Fingerprinted paths change along with their contents
+		if fingerprinted {
+			header.Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+		f.serveContent(w, r, original, stat.ModTime(), file)
 	})
 }
 
+// requestedWidth returns the width in the query string (e.g. ?w=800) or 0 if
+// there isn't one
+func requestedWidth(r *http.Request) (int, error) {
+	query := r.URL.Query()
+	if !query.Has("w") {
+		return 0, nil
+	}
+	width, err := strconv.Atoi(query.Get("w"))
+	if err != nil || width <= 0 || width > resize.MaxWidth {
+		return 0, fmt.Errorf("publicrt: invalid image width %q. Widths must be between 1 and %d", query.Get("w"), resize.MaxWidth)
+	}
+	return width, nil
+}
+
+// setImageSize sets the image's width and height headers
+func setImageSize(header http.Header, file http.File) error {
+	width, height, err := resize.Size(file)
+	if err != nil {
+		// Leave the headers off of images we can't read
+		_, err = file.Seek(0, io.SeekStart)
+		return err
+	}
+	header.Set("X-Image-Width", strconv.Itoa(width))
+	header.Set("X-Image-Height", strconv.Itoa(height))
+	_, err = file.Seek(0, io.SeekStart)
+	return err
+}
+
 func serveContent(w http.ResponseWriter, req *http.Request, name string, modtime time.Time, content io.ReadSeeker) {
 	http.ServeContent(w, req, name, modtime, content)
 }
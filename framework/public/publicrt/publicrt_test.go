@@ -0,0 +1,78 @@
+package publicrt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/livebud/bud/framework/public/publicrt"
+	"github.com/livebud/bud/internal/fingerprint"
+	"github.com/livebud/bud/internal/is"
+)
+
+var logo = []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+
+func TestAssetPath(t *testing.T) {
+	is := is.New(t)
+	hash := fingerprint.Hash(logo)
+	assets := publicrt.Assets{"/logo.svg": hash}
+	is.Equal(assets.Path("/logo.svg"), "/logo."+hash+".svg")
+	is.Equal(assets.Path("/missing.svg"), "/missing.svg")
+	is.Equal(publicrt.Assets{}.Path("/logo.svg"), "/logo.svg")
+}
+
+func TestServeFingerprinted(t *testing.T) {
+	is := is.New(t)
+	hash := fingerprint.Hash(logo)
+	fsys := fstest.MapFS{
+		"public/logo.svg": &fstest.MapFile{Data: logo},
+	}
+	assets := publicrt.Assets{"/logo.svg": hash}
+	handler := publicrt.Static().Serve(fsys, assets).Middleware(http.NotFoundHandler())
+	get := func(path string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	// Fingerprinted path
+	rec := get(assets.Path("/logo.svg"))
+	is.Equal(rec.Code, 200)
+	is.Equal(rec.Body.Bytes(), logo)
+	is.Equal(rec.Header().Get("ETag"), `"`+hash+`"`)
+	is.Equal(rec.Header().Get("Cache-Control"), "public, max-age=31536000, immutable")
+	is.In(rec.Header().Get("Content-Type"), "image/svg")
+	// Original path
+	rec = get("/logo.svg")
+	is.Equal(rec.Code, 200)
+	is.Equal(rec.Body.Bytes(), logo)
+	is.Equal(rec.Header().Get("ETag"), `"`+hash+`"`)
+	is.Equal(rec.Header().Get("Cache-Control"), "")
+	// Unchanged
+	rec = get("/logo.svg", "If-None-Match", `"`+hash+`"`)
+	is.Equal(rec.Code, 304)
+	// Stale fingerprint
+	rec = get("/logo.00000000.svg")
+	is.Equal(rec.Code, 404)
+}
+
+func TestServeInvalidWidth(t *testing.T) {
+	is := is.New(t)
+	fsys := fstest.MapFS{
+		"public/logo.svg": &fstest.MapFile{Data: logo},
+	}
+	handler := publicrt.Static().Serve(fsys, publicrt.Assets{}).Middleware(http.NotFoundHandler())
+	for _, width := range []string{"", "abc", "0", "-1", "4097"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logo.svg?w="+width, nil))
+		is.Equal(rec.Code, 400)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logo.svg?w=4096", nil))
+	is.Equal(rec.Code, 200)
+	is.Equal(rec.Body.Bytes(), logo)
+}
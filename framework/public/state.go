@@ -9,5 +9,13 @@ import (
 type State struct {
 	Imports []*imports.Import
 	Embeds  []*embed.File
+	Assets  []*Asset
 	Flag    *framework.Flag
 }
+
+// Asset is an embedded public file that's fingerprinted with the hash of its
+// contents
+type Asset struct {
+	Path string // URL path (e.g. /logo.png)
+	Hash string
+}
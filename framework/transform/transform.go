@@ -0,0 +1,214 @@
+// Package transform loads the transforms defined within the transform/
+// directory of your app and the bud-* plugins you depend on.
+//
+// Transforms are JS modules that get bundled and evaluated in the same VM that
+// compiles Svelte. They export which extension they transform from and to
+// alongside the function that does the transforming:
+//
+//	// transform/markdown.js
+//	import { marked } from "marked"
+//
+//	export default {
+//	  from: ".md",
+//	  to: ".svelte",
+//	  transform(file) {
+//	    return marked(file.code)
+//	  },
+//	}
+//
+// The transform function runs for both the DOM and SSR. Export dom and ssr
+// functions instead to transform for a specific platform. Functions receive
+// the file's path, code and platform. They return the transformed code or an
+// object with code and optionally css. Functions may be async.
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+	"github.com/livebud/bud/framework/transform/transformrt"
+	"github.com/livebud/bud/internal/esmeta"
+	"github.com/livebud/bud/package/gomod"
+	"github.com/livebud/bud/package/js"
+	"github.com/livebud/bud/package/pluginmod"
+)
+
+// Load the transforms from the app and its plugins. The app's transforms come
+// first and replace the plugins' transforms between the same extensions.
+func Load(module *gomod.Module, vm js.VM) (transformables []*transformrt.Transformable, err error) {
+	modules, err := pluginmod.Glob(module, "transform")
+	if err != nil {
+		return nil, err
+	}
+	loaded := 0
+	for _, module := range modules {
+		des, err := fs.ReadDir(module, "transform")
+		if err != nil {
+			return nil, err
+		}
+		var moduleTransformables []*transformrt.Transformable
+		for _, de := range des {
+			if de.IsDir() || !isScript(de.Name()) {
+				continue
+			}
+			global := fmt.Sprintf("__bud_transform_%d__", loaded)
+			loaded++
+			transformable, err := load(vm, module, path.Join("transform", de.Name()), global)
+			if err != nil {
+				return nil, err
+			}
+			moduleTransformables = append(moduleTransformables, transformable)
+		}
+		transformables = transformrt.Merge(transformables, moduleTransformables)
+	}
+	return transformables, nil
+}
+
+func isScript(name string) bool {
+	switch path.Ext(name) {
+	case ".js", ".ts":
+		return true
+	default:
+		return false
+	}
+}
+
+// descriptor describes what the transform exports
+type descriptor struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	All  bool   `json:"transform"`
+	DOM  bool   `json:"dom"`
+	SSR  bool   `json:"ssr"`
+}
+
+// load a transform into the VM under the global name
+func load(vm js.VM, module *gomod.Module, fpath, global string) (*transformrt.Transformable, error) {
+	code, err := bundle(module, fpath, global)
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.Script(fpath, code); err != nil {
+		return nil, fmt.Errorf("transform: unable to load %q. %w", fpath, err)
+	}
+	expr := fmt.Sprintf(`;(function(t) {
+		t = t.default || t
+		return JSON.stringify({
+			from: t.from,
+			to: t.to,
+			transform: typeof t.transform === "function",
+			dom: typeof t.dom === "function",
+			ssr: typeof t.ssr === "function",
+		})
+	})(%s)`, global)
+	result, err := vm.Eval(fpath, expr)
+	if err != nil {
+		return nil, fmt.Errorf("transform: unable to load %q. %w", fpath, err)
+	}
+	var desc descriptor
+	if err := json.Unmarshal([]byte(result), &desc); err != nil {
+		return nil, fmt.Errorf("transform: unable to load %q. %w", fpath, err)
+	}
+	if !isExt(desc.From) || !isExt(desc.To) {
+		return nil, fmt.Errorf("transform: %q must export the extensions it transforms \"from\" and \"to\" (e.g. \".md\")", fpath)
+	}
+	platforms := transformrt.Platforms{}
+	if desc.All {
+		platforms[transformrt.PlatformAll] = transform(vm, fpath, global, "transform", "")
+	}
+	if desc.DOM {
+		platforms[transformrt.PlatformDOM] = transform(vm, fpath, global, "dom", "dom")
+	}
+	if desc.SSR {
+		platforms[transformrt.PlatformSSR] = transform(vm, fpath, global, "ssr", "ssr")
+	}
+	if len(platforms) == 0 {
+		return nil, fmt.Errorf("transform: %q must export a transform, dom or ssr function", fpath)
+	}
+	return &transformrt.Transformable{
+		From: desc.From,
+		To:   desc.To,
+		For:  platforms,
+	}, nil
+}
+
+func isExt(ext string) bool {
+	return len(ext) > 1 && ext[0] == '.'
+}
+
+// bundle the transform with its dependencies, so it can run in the VM
+func bundle(module *gomod.Module, fpath, global string) (string, error) {
+	result := esbuild.Build(esbuild.BuildOptions{
+		EntryPoints:   []string{module.Directory(filepath.FromSlash(fpath))},
+		AbsWorkingDir: module.Directory(),
+		Format:        esbuild.FormatIIFE,
+		Platform:      esbuild.PlatformBrowser,
+		GlobalName:    global,
+		Bundle:        true,
+	})
+	if len(result.Errors) > 0 {
+		return "", esmeta.Errors(result.Errors)
+	}
+	// Expect exactly 1 output file
+	if len(result.OutputFiles) != 1 {
+		return "", fmt.Errorf("transform: expected exactly 1 output file for %q but got %d", fpath, len(result.OutputFiles))
+	}
+	return string(result.OutputFiles[0].Contents), nil
+}
+
+// input to the transform function
+type input struct {
+	Path     string `json:"path"`
+	Code     string `json:"code"`
+	Platform string `json:"platform,omitempty"`
+}
+
+// output of the transform function
+type output struct {
+	Code *string `json:"code"`
+	CSS  *string `json:"css"`
+}
+
+// transform calls the exported function to transform the file
+func transform(vm js.VM, fpath, global, fn, platform string) func(ctx context.Context, file *transformrt.File) error {
+	return func(ctx context.Context, file *transformrt.File) error {
+		// The VM can't be interrupted, so check before evaluating
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		in, err := json.Marshal(&input{
+			Path:     file.Path(),
+			Code:     string(file.Code),
+			Platform: platform,
+		})
+		if err != nil {
+			return err
+		}
+		expr := fmt.Sprintf(`;(function(t, file) {
+			t = t.default || t
+			const encode = (out) => JSON.stringify(typeof out === "string" ? { code: out } : (out || {}))
+			const out = t[%q](file)
+			return out && typeof out.then === "function" ? out.then(encode) : encode(out)
+		})(%s, %s)`, fn, global, in)
+		result, err := vm.Eval(file.Path(), expr)
+		if err != nil {
+			return fmt.Errorf("transform: %q unable to transform %q. %w", fpath, file.Path(), err)
+		}
+		var out output
+		if err := json.Unmarshal([]byte(result), &out); err != nil {
+			return fmt.Errorf("transform: %q unable to transform %q. %w", fpath, file.Path(), err)
+		} else if out.Code == nil {
+			return fmt.Errorf("transform: %q didn't return any code for %q", fpath, file.Path())
+		}
+		file.Code = []byte(*out.Code)
+		if out.CSS != nil {
+			file.CSS = []byte(*out.CSS)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,145 @@
+package transform_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/livebud/bud/framework/transform"
+	"github.com/livebud/bud/framework/transform/transformrt"
+	"github.com/livebud/bud/internal/cli/testcli"
+	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/internal/testdir"
+	"github.com/livebud/bud/internal/versions"
+	"github.com/livebud/bud/package/gomod"
+	v8 "github.com/livebud/bud/package/js/v8"
+)
+
+func TestLoad(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["transform/shout.js"] = `
+		export default {
+			from: ".txt",
+			to: ".js",
+			transform(file) {
+				return "export default " + JSON.stringify(file.code.toUpperCase())
+			},
+		}
+	`
+	td.Files["transform/platform.ts"] = `
+		export default {
+			from: ".txt",
+			to: ".txt",
+			dom: (file: { code: string }) => ({ code: file.code + " dom" }),
+			ssr: async (file: { code: string, platform: string }) => file.code + " " + file.platform,
+		}
+	`
+	is.NoErr(td.Write(ctx))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	defer vm.Close()
+	transformables, err := transform.Load(module, vm)
+	is.NoErr(err)
+	is.Equal(len(transformables), 2)
+	transformer, err := transformrt.Load(transformables...)
+	is.NoErr(err)
+	code, err := transformer.DOM.Transform(ctx, "hello.txt", "hello.js", []byte("hello"))
+	is.NoErr(err)
+	is.Equal(string(code), `export default "HELLO DOM"`)
+	code, err = transformer.SSR.Transform(ctx, "hello.txt", "hello.js", []byte("hello"))
+	is.NoErr(err)
+	is.Equal(string(code), `export default "HELLO SSR"`)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["transform/invalid.js"] = `
+		export default {
+			transform(file) { return file.code },
+		}
+	`
+	is.NoErr(td.Write(ctx))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	defer vm.Close()
+	transformables, err := transform.Load(module, vm)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `"transform/invalid.js" must export the extensions it transforms`))
+	is.Equal(transformables, nil)
+}
+
+func TestNoTransforms(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	is.NoErr(td.Write(ctx))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	defer vm.Close()
+	transformables, err := transform.Load(module, vm)
+	is.NoErr(err)
+	is.Equal(len(transformables), 0)
+}
+
+func TestViewTransform(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["transform/text.js"] = `
+		export default {
+			from: ".txt",
+			to: ".svelte",
+			transform(file) {
+				return "<p>" + file.code.trim() + "</p>"
+			},
+		}
+	`
+	td.Files["transform/greeting.js"] = `
+		export default {
+			from: ".svelte",
+			to: ".svelte",
+			transform(file) {
+				return file.code.replace("{greeting}", "hello")
+			},
+		}
+	`
+	td.Files["view/intro.txt"] = `welcome to bud`
+	td.Files["view/index.svelte"] = `
+		<script>
+			import Intro from "./intro.txt"
+		</script>
+		<h1>{greeting}</h1>
+		<Intro />
+	`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), `<h1>hello</h1>`)
+	is.In(res.Body().String(), `<p>welcome to bud</p>`)
+	res, err = app.Get("/bud/view/_index.svelte.js")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), `welcome to bud`)
+	is.NoErr(app.Close())
+}
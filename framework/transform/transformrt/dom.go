@@ -1,6 +1,10 @@
 package transformrt
 
-import esbuild "github.com/evanw/esbuild/pkg/api"
+import (
+	"context"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+)
 
 type DOM struct {
 	Map *Map
@@ -8,10 +12,10 @@ type DOM struct {
 
 var _ Transformer = (*DOM)(nil)
 
-func (d *DOM) Transform(fromPath, toPath string, code []byte) ([]byte, error) {
-	return d.Map.DOM.Transform(fromPath, toPath, code)
+func (d *DOM) Transform(ctx context.Context, fromPath, toPath string, code []byte) ([]byte, error) {
+	return d.Map.DOM.Transform(ctx, fromPath, toPath, code)
 }
 
-func (d *DOM) Plugins() []esbuild.Plugin {
-	return d.Map.DOM.Plugins()
+func (d *DOM) Plugins(ctx context.Context) []esbuild.Plugin {
+	return d.Map.DOM.Plugins(ctx)
 }
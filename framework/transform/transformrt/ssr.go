@@ -1,6 +1,10 @@
 package transformrt
 
-import esbuild "github.com/evanw/esbuild/pkg/api"
+import (
+	"context"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+)
 
 type SSR struct {
 	Map *Map
@@ -8,10 +12,10 @@ type SSR struct {
 
 var _ Transformer = (*SSR)(nil)
 
-func (d *SSR) Transform(fromPath, toPath string, code []byte) ([]byte, error) {
-	return d.Map.SSR.Transform(fromPath, toPath, code)
+func (d *SSR) Transform(ctx context.Context, fromPath, toPath string, code []byte) ([]byte, error) {
+	return d.Map.SSR.Transform(ctx, fromPath, toPath, code)
 }
 
-func (d *SSR) Plugins() []esbuild.Plugin {
-	return d.Map.SSR.Plugins()
+func (d *SSR) Plugins(ctx context.Context) []esbuild.Plugin {
+	return d.Map.SSR.Plugins(ctx)
 }
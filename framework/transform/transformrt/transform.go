@@ -1,10 +1,13 @@
 package transformrt
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 
 	esbuild "github.com/evanw/esbuild/pkg/api"
 	"github.com/livebud/bud/internal/dag"
@@ -14,6 +17,9 @@ type File struct {
 	path string
 	ext  string
 	Code []byte
+	// CSS extracted while transforming (e.g. a Svelte <style> block). Extracted
+	// CSS is bundled into stylesheets rather than injected at runtime.
+	CSS []byte
 }
 
 func (f *File) Path() string {
@@ -21,6 +27,11 @@ func (f *File) Path() string {
 	return base + f.ext
 }
 
+// Source is the path of the file before it was transformed
+func (f *File) Source() string {
+	return f.path
+}
+
 // Platform we're transforming to.
 type Platform int
 
@@ -30,7 +41,10 @@ const (
 	PlatformSSR
 )
 
-type Platforms map[Platform]func(file *File) error
+// Platforms map each platform to the function that transforms files for it.
+// Transforms receive the context of the build, so long transforms can stop
+// when the build is cancelled.
+type Platforms map[Platform]func(ctx context.Context, file *File) error
 
 type Transformable struct {
 	To   string
@@ -38,6 +52,24 @@ type Transformable struct {
 	For  Platforms
 }
 
+// Merge the transformables with the defaults. Defaults that transform between
+// the same extensions as one of the transformables are replaced, so apps can
+// override the built-in transforms.
+func Merge(transformables, defaults []*Transformable) []*Transformable {
+	replaced := map[string]bool{}
+	for _, transformable := range transformables {
+		replaced[transformable.From+">"+transformable.To] = true
+	}
+	merged := append([]*Transformable{}, transformables...)
+	for _, transformable := range defaults {
+		if transformable.From != transformable.To && replaced[transformable.From+">"+transformable.To] {
+			continue
+		}
+		merged = append(merged, transformable)
+	}
+	return merged
+}
+
 func MustLoad(transformables ...*Transformable) *Map {
 	transformer, err := Load(transformables...)
 	if err != nil {
@@ -46,6 +78,8 @@ func MustLoad(transformables ...*Transformable) *Map {
 	return transformer
 }
 
+// Load the transformables into a transformer for each platform. Conflicting
+// transforms and ambiguous routes are reported as errors.
 func Load(transformables ...*Transformable) (*Map, error) {
 	browser, err := load(PlatformDOM, transformables)
 	if err != nil {
@@ -58,7 +92,7 @@ func Load(transformables ...*Transformable) (*Map, error) {
 	return &Map{browser, node}, nil
 }
 
-func getTransform(transformable *Transformable, platform Platform) (func(file *File) error, bool) {
+func getTransform(transformable *Transformable, platform Platform) (func(ctx context.Context, file *File) error, bool) {
 	tr, ok := transformable.For[platform]
 	if ok {
 		return tr, true
@@ -70,51 +104,93 @@ func getTransform(transformable *Transformable, platform Platform) (func(file *F
 	return nil, false
 }
 
+// loaders are the extensions that esbuild can load once transformed, in order
+// of preference
+var loaders = []struct {
+	ext    string
+	loader esbuild.Loader
+}{
+	{".js", esbuild.LoaderJS},
+	{".jsx", esbuild.LoaderJSX},
+	{".ts", esbuild.LoaderTS},
+	{".tsx", esbuild.LoaderTSX},
+	{".css", esbuild.LoaderCSS},
+	{".json", esbuild.LoaderJSON},
+}
+
+func loaderOf(ext string) (esbuild.Loader, bool) {
+	for _, l := range loaders {
+		if l.ext == ext {
+			return l.loader, true
+		}
+	}
+	return esbuild.LoaderNone, false
+}
+
 func load(platform Platform, transformables []*Transformable) (*transformer, error) {
 	graph := dag.New()
-	tmap := map[string][]func(file *File) error{}
-	froms := map[string]struct{}{}
+	tmap := map[string][]func(ctx context.Context, file *File) error{}
+	var froms []string
 	// Build a dependency graph of how the transforms transform (from -> to)
 	for _, transformable := range transformables {
 		transform, ok := getTransform(transformable, platform)
 		if !ok {
 			continue
 		}
-		graph.Link(transformable.From, transformable.To)
 		key := transformable.From + ">" + transformable.To
-		froms[transformable.From] = struct{}{}
 		// We can compose transforms of the same type. For example, two
 		// svelte-to-svelte transforms. We cannot compose different types though.
 		// For example, two svelte-to-jsx transforms.
-		// TODO: Figure out what to do with the ignored transform.
 		if len(tmap[key]) > 0 && transformable.From != transformable.To {
-			continue
+			return nil, fmt.Errorf("transformrt: conflicting transforms from %q to %q", transformable.From, transformable.To)
+		}
+		graph.Set(transformable.From)
+		graph.Link(transformable.From, transformable.To)
+		if !hasExt(froms, transformable.From) {
+			froms = append(froms, transformable.From)
 		}
-		// TODO: this can be undefined
 		tmap[key] = append(tmap[key], transform)
 	}
-	// Build the full pathmap to generate the plugins
+	// Compose multiple transforms
+	index := map[string]func(ctx context.Context, file *File) error{}
+	for key, transforms := range tmap {
+		index[key] = compose(transforms)
+	}
+	// Precompute the routes between every pair of extensions
+	routes := map[string]*route{}
+	for _, from := range graph.Nodes() {
+		for to, r := range shortestRoutes(graph, index, from) {
+			routes[from+">"+to] = r
+		}
+	}
+	// Find what each extension transforms into for esbuild to load
 	pathmap := map[string]string{}
-	for from := range froms {
-		to, err := graph.ShortestPathOf(from, []string{".js", ".jsx"})
+	for _, from := range froms {
+		to, err := loaderTarget(routes, from)
 		if err != nil {
 			return nil, err
 		}
-		pathmap[from] = to[len(to)-1]
+		pathmap[from] = to
 	}
-	// Build the index to efficiently access handlers
-	// Compose multiple transforms
-	index := map[string]func(file *File) error{}
-	for key, transforms := range tmap {
-		index[key] = compose(transforms)
+	return &transformer{routes, pathmap}, nil
+}
+
+func hasExt(exts []string, ext string) bool {
+	for _, e := range exts {
+		if e == ext {
+			return true
+		}
 	}
-	return &transformer{graph, index, pathmap}, nil
+	return false
 }
 
-func compose(fns []func(file *File) error) func(file *File) error {
-	return func(file *File) error {
+func compose(fns []func(ctx context.Context, file *File) error) func(ctx context.Context, file *File) error {
+	return func(ctx context.Context, file *File) error {
 		for _, fn := range fns {
-			if err := fn(file); err != nil {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(ctx, file); err != nil {
 				return err
 			}
 		}
@@ -122,9 +198,110 @@ func compose(fns []func(file *File) error) func(file *File) error {
 	}
 }
 
+// route is a precomputed sequence of transforms from one extension to another
+type route struct {
+	hops  []string
+	steps []*step
+	// Set when there's more than one shortest route
+	ambiguous bool
+}
+
+// step transforms a file into an extension
+type step struct {
+	ext       string
+	transform func(ctx context.Context, file *File) error
+}
+
+// shortestRoutes finds the shortest routes from an extension to every
+// extension it can be transformed into
+func shortestRoutes(graph *dag.Graph, index map[string]func(ctx context.Context, file *File) error, from string) map[string]*route {
+	// Breadth-first search, counting the shortest paths to detect ambiguity
+	parents := map[string]string{}
+	depths := map[string]int{from: 0}
+	counts := map[string]int{from: 1}
+	queue := []string{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, child := range graph.Children(node) {
+			depth, seen := depths[child]
+			if !seen {
+				depths[child] = depths[node] + 1
+				parents[child] = node
+				counts[child] = counts[node]
+				queue = append(queue, child)
+				continue
+			}
+			if depth == depths[node]+1 {
+				counts[child] += counts[node]
+			}
+		}
+	}
+	routes := map[string]*route{}
+	for to := range depths {
+		// Walk back up from the target
+		hops := []string{to}
+		for node := to; node != from; {
+			node = parents[node]
+			hops = append([]string{node}, hops...)
+		}
+		// Turn the hops into pairs (e.g. [ [.svelte, .js], ...]), applying any
+		// transforms from an extension to itself along the way
+		r := &route{hops: hops, ambiguous: counts[to] > 1}
+		pairs := [][2]string{{hops[0], hops[0]}}
+		for i := 1; i < len(hops); i++ {
+			pairs = append(pairs, [2]string{hops[i-1], hops[i]})
+			pairs = append(pairs, [2]string{hops[i], hops[i]})
+		}
+		for _, pair := range pairs {
+			if transform, ok := index[pair[0]+">"+pair[1]]; ok {
+				r.steps = append(r.steps, &step{pair[1], transform})
+			}
+		}
+		routes[to] = r
+	}
+	return routes
+}
+
+// loaderTarget finds the closest extension that esbuild can load
+func loaderTarget(routes map[string]*route, from string) (string, error) {
+	var targets []string
+	shortest := 0
+	for _, l := range loaders {
+		r, ok := routes[from+">"+l.ext]
+		if !ok {
+			continue
+		}
+		if len(targets) == 0 || len(r.hops) < shortest {
+			targets = []string{l.ext}
+			shortest = len(r.hops)
+		} else if len(r.hops) == shortest {
+			targets = append(targets, l.ext)
+		}
+	}
+	if len(targets) == 0 {
+		return "", fmt.Errorf("transformrt: unable to transform %q into a file that can be loaded", from)
+	} else if len(targets) > 1 {
+		return "", fmt.Errorf("transformrt: ambiguous transforms from %q to %s", from, strings.Join(quote(targets), " or "))
+	}
+	to := targets[0]
+	if routes[from+">"+to].ambiguous {
+		return "", fmt.Errorf("transformrt: ambiguous transforms from %q to %q", from, to)
+	}
+	return to, nil
+}
+
+func quote(exts []string) []string {
+	quoted := make([]string, len(exts))
+	for i, ext := range exts {
+		quoted[i] = strconv.Quote(ext)
+	}
+	return quoted
+}
+
 type Transformer interface {
-	Transform(fromPath, toPath string, code []byte) ([]byte, error)
-	Plugins() (plugins []esbuild.Plugin)
+	Transform(ctx context.Context, fromPath, toPath string, code []byte) ([]byte, error)
+	Plugins(ctx context.Context) (plugins []esbuild.Plugin)
 }
 
 // Map aggregates all the platform-specific transformers
@@ -135,88 +312,116 @@ type Map struct {
 
 // Transformer is specific to a platform
 type transformer struct {
-	graph   *dag.Graph
-	index   map[string]func(file *File) error
+	routes  map[string]*route
 	pathmap map[string]string
 }
 
 var _ Transformer = (*transformer)(nil)
 
-// TODO: support context
-func (t *transformer) Transform(fromPath, toPath string, code []byte) ([]byte, error) {
-	fromExt := filepath.Ext(fromPath)
-	hops, err := t.graph.ShortestPath(fromExt, filepath.Ext(toPath))
+func (t *transformer) Transform(ctx context.Context, fromPath, toPath string, code []byte) ([]byte, error) {
+	file, err := t.transform(ctx, fromPath, toPath, code)
 	if err != nil {
 		return nil, err
-	} else if len(hops) == 0 {
-		return code, nil
-	}
-	// Turn the hops into pairs (e.g. [ [.svelte, .js], ...])
-	pairs := [][2]string{[2]string{hops[0], hops[0]}}
-	for i := 1; i < len(hops); i++ {
-		pairs = append(pairs, [2]string{hops[i-1], hops[i]})
-		pairs = append(pairs, [2]string{hops[i], hops[i]})
 	}
+	return file.Code, nil
+}
+
+func (t *transformer) transform(ctx context.Context, fromPath, toPath string, code []byte) (*File, error) {
+	fromExt, toExt := filepath.Ext(fromPath), filepath.Ext(toPath)
 	file := &File{
 		path: fromPath,
 		ext:  fromExt,
 		Code: code,
 	}
-	// Apply transformations over the transform pairs
-	for _, pair := range pairs {
-		// Handle .svelte -> .svelte transformations
-		key := pair[0] + ">" + pair[1]
-		if transform, ok := t.index[key]; ok {
-			if err := transform(file); err != nil {
-				return nil, err
-			}
-			// Update the extension
-			file.ext = pair[1]
+	r, ok := t.routes[fromExt+">"+toExt]
+	if !ok {
+		return nil, fmt.Errorf("transformrt: unable to transform %q into %q", fromPath, toPath)
+	} else if r.ambiguous {
+		return nil, fmt.Errorf("transformrt: ambiguous transforms from %q to %q", fromExt, toExt)
+	}
+	// Apply transformations along the route
+	for _, step := range r.steps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
+		if err := step.transform(ctx, file); err != nil {
+			return nil, err
+		}
+		// Update the extension
+		file.ext = step.ext
 	}
-	return file.Code, nil
+	return file, nil
 }
 
-func (t *transformer) Plugins() (plugins []esbuild.Plugin) {
+// Plugins load the files that need transforming. The context is passed into
+// the transforms.
+func (t *transformer) Plugins(ctx context.Context) (plugins []esbuild.Plugin) {
+	// Stylesheets extracted during this build, keyed by the path of the virtual
+	// stylesheet. Plugins share this cache so bundling the extracted CSS after
+	// the JS doesn't transform each file twice.
+	styles := &styleCache{css: map[string][]byte{}}
 	for from, to := range t.pathmap {
-		from := from
+		from, to := from, to
 		plugins = append(plugins, esbuild.Plugin{
 			Name: "transform_" + strings.TrimPrefix(from, ".") + "_to_" + strings.TrimPrefix(to, "."),
 			Setup: func(epb esbuild.PluginBuild) {
 				dir := epb.InitialOptions.AbsWorkingDir
-				// Load svelte files. Add import if not present
-				epb.OnLoad(esbuild.OnLoadOptions{Filter: `\` + from + `$`}, func(args esbuild.OnLoadArgs) (result esbuild.OnLoadResult, err error) {
-					// Read the code in
-					code, err := os.ReadFile(args.Path)
-					if err != nil {
-						return result, err
-					}
-					relPath, err := filepath.Rel(dir, args.Path)
-					if err != nil {
-						return result, err
-					}
-					fromPath := relPath
-					toPath := strings.TrimSuffix(args.Path, from) + "." + to
-					// Transform the code
-					// TODO: We wouldn't need to get the shortest path in Transform
-					// everytime, we could pre-compute these shortest paths.
-					newCode, err := t.Transform(fromPath, toPath, code)
+				// Load the files from disk that need transforming. Files within other
+				// plugins' namespaces are left to those plugins.
+				epb.OnLoad(esbuild.OnLoadOptions{Filter: `\` + from + `$`, Namespace: "file"}, func(args esbuild.OnLoadArgs) (result esbuild.OnLoadResult, err error) {
+					file, err := t.transformFile(ctx, dir, args.Path, from, to)
 					if err != nil {
 						return result, err
 					}
 					// Update the file contents
-					contents := string(newCode)
+					contents := string(file.Code)
+					// Import the extracted CSS so esbuild links it to the JS
+					if len(file.CSS) > 0 {
+						stylePath := file.path + ".css"
+						styles.Set(stylePath, file.CSS)
+						contents += "\nimport " + strconv.Quote("./"+filepath.Base(stylePath)) + "\n"
+					}
 					result.ResolveDir = filepath.Dir(args.Path)
 					result.Contents = &contents
 					// Use an appropriate loader that esbuild understands
-					switch to {
-					case ".js":
-						result.Loader = esbuild.LoaderJS
-					case ".jsx":
-						result.Loader = esbuild.LoaderJSX
-					default:
+					loader, ok := loaderOf(to)
+					if !ok {
 						return result, fmt.Errorf("transform: unhandled loader type %q", to)
 					}
+					result.Loader = loader
+					return result, nil
+				})
+				// Resolve the stylesheets extracted from the transformed files. These
+				// stylesheets are virtual, they don't exist on disk.
+				epb.OnResolve(esbuild.OnResolveOptions{Filter: `\` + from + `\.css$`}, func(args esbuild.OnResolveArgs) (result esbuild.OnResolveResult, err error) {
+					stylePath := args.Path
+					if !filepath.IsAbs(stylePath) {
+						stylePath = filepath.Join(args.ResolveDir, args.Path)
+					}
+					// Keep the path relative to the working directory
+					result.Path, err = filepath.Rel(dir, stylePath)
+					if err != nil {
+						return result, err
+					}
+					result.Namespace = "transform_css"
+					return result, nil
+				})
+				epb.OnLoad(esbuild.OnLoadOptions{Filter: `\` + from + `\.css$`, Namespace: "transform_css"}, func(args esbuild.OnLoadArgs) (result esbuild.OnLoadResult, err error) {
+					css, ok := styles.Get(args.Path)
+					if !ok {
+						// We haven't transformed this file yet during this build, so
+						// transform it to extract the stylesheet.
+						file, err := t.transformFile(ctx, dir, filepath.Join(dir, strings.TrimSuffix(args.Path, ".css")), from, to)
+						if err != nil {
+							return result, err
+						}
+						css = file.CSS
+						styles.Set(args.Path, css)
+					}
+					contents := string(css)
+					result.ResolveDir = filepath.Join(dir, filepath.Dir(args.Path))
+					result.Contents = &contents
+					result.Loader = esbuild.LoaderCSS
 					return result, nil
 				})
 			},
@@ -224,3 +429,38 @@ func (t *transformer) Plugins() (plugins []esbuild.Plugin) {
 	}
 	return plugins
 }
+
+// transformFile reads a file from disk and transforms it
+func (t *transformer) transformFile(ctx context.Context, dir, path, from, to string) (*File, error) {
+	// Read the code in
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fromPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return nil, err
+	}
+	toPath := strings.TrimSuffix(path, from) + to
+	// Transform the code
+	return t.transform(ctx, fromPath, toPath, code)
+}
+
+// styleCache caches the stylesheets extracted during a build
+type styleCache struct {
+	mu  sync.Mutex
+	css map[string][]byte
+}
+
+func (c *styleCache) Get(path string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	css, ok := c.css[path]
+	return css, ok
+}
+
+func (c *styleCache) Set(path string, css []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.css[path] = css
+}
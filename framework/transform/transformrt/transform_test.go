@@ -3,6 +3,7 @@ package transformrt_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -17,13 +18,14 @@ import (
 
 func TestTransform(t *testing.T) {
 	is := is.New(t)
+	ctx := context.Background()
 	trace := []string{}
 	transformer, err := transformrt.Load([]*transformrt.Transformable{
 		{
 			From: ".svelte",
 			To:   ".svelte",
 			For: transformrt.Platforms{
-				transformrt.PlatformAll: func(file *transformrt.File) error {
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
 					trace = append(trace, ".svelte>.svelte")
 					is.Equal(file.Path(), "index.svelte")
 					file.Code = bytes.ReplaceAll(file.Code, []byte("<h1>"), []byte("<h1 id='link'>"))
@@ -35,7 +37,7 @@ func TestTransform(t *testing.T) {
 			From: ".md",
 			To:   ".svelte",
 			For: transformrt.Platforms{
-				transformrt.PlatformAll: func(file *transformrt.File) error {
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
 					trace = append(trace, ".md>.svelte")
 					is.Equal(file.Path(), "index.md")
 					file.Code = []byte(`<h1>Hi world</h1>`)
@@ -47,7 +49,7 @@ func TestTransform(t *testing.T) {
 			From: ".svelte",
 			To:   ".js",
 			For: transformrt.Platforms{
-				transformrt.PlatformAll: func(file *transformrt.File) error {
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
 					trace = append(trace, ".svelte>.js")
 					is.Equal(file.Path(), "index.svelte")
 					file.Code = []byte(`document.body.innerHTML = "` + string(file.Code) + `"`)
@@ -57,7 +59,7 @@ func TestTransform(t *testing.T) {
 		},
 	}...)
 	is.NoErr(err)
-	result, err := transformer.SSR.Transform("index.md", "index.js", []byte(`# Hi world`))
+	result, err := transformer.SSR.Transform(ctx, "index.md", "index.js", []byte(`# Hi world`))
 	is.NoErr(err)
 	is.Equal(string(result), `document.body.innerHTML = "<h1 id='link'>Hi world</h1>"`)
 	is.Equal(len(trace), 3)
@@ -65,7 +67,7 @@ func TestTransform(t *testing.T) {
 	is.Equal(trace[1], ".svelte>.svelte")
 	is.Equal(trace[2], ".svelte>.js")
 	trace = []string{}
-	result, err = transformer.DOM.Transform("index.md", "index.js", []byte(`# Hi world`))
+	result, err = transformer.DOM.Transform(ctx, "index.md", "index.js", []byte(`# Hi world`))
 	is.NoErr(err)
 	is.Equal(string(result), `document.body.innerHTML = "<h1 id='link'>Hi world</h1>"`)
 	is.Equal(len(trace), 3)
@@ -82,7 +84,7 @@ func TestPlugins(t *testing.T) {
 			From: ".svelte",
 			To:   ".svelte",
 			For: transformrt.Platforms{
-				transformrt.PlatformAll: func(file *transformrt.File) error {
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
 					if file.Path() != "hello.svelte" {
 						return fmt.Errorf("wrong file name: %s", file.Path())
 					}
@@ -95,7 +97,7 @@ func TestPlugins(t *testing.T) {
 			From: ".md",
 			To:   ".svelte",
 			For: transformrt.Platforms{
-				transformrt.PlatformAll: func(file *transformrt.File) error {
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
 					if file.Path() != "hello.md" {
 						return fmt.Errorf("wrong file name: %s", file.Path())
 					}
@@ -108,7 +110,7 @@ func TestPlugins(t *testing.T) {
 			From: ".svelte",
 			To:   ".js",
 			For: transformrt.Platforms{
-				transformrt.PlatformAll: func(file *transformrt.File) error {
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
 					file.Code = []byte(`export default "` + string(file.Code) + `"`)
 					return nil
 				},
@@ -116,7 +118,7 @@ func TestPlugins(t *testing.T) {
 		},
 	}...)
 	is.NoErr(err)
-	plugins := transformer.SSR.Plugins()
+	plugins := transformer.SSR.Plugins(ctx)
 	is.Equal(len(plugins), 2)
 	// Create the test dir
 	dir, err := filepath.EvalSymlinks(t.TempDir())
@@ -152,13 +154,14 @@ func TestPlugins(t *testing.T) {
 
 func TestTargets(t *testing.T) {
 	is := is.New(t)
+	ctx := context.Background()
 	trace := []string{}
 	transformer, err := transformrt.Load([]*transformrt.Transformable{
 		{
 			From: ".svelte",
 			To:   ".svelte",
 			For: transformrt.Platforms{
-				transformrt.PlatformAll: func(file *transformrt.File) error {
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
 					trace = append(trace, ".svelte>.svelte")
 					is.Equal(file.Path(), "index.svelte")
 					file.Code = bytes.ReplaceAll(file.Code, []byte("<h1>"), []byte("<h1 id='link'>"))
@@ -170,7 +173,7 @@ func TestTargets(t *testing.T) {
 			From: ".md",
 			To:   ".svelte",
 			For: transformrt.Platforms{
-				transformrt.PlatformAll: func(file *transformrt.File) error {
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
 					trace = append(trace, ".md>.svelte")
 					is.Equal(file.Path(), "index.md")
 					file.Code = []byte(`<h1>Hi world</h1>`)
@@ -182,13 +185,13 @@ func TestTargets(t *testing.T) {
 			From: ".svelte",
 			To:   ".js",
 			For: transformrt.Platforms{
-				transformrt.PlatformSSR: func(file *transformrt.File) error {
+				transformrt.PlatformSSR: func(ctx context.Context, file *transformrt.File) error {
 					trace = append(trace, ".svelte>.js(ssr)")
 					is.Equal(file.Path(), "index.svelte")
 					file.Code = []byte(`export default "` + string(file.Code) + `"`)
 					return nil
 				},
-				transformrt.PlatformDOM: func(file *transformrt.File) error {
+				transformrt.PlatformDOM: func(ctx context.Context, file *transformrt.File) error {
 					trace = append(trace, ".svelte>.js(dom)")
 					is.Equal(file.Path(), "index.svelte")
 					file.Code = []byte(`document.body.innerHTML = "` + string(file.Code) + `"`)
@@ -198,7 +201,7 @@ func TestTargets(t *testing.T) {
 		},
 	}...)
 	is.NoErr(err)
-	result, err := transformer.SSR.Transform("index.md", "index.js", []byte(`# Hi world`))
+	result, err := transformer.SSR.Transform(ctx, "index.md", "index.js", []byte(`# Hi world`))
 	is.NoErr(err)
 	is.Equal(string(result), `export default "<h1 id='link'>Hi world</h1>"`)
 	is.Equal(len(trace), 3)
@@ -206,7 +209,7 @@ func TestTargets(t *testing.T) {
 	is.Equal(trace[1], ".svelte>.svelte")
 	is.Equal(trace[2], ".svelte>.js(ssr)")
 	trace = []string{}
-	result, err = transformer.DOM.Transform("index.md", "index.js", []byte(`# Hi world`))
+	result, err = transformer.DOM.Transform(ctx, "index.md", "index.js", []byte(`# Hi world`))
 	is.NoErr(err)
 	is.Equal(string(result), `document.body.innerHTML = "<h1 id='link'>Hi world</h1>"`)
 	is.Equal(len(trace), 3)
@@ -214,3 +217,191 @@ func TestTargets(t *testing.T) {
 	is.Equal(trace[1], ".svelte>.svelte")
 	is.Equal(trace[2], ".svelte>.js(dom)")
 }
+
+func TestLoaders(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	transformer, err := transformrt.Load([]*transformrt.Transformable{
+		{
+			From: ".yml",
+			To:   ".json",
+			For: transformrt.Platforms{
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
+					file.Code = []byte(`{"name": "bud"}`)
+					return nil
+				},
+			},
+		},
+		{
+			From: ".scss",
+			To:   ".css",
+			For: transformrt.Platforms{
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
+					file.Code = []byte(`h1 { color: red }`)
+					return nil
+				},
+			},
+		},
+		{
+			From: ".vue",
+			To:   ".ts",
+			For: transformrt.Platforms{
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
+					file.Code = []byte(`const greeting: string = "hi"; export default greeting`)
+					return nil
+				},
+			},
+		},
+	}...)
+	is.NoErr(err)
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	is.NoErr(err)
+	td := testdir.New(dir)
+	td.Files["index.js"] = `
+		import config from "./config.yml"
+		import greeting from "./greeting.vue"
+		import "./style.scss"
+		console.log(config.name, greeting)
+	`
+	td.Files["config.yml"] = `name: bud`
+	td.Files["greeting.vue"] = `<template>hi</template>`
+	td.Files["style.scss"] = `h1 { color: $red }`
+	is.NoErr(td.Write(ctx))
+	result := esbuild.Build(esbuild.BuildOptions{
+		EntryPoints:   []string{"index.js"},
+		AbsWorkingDir: dir,
+		Outdir:        "out",
+		Plugins:       transformer.DOM.Plugins(ctx),
+		Bundle:        true,
+	})
+	is.Equal(len(result.Errors), 0)
+	is.Equal(len(result.OutputFiles), 2)
+	for _, output := range result.OutputFiles {
+		switch filepath.Ext(output.Path) {
+		case ".js":
+			is.In(string(output.Contents), `"bud"`)
+			is.In(string(output.Contents), `var greeting = "hi";`)
+		case ".css":
+			is.In(string(output.Contents), `color: red;`)
+		default:
+			is.Fail()
+		}
+	}
+}
+
+func TestConflict(t *testing.T) {
+	is := is.New(t)
+	transformer, err := transformrt.Load([]*transformrt.Transformable{
+		{
+			From: ".svelte",
+			To:   ".js",
+			For: transformrt.Platforms{
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error { return nil },
+			},
+		},
+		{
+			From: ".svelte",
+			To:   ".js",
+			For: transformrt.Platforms{
+				transformrt.PlatformDOM: func(ctx context.Context, file *transformrt.File) error { return nil },
+			},
+		},
+	}...)
+	is.True(err != nil)
+	is.Equal(err.Error(), `transformrt: conflicting transforms from ".svelte" to ".js"`)
+	is.Equal(transformer, nil)
+}
+
+func TestAmbiguous(t *testing.T) {
+	is := is.New(t)
+	noop := transformrt.Platforms{
+		transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error { return nil },
+	}
+	// .md can become a .svelte or a .vue file before becoming JS
+	transformer, err := transformrt.Load([]*transformrt.Transformable{
+		{From: ".md", To: ".svelte", For: noop},
+		{From: ".md", To: ".vue", For: noop},
+		{From: ".svelte", To: ".js", For: noop},
+		{From: ".vue", To: ".js", For: noop},
+	}...)
+	is.True(err != nil)
+	is.Equal(err.Error(), `transformrt: ambiguous transforms from ".md" to ".js"`)
+	is.Equal(transformer, nil)
+	// .md can become JS or TypeScript in the same number of steps
+	transformer, err = transformrt.Load([]*transformrt.Transformable{
+		{From: ".md", To: ".js", For: noop},
+		{From: ".md", To: ".ts", For: noop},
+	}...)
+	is.True(err != nil)
+	is.Equal(err.Error(), `transformrt: ambiguous transforms from ".md" to ".js" or ".ts"`)
+	is.Equal(transformer, nil)
+	// Unless there's a shorter route
+	transformer, err = transformrt.Load([]*transformrt.Transformable{
+		{From: ".md", To: ".svelte", For: noop},
+		{From: ".md", To: ".vue", For: noop},
+		{From: ".svelte", To: ".js", For: noop},
+		{From: ".vue", To: ".js", For: noop},
+		{From: ".md", To: ".js", For: noop},
+	}...)
+	is.NoErr(err)
+	is.True(transformer != nil)
+}
+
+func TestMerge(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	transform := func(code string) transformrt.Platforms {
+		return transformrt.Platforms{
+			transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
+				file.Code = append(file.Code, code...)
+				return nil
+			},
+		}
+	}
+	transformables := transformrt.Merge([]*transformrt.Transformable{
+		{From: ".md", To: ".svelte", For: transform(" app")},
+		{From: ".svelte", To: ".svelte", For: transform(" app")},
+	}, []*transformrt.Transformable{
+		{From: ".md", To: ".svelte", For: transform(" builtin")},
+		{From: ".svelte", To: ".svelte", For: transform(" builtin")},
+		{From: ".svelte", To: ".js", For: transform(" builtin")},
+	})
+	is.Equal(len(transformables), 4)
+	transformer, err := transformrt.Load(transformables...)
+	is.NoErr(err)
+	code, err := transformer.DOM.Transform(ctx, "index.md", "index.js", []byte("md"))
+	is.NoErr(err)
+	// Transforms from an extension to itself are composed, not replaced
+	is.Equal(string(code), "md app app builtin builtin")
+}
+
+func TestCancel(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	transformer, err := transformrt.Load([]*transformrt.Transformable{
+		{
+			From: ".md",
+			To:   ".js",
+			For: transformrt.Platforms{
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
+					cancel()
+					return nil
+				},
+			},
+		},
+		{
+			From: ".js",
+			To:   ".js",
+			For: transformrt.Platforms{
+				transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
+					is.Fail() // Shouldn't be called after cancelling
+					return nil
+				},
+			},
+		},
+	}...)
+	is.NoErr(err)
+	code, err := transformer.SSR.Transform(ctx, "index.md", "index.js", []byte("# hi"))
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(code, nil)
+}
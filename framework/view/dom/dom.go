@@ -6,16 +6,21 @@ import (
 	_ "embed"
 	"fmt"
 	"io/fs"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/livebud/bud/package/budfs"
 
 	esbuild "github.com/evanw/esbuild/pkg/api"
+	"github.com/livebud/bud/framework/public/publicjs"
 	"github.com/livebud/bud/framework/transform/transformrt"
 	"github.com/livebud/bud/internal/entrypoint"
 	"github.com/livebud/bud/internal/esmeta"
+	"github.com/livebud/bud/internal/fingerprint"
 	"github.com/livebud/bud/internal/gotemplate"
 	"github.com/livebud/bud/package/gomod"
 )
@@ -26,6 +31,12 @@ var template string
 // generator
 var generator = gotemplate.MustParse("dom.gotext", template)
 
+//go:embed islands.gotext
+var islandsTemplate string
+
+// islandsGenerator generates the entries of pages in islands mode
+var islandsGenerator = gotemplate.MustParse("islands.gotext", islandsTemplate)
+
 // Serve node_modules
 // TODO: migrate to it's own package
 func NodeModules(module *gomod.Module) budfs.FileGenerator {
@@ -49,12 +60,7 @@ func NodeModules(module *gomod.Module) budfs.FileGenerator {
 			Plugins:    plugins,
 		})
 		if len(result.Errors) > 0 {
-			msgs := esbuild.FormatMessages(result.Errors, esbuild.FormatMessagesOptions{
-				Color:         true,
-				Kind:          esbuild.ErrorMessage,
-				TerminalWidth: 80,
-			})
-			return fmt.Errorf(strings.Join(msgs, "\n"))
+			return esmeta.Errors(result.Errors)
 		}
 		content := result.OutputFiles[0].Contents
 		// Replace require statements and updates the path on imports
@@ -73,19 +79,37 @@ func NodeModules(module *gomod.Module) budfs.FileGenerator {
 }
 
 func New(module *gomod.Module, transformer transformrt.Transformer) *Compiler {
-	return &Compiler{module, transformer}
+	return &Compiler{module: module, transformer: transformer}
 }
 
 type Compiler struct {
 	module      *gomod.Module
 	transformer transformrt.Transformer
+
+	// Graph is updated with the imports of each file generated in development,
+	// so hot reloads can find the modules affected by a change. Can be nil.
+	Graph *esmeta.Graph
+	// Assets map the public files to the hash of their contents, linking them
+	// to their fingerprinted URLs. They're set when the public files are
+	// embedded.
+	Assets map[string]string
+	// Minify the compiled scripts and stylesheets
+	Minify bool
+	// Hot connects the entrypoints to the hot reload server. Production builds
+	// leave the hot reload client out.
+	Hot bool
+	// Dev sets process.env.NODE_ENV to "development" instead of "production"
+	Dev bool
 }
 
-// Compile into a list of  views for embedding
-func (c *Compiler) Compile(ctx context.Context, fsys fs.FS) ([]esbuild.OutputFile, error) {
+// Compile into a list of views for embedding. Scripts are split into shared
+// chunks and every file is content-hashed, so they can be cached forever.
+// Stylesheets extracted from the views are bundled into one file for each page
+// and one for each shared chunk. The manifest links each page to its assets.
+func (c *Compiler) Compile(ctx context.Context, fsys fs.FS) ([]esbuild.OutputFile, entrypoint.Manifest, error) {
 	views, err := entrypoint.List(fsys, "view")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	entries := make([]esbuild.EntryPoint, len(views))
 	viewDir := filepath.Join("bud", "view") + string(filepath.Separator)
@@ -97,6 +121,9 @@ func (c *Compiler) Compile(ctx context.Context, fsys fs.FS) ([]esbuild.OutputFil
 			OutputPath: outPath,
 		}
 	}
+	// Share the transform plugins between the script and stylesheet builds, so
+	// each file is only transformed once.
+	plugins := c.transformer.Plugins(ctx)
 	// If the name starts with node_modules, trim it to allow esbuild to do
 	// the resolving. e.g. node_modules/livebud => livebud
 	result := esbuild.Build(esbuild.BuildOptions{
@@ -109,39 +136,191 @@ func (c *Compiler) Compile(ctx context.Context, fsys fs.FS) ([]esbuild.OutputFil
 		// Add "import" condition to support svelte/internal
 		// https://esbuild.github.io/api/#how-conditions-work
 		Conditions:        []string{"browser", "default", "import"},
-		Metafile:          false,
+		Metafile:          true,
 		Bundle:            true,
 		Splitting:         true,
-		MinifyIdentifiers: true,
-		MinifySyntax:      true,
-		MinifyWhitespace:  true,
+		MinifyIdentifiers: c.Minify,
+		MinifySyntax:      c.Minify,
+		MinifyWhitespace:  c.Minify,
+		Define:            define(c.Dev),
 		Plugins: append([]esbuild.Plugin{
-			domPlugin(fsys, c.module),
-		}, c.transformer.Plugins()...),
+			domPlugin(fsys, c.module, c.Hot),
+			publicjs.Plugin(fsys, c.Assets),
+		}, plugins...),
 		Write: false,
 	})
 	if len(result.Errors) > 0 {
-		msgs := esbuild.FormatMessages(result.Errors, esbuild.FormatMessagesOptions{
-			Color:         true,
-			Kind:          esbuild.ErrorMessage,
-			TerminalWidth: 80,
-		})
-		return nil, fmt.Errorf(strings.Join(msgs, "\n"))
+		return nil, nil, esmeta.Errors(result.Errors)
+	}
+	metafile, err := esmeta.Parse(result.Metafile)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Bundle the stylesheets extracted from the views
+	styleFiles, styles, err := c.compileStyles(metafile, plugins)
+	if err != nil {
+		return nil, nil, err
 	}
-	for i, outFile := range result.OutputFiles {
-		outFile := outFile
+	files := make([]esbuild.OutputFile, 0, len(result.OutputFiles)+len(styleFiles))
+	scripts := map[string]string{}
+	for _, outFile := range result.OutputFiles {
+		// Skip the stylesheets that esbuild bundled per entry. They duplicate the
+		// shared styles, so we bundle our own.
+		if filepath.Ext(outFile.Path) == ".css" {
+			continue
+		}
 		outPath := strings.TrimPrefix(outFile.Path, "/")
+		// esbuild already hashes the chunks. Entries aren't imported by other
+		// files, so they can be renamed after the fact.
 		if isEntry(outPath) {
-			outPath = strings.TrimSuffix(outPath, ".js")
+			scriptPath := fingerprint.Path(strings.TrimSuffix(outPath, ".js"), outFile.Contents)
+			scripts[outPath] = scriptPath
+			outPath = scriptPath
 		}
-		result.OutputFiles[i].Path = outPath
+		outFile.Path = outPath
+		files = append(files, outFile)
 	}
-	return result.OutputFiles, nil
+	files = append(files, styleFiles...)
+	// Link each page to its assets
+	manifest := entrypoint.Manifest{}
+	dir := c.module.Directory()
+	clients := map[string]*entrypoint.View{}
+	for _, view := range views {
+		clients[view.Client] = view
+	}
+	for key, output := range metafile.Outputs {
+		if output.EntryPoint == nil {
+			continue
+		}
+		view, ok := clients[strings.TrimPrefix(*output.EntryPoint, "dom:")]
+		if !ok {
+			continue
+		}
+		assets := linkAssets(dir, metafile, styles, key)
+		assets.Script = "/bud/view/" + scripts[outputPath(dir, key)]
+		manifest[view.Page] = assets
+	}
+	return files, manifest, nil
+}
+
+// compileStyles bundles the stylesheets extracted from the views, one for each
+// script that esbuild outputs. This mirrors how esbuild splits the scripts, so
+// styles shared between pages end up in their own stylesheet.
+func (c *Compiler) compileStyles(metafile *esmeta.File, plugins []esbuild.Plugin) (files []esbuild.OutputFile, styles map[string]string, err error) {
+	dir := c.module.Directory()
+	imports := map[string][]string{}
+	var entries []esbuild.EntryPoint
+	for key, output := range metafile.Outputs {
+		if filepath.Ext(key) != ".js" {
+			continue
+		}
+		var stylePaths []string
+		for input := range output.Inputs {
+			if strings.HasPrefix(input, "transform_css:") {
+				stylePaths = append(stylePaths, strings.TrimPrefix(input, "transform_css:"))
+			}
+		}
+		if len(stylePaths) == 0 {
+			continue
+		}
+		sort.Strings(stylePaths)
+		entryPath := "style:" + key
+		imports[entryPath] = stylePaths
+		entries = append(entries, esbuild.EntryPoint{
+			InputPath:  entryPath,
+			OutputPath: styleName(outputPath(dir, key)),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].InputPath < entries[j].InputPath
+	})
+	styles = map[string]string{}
+	if len(entries) == 0 {
+		return nil, styles, nil
+	}
+	result := esbuild.Build(esbuild.BuildOptions{
+		EntryPointsAdvanced: entries,
+		Outdir:              "/",
+		AbsWorkingDir:       dir,
+		Bundle:              true,
+		MinifySyntax:        c.Minify,
+		MinifyWhitespace:    c.Minify,
+		Plugins: append([]esbuild.Plugin{
+			stylePlugin(imports, dir),
+		}, plugins...),
+		Write: false,
+	})
+	if len(result.Errors) > 0 {
+		return nil, nil, esmeta.Errors(result.Errors)
+	}
+	// Fingerprint the stylesheets so they can be cached long-term
+	for i, entry := range entries {
+		outFile, ok := findOutput(result.OutputFiles, "/"+entry.OutputPath+".css")
+		if !ok {
+			return nil, nil, fmt.Errorf("dom: unable to find the stylesheet for %q", entry.OutputPath)
+		}
+		outFile.Path = fingerprint.Path(entry.OutputPath+".css", outFile.Contents)
+		styles[strings.TrimPrefix(entries[i].InputPath, "style:")] = outFile.Path
+		files = append(files, outFile)
+	}
+	return files, styles, nil
+}
+
+// linkAssets walks the scripts that the output statically imports, returning
+// the chunks to preload and the stylesheets to link. Stylesheets of imported
+// chunks come before the output's own stylesheet.
+func linkAssets(dir string, metafile *esmeta.File, styles map[string]string, key string) *entrypoint.Assets {
+	assets := new(entrypoint.Assets)
+	seen := map[string]bool{}
+	var walk func(path string)
+	walk = func(path string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		output, ok := metafile.Outputs[path]
+		if !ok {
+			return
+		}
+		for _, imp := range output.Imports {
+			// Dynamic imports load their own assets
+			if imp.Kind != "import-statement" {
+				continue
+			}
+			walk(imp.Path)
+		}
+		if path != key {
+			assets.Preloads = append(assets.Preloads, "/bud/view/"+outputPath(dir, path))
+		}
+		if style, ok := styles[path]; ok {
+			assets.Styles = append(assets.Styles, "/bud/view/"+style)
+		}
+	}
+	walk(key)
+	return assets
+}
+
+// outputPath converts the metafile output key into a path relative to the
+// output directory.
+func outputPath(dir, key string) string {
+	return strings.TrimPrefix(filepath.Join(dir, key), "/")
+}
+
+// styleName returns the name of the stylesheet for a script without the
+// extension.
+//
+//	e.g. _index.svelte.js.js => _index.svelte, chunk-24MBV2RM.js => chunk-24MBV2RM
+func styleName(scriptPath string) string {
+	name := strings.TrimSuffix(scriptPath, ".js")
+	if isEntry(name) {
+		name = strings.TrimSuffix(name, ".js")
+	}
+	return name
 }
 
 // GenerateDir generates a directory of compiled files
 func (c *Compiler) GenerateDir(fsys budfs.FS, dir *budfs.Dir) error {
-	files, err := c.Compile(fsys.Context(), fsys)
+	files, _, err := c.Compile(fsys.Context(), fsys)
 	if err != nil {
 		return err
 	}
@@ -158,6 +337,12 @@ func (c *Compiler) GenerateFile(fsys budfs.FS, file *budfs.File) error {
 	// If the name starts with node_modules, trim it to allow esbuild to do
 	// the resolving. e.g. node_modules/livebud => livebud
 	entryPoint := trimEntrypoint(file.Target())
+	// Stylesheets are bundled from the entrypoint's scripts
+	//   e.g. bud/view/_index.svelte.css => bud/view/_index.svelte.js
+	isStylesheet := filepath.Ext(entryPoint) == ".css" && isEntry(entryPoint)
+	if isStylesheet {
+		entryPoint = strings.TrimSuffix(entryPoint, ".css") + ".js"
+	}
 	// Check that the entrypoint exists, ignoring generated files to avoid
 	// infinite recursion
 	if !strings.HasPrefix(entryPoint, "bud/") {
@@ -169,33 +354,39 @@ func (c *Compiler) GenerateFile(fsys budfs.FS, file *budfs.File) error {
 	result := esbuild.Build(esbuild.BuildOptions{
 		EntryPoints:   []string{entryPoint},
 		AbsWorkingDir: c.module.Directory(),
-		Format:        esbuild.FormatESModule,
-		Platform:      esbuild.PlatformBrowser,
+		// Extracted stylesheets need an output directory
+		Outdir:   "/",
+		Format:   esbuild.FormatESModule,
+		Platform: esbuild.PlatformBrowser,
 		// Add "import" condition to support svelte/internal
 		// https://esbuild.github.io/api/#how-conditions-work
 		Conditions: []string{"browser", "default", "import"},
 		Metafile:   true,
 		Bundle:     true,
 		Plugins: append([]esbuild.Plugin{
-			domPlugin(fsys, c.module),
+			domPlugin(fsys, c.module, c.Hot),
+			publicjs.Plugin(fsys, c.Assets),
 			domExternalizePlugin(),
-		}, c.transformer.Plugins()...),
+		}, c.transformer.Plugins(fsys.Context())...),
 	})
 	if len(result.Errors) > 0 {
-		msgs := esbuild.FormatMessages(result.Errors, esbuild.FormatMessagesOptions{
-			Color:         true,
-			Kind:          esbuild.ErrorMessage,
-			TerminalWidth: 80,
-		})
-		return fmt.Errorf(strings.Join(msgs, "\n"))
+		return esmeta.Errors(result.Errors)
 	}
 	// if err := esmeta.Link2(dfs, result.Metafile); err != nil {
 	// 	return nil, err
 	// }
-	code := result.OutputFiles[0].Contents
-	// Replace require statements and updates the path on imports
-	code = replaceDependencyPaths(code)
-	file.Data = code
+	if isStylesheet {
+		// Pages without styles still link to their stylesheet
+		style, _ := findOutputExt(result.OutputFiles, ".css")
+		file.Data = style.Contents
+	} else {
+		script, ok := findOutputExt(result.OutputFiles, ".js")
+		if !ok {
+			return fmt.Errorf("dom: unable to find the script for %q", entryPoint)
+		}
+		// Replace require statements and updates the path on imports
+		file.Data = replaceDependencyPaths(script.Contents)
+	}
 	// Link the dependencies
 	metafile, err := esmeta.Parse(result.Metafile)
 	if err != nil {
@@ -204,9 +395,37 @@ func (c *Compiler) GenerateFile(fsys budfs.FS, file *budfs.File) error {
 	for _, dep := range metafile.Dependencies() {
 		fsys.Link(dep)
 	}
+	if c.Graph != nil {
+		c.Graph.Add(metafile)
+		// Track the files bundled into each page, so changes only reload the
+		// pages that depend on them
+		if isEntry(entryPoint) && !isStylesheet {
+			c.Graph.Entry(entryPage(entryPoint), metafile)
+		}
+	}
 	return nil
 }
 
+// findOutput finds an output file by path
+func findOutput(files []esbuild.OutputFile, path string) (esbuild.OutputFile, bool) {
+	for _, file := range files {
+		if file.Path == path {
+			return file, true
+		}
+	}
+	return esbuild.OutputFile{}, false
+}
+
+// findOutputExt finds the first output file with the extension
+func findOutputExt(files []esbuild.OutputFile, ext string) (esbuild.OutputFile, bool) {
+	for _, file := range files {
+		if filepath.Ext(file.Path) == ext {
+			return file, true
+		}
+	}
+	return esbuild.OutputFile{}, false
+}
+
 func toEntry(path string) string {
 	dir, base := filepath.Split(path)
 	return filepath.Join(dir, "_"+base) + ".js"
@@ -217,6 +436,14 @@ func isEntry(path string) bool {
 	return base[0] == '_'
 }
 
+// entryPage returns the page for an entrypoint
+//
+//	e.g. bud/view/about/_index.svelte.js => view/about/index.svelte
+func entryPage(entryPoint string) string {
+	dir, base := path.Split(strings.TrimPrefix(entryPoint, "bud/"))
+	return dir + strings.TrimSuffix(strings.TrimPrefix(base, "_"), ".js")
+}
+
 func trimEntrypoint(path string) string {
 	// Trim up node_modules so esbuild can resolve them, yet they're valid url
 	// paths on the frontend.
@@ -238,12 +465,12 @@ func trimEntrypoint(path string) string {
 	return path
 }
 
-// Build the bud/view/$page.{jsx,svelte} client-side entrypoint
-func domPlugin(fsys fs.FS, module *gomod.Module) esbuild.Plugin {
+// Build the bud/view/$page.{jsx,svelte,md,mdx} client-side entrypoint
+func domPlugin(fsys fs.FS, module *gomod.Module, hot bool) esbuild.Plugin {
 	return esbuild.Plugin{
 		Name: "dom",
 		Setup: func(epb esbuild.PluginBuild) {
-			epb.OnResolve(esbuild.OnResolveOptions{Filter: `^bud\/view\/(?:[A-Za-z\-0-9]+\/)*_[A-Za-z\-0-9]+\.(svelte|jsx)\.js$`}, func(args esbuild.OnResolveArgs) (result esbuild.OnResolveResult, err error) {
+			epb.OnResolve(esbuild.OnResolveOptions{Filter: `^bud\/view\/(?:[A-Za-z\-0-9]+\/)*_[A-Za-z\-0-9]+\.(svelte|jsx|md|mdx)\.js$`}, func(args esbuild.OnResolveArgs) (result esbuild.OnResolveResult, err error) {
 				result.Namespace = "dom"
 				result.Path = args.Path
 				return result, nil
@@ -253,7 +480,16 @@ func domPlugin(fsys fs.FS, module *gomod.Module) esbuild.Plugin {
 				if err != nil {
 					return result, err
 				}
-				code, err := generator.Generate(view)
+				// Leave out the hot reload client
+				if !hot {
+					view.Hot = ""
+				}
+				// Pages in islands mode only hydrate their islands
+				entry := generator
+				if view.Partial {
+					entry = islandsGenerator
+				}
+				code, err := entry.Generate(view)
 				if err != nil {
 					return result, err
 				}
@@ -267,6 +503,43 @@ func domPlugin(fsys fs.FS, module *gomod.Module) esbuild.Plugin {
 	}
 }
 
+// define the environment, so libraries like React can leave out their
+// development-only code in production builds
+func define(dev bool) map[string]string {
+	env := "production"
+	if dev {
+		env = "development"
+	}
+	return map[string]string{
+		"process.env.NODE_ENV": strconv.Quote(env),
+	}
+}
+
+// Generate the stylesheet entries that import the extracted stylesheets
+func stylePlugin(imports map[string][]string, dir string) esbuild.Plugin {
+	return esbuild.Plugin{
+		Name: "style",
+		Setup: func(epb esbuild.PluginBuild) {
+			epb.OnResolve(esbuild.OnResolveOptions{Filter: `^style:`}, func(args esbuild.OnResolveArgs) (result esbuild.OnResolveResult, err error) {
+				result.Namespace = "style"
+				result.Path = args.Path
+				return result, nil
+			})
+			epb.OnLoad(esbuild.OnLoadOptions{Filter: `.*`, Namespace: "style"}, func(args esbuild.OnLoadArgs) (result esbuild.OnLoadResult, err error) {
+				code := new(strings.Builder)
+				for _, stylePath := range imports[args.Path] {
+					code.WriteString("@import " + strconv.Quote("./"+stylePath) + ";\n")
+				}
+				contents := code.String()
+				result.ResolveDir = dir
+				result.Contents = &contents
+				result.Loader = esbuild.LoaderCSS
+				return result, nil
+			})
+		},
+	}
+}
+
 // Transforms the dom file imports into including the "__LIVEBUD_EXTERNAL__:" prefix
 func domExternalizePlugin() esbuild.Plugin {
 	return esbuild.Plugin{
@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/livebud/bud/package/gomod"
 
 	"github.com/livebud/bud/framework/view/dom"
+	"github.com/livebud/bud/internal/esmeta"
 	"github.com/livebud/bud/internal/is"
 	"github.com/livebud/bud/internal/testdir"
 	"github.com/livebud/bud/internal/versions"
@@ -45,7 +47,9 @@ func TestServeFile(t *testing.T) {
 	module, err := gomod.Find(dir)
 	is.NoErr(err)
 	bfs := budfs.New(module, log)
-	bfs.FileServer("bud/view", dom.New(module, transformer.DOM))
+	compiler := dom.New(module, transformer.DOM)
+	compiler.Hot = true
+	bfs.FileServer("bud/view", compiler)
 	// Read the wrapped version of index.svelte with node_modules rewritten
 	code, err := fs.ReadFile(bfs, "bud/view/_index.svelte.js")
 	is.NoErr(err)
@@ -54,7 +58,7 @@ func TestServeFile(t *testing.T) {
 	is.True(strings.Contains(string(code), `text("index")`))
 	is.True(strings.Contains(string(code), `"/bud/view/index.svelte": view_default`))
 	is.True(strings.Contains(string(code), `page: "/bud/view/index.svelte",`))
-	is.True(strings.Contains(string(code), `hot: new Hot("http://127.0.0.1:35729/bud/hot/view/index.svelte", components)`))
+	is.True(strings.Contains(string(code), `hot: new Hot("/bud/hot/view/index.svelte", components)`))
 
 	// Unwrapped version with node_modules rewritten
 	code, err = fs.ReadFile(bfs, "bud/view/index.svelte")
@@ -65,7 +69,7 @@ func TestServeFile(t *testing.T) {
 	// Unwrapped version doesn't contain wrapping
 	is.True(!strings.Contains(string(code), `"/bud/view/index.svelte": view_default`))
 	is.True(!strings.Contains(string(code), `page: "/bud/view/index.svelte",`))
-	is.True(!strings.Contains(string(code), `hot: new Hot("http://127.0.0.1:35729/bud/hot/view/index.svelte", components)`))
+	is.True(!strings.Contains(string(code), `hot: new Hot("/bud/hot/view/index.svelte", components)`))
 
 	// Read the wrapped version of about/index.svelte with node_modules rewritten
 	code, err = fs.ReadFile(bfs, "bud/view/about/_index.svelte.js")
@@ -75,7 +79,7 @@ func TestServeFile(t *testing.T) {
 	is.True(strings.Contains(string(code), `text("about")`))
 	is.True(strings.Contains(string(code), `"/bud/view/about/index.svelte": about_default`))
 	is.True(strings.Contains(string(code), `page: "/bud/view/about/index.svelte",`))
-	is.True(strings.Contains(string(code), `hot: new Hot("http://127.0.0.1:35729/bud/hot/view/about/index.svelte", components)`))
+	is.True(strings.Contains(string(code), `hot: new Hot("/bud/hot/view/about/index.svelte", components)`))
 
 	// Unwrapped version with node_modules rewritten
 	code, err = fs.ReadFile(bfs, "bud/view/about/index.svelte")
@@ -86,7 +90,7 @@ func TestServeFile(t *testing.T) {
 	// Unwrapped version doesn't contain wrapping
 	is.True(!strings.Contains(string(code), `"/bud/view/about/index.svelte": about_default`))
 	is.True(!strings.Contains(string(code), `page: "/bud/view/about/index.svelte",`))
-	is.True(!strings.Contains(string(code), `hot: new Hot("http://127.0.0.1:35729/bud/hot/view/about/index.svelte", components)`))
+	is.True(!strings.Contains(string(code), `hot: new Hot("/bud/hot/view/about/index.svelte", components)`))
 }
 
 func TestNodeModules(t *testing.T) {
@@ -128,12 +132,16 @@ func TestGenerateDir(t *testing.T) {
 	module, err := gomod.Find(dir)
 	is.NoErr(err)
 	bfs := budfs.New(module, log)
-	bfs.DirGenerator("bud/view", dom.New(module, transformer.DOM))
+	compiler := dom.New(module, transformer.DOM)
+	compiler.Minify = true
+	bfs.DirGenerator("bud/view", compiler)
 	des, err := fs.ReadDir(bfs, "bud/view")
 	is.NoErr(err)
 	is.Equal(len(des), 3)
-	is.Equal(des[0].Name(), "_index.svelte.js")
+	// Entries are content-hashed
+	is.True(indexRe.MatchString(des[0].Name()))
 	is.Equal(des[0].IsDir(), false)
+	indexName := des[0].Name()
 	is.Equal(des[1].Name(), "about")
 	is.Equal(des[1].IsDir(), true)
 	is.True(strings.HasPrefix(des[2].Name(), "chunk-"))
@@ -142,28 +150,31 @@ func TestGenerateDir(t *testing.T) {
 	des, err = fs.ReadDir(bfs, "bud/view/about")
 	is.NoErr(err)
 	is.Equal(len(des), 1)
-	is.Equal(des[0].Name(), "_index.svelte.js")
+	is.True(indexRe.MatchString(des[0].Name()))
 	is.Equal(des[0].IsDir(), false)
+	aboutName := des[0].Name()
 
-	code, err := fs.ReadFile(bfs, "bud/view/_index.svelte.js")
+	code, err := fs.ReadFile(bfs, "bud/view/"+indexName)
 	is.NoErr(err)
 	is.True(strings.Contains(string(code), `"H1"`))
 	is.True(strings.Contains(string(code), `"index"`))
 	is.True(strings.Contains(string(code), fmt.Sprintf(`from"./%s"`, chunkName)))
 	is.True(strings.Contains(string(code), `page:"/bud/view/index.svelte"`))
 	is.True(strings.Contains(string(code), `document.getElementById("bud_target")`))
-	// TODO: remove hot
-	// is.True(!strings.Contains(string(code), `hot:`))
+	// Production builds leave out the hot reload client
+	is.True(!strings.Contains(string(code), `hot:`))
+	is.True(!strings.Contains(string(code), `/bud/hot/`))
 
-	code, err = fs.ReadFile(bfs, "bud/view/about/_index.svelte.js")
+	code, err = fs.ReadFile(bfs, "bud/view/about/"+aboutName)
 	is.NoErr(err)
 	is.True(strings.Contains(string(code), `"H2"`))
 	is.True(strings.Contains(string(code), `"about"`))
 	is.True(strings.Contains(string(code), fmt.Sprintf(`from"../%s"`, chunkName)))
 	is.True(strings.Contains(string(code), `page:"/bud/view/about/index.svelte"`))
 	is.True(strings.Contains(string(code), `document.getElementById("bud_target")`))
-	// TODO: remove hot
-	// is.True(!strings.Contains(string(code), `hot:`))
+	// Production builds leave out the hot reload client
+	is.True(!strings.Contains(string(code), `hot:`))
+	is.True(!strings.Contains(string(code), `/bud/hot/`))
 
 	code, err = fs.ReadFile(bfs, fmt.Sprintf("bud/view/%s", chunkName))
 	is.NoErr(err)
@@ -171,6 +182,46 @@ func TestGenerateDir(t *testing.T) {
 	is.True(strings.Contains(string(code), `"bud_props"`))
 }
 
+var indexRe = regexp.MustCompile(`^_index\.svelte\.[0-9a-f]{8}\.js$`)
+
+func TestManifest(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = `<h1>index</h1>`
+	td.Files["view/about/index.svelte"] = `<h2>about</h2>`
+	td.NodeModules["livebud"] = "*"
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	vm, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	files, manifest, err := dom.New(module, transformer.DOM).Compile(ctx, module)
+	is.NoErr(err)
+	paths := map[string]bool{}
+	for _, file := range files {
+		paths["/bud/view/"+file.Path] = true
+	}
+	index := manifest["view/index.svelte"]
+	is.True(index != nil)
+	is.True(paths[index.Script])
+	is.True(indexRe.MatchString(filepath.Base(index.Script)))
+	// Shared chunks are preloaded
+	is.Equal(len(index.Preloads), 1)
+	is.True(paths[index.Preloads[0]])
+	is.True(strings.HasPrefix(index.Preloads[0], "/bud/view/chunk-"))
+	about := manifest["view/about/index.svelte"]
+	is.True(about != nil)
+	is.True(paths[about.Script])
+	is.True(strings.HasPrefix(about.Script, "/bud/view/about/_index.svelte."))
+	is.Equal(about.Preloads, index.Preloads)
+}
+
 func TestUpdateFile(t *testing.T) {
 	is := is.New(t)
 	log := testlog.New()
@@ -254,3 +305,212 @@ func TestUpdateFile(t *testing.T) {
 	is.True(errors.Is(err, fs.ErrNotExist))
 	is.Equal(code, nil)
 }
+
+func TestStyles(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/Nav.svelte"] = `<nav>nav</nav><style>nav { color: blue }</style>`
+	td.Files["view/index.svelte"] = `
+		<script>
+			import Nav from "./Nav.svelte";
+		</script>
+		<Nav /><h1>index</h1>
+		<style>h1 { color: red }</style>
+	`
+	td.Files["view/about/index.svelte"] = `
+		<script>
+			import Nav from "../Nav.svelte";
+		</script>
+		<Nav /><h2>about</h2>
+	`
+	td.NodeModules["livebud"] = "*"
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	vm, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	// Development stylesheets
+	bfs := budfs.New(module, log)
+	bfs.FileServer("bud/view", dom.New(module, transformer.DOM))
+	code, err := fs.ReadFile(bfs, "bud/view/_index.svelte.css")
+	is.NoErr(err)
+	is.True(strings.Contains(string(code), `color: red`))
+	is.True(strings.Contains(string(code), `color: blue`))
+	// CSS is no longer injected at runtime
+	code, err = fs.ReadFile(bfs, "bud/view/_index.svelte.js")
+	is.NoErr(err)
+	is.True(!strings.Contains(string(code), `color: red`))
+	// Embedded stylesheets are hashed and split like the scripts
+	compiler := dom.New(module, transformer.DOM)
+	compiler.Minify = true
+	files, manifest, err := compiler.Compile(ctx, module)
+	is.NoErr(err)
+	styles := map[string]string{}
+	for _, file := range files {
+		if filepath.Ext(file.Path) == ".css" {
+			styles["/bud/view/"+file.Path] = string(file.Contents)
+		}
+	}
+	is.Equal(len(styles), 3)
+	index := manifest["view/index.svelte"]
+	is.True(index != nil)
+	is.Equal(len(index.Styles), 2)
+	is.True(strings.Contains(styles[index.Styles[0]], `color:blue`))
+	is.True(strings.Contains(styles[index.Styles[1]], `color:red`))
+	about := manifest["view/about/index.svelte"]
+	is.True(about != nil)
+	is.Equal(len(about.Styles), 1)
+	is.Equal(about.Styles[0], index.Styles[0])
+}
+
+func TestFrames(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/Frame.svelte"] = `<main><slot /></main>`
+	td.Files["view/users/Frame.svelte"] = `<section><slot /></section>`
+	td.Files["view/users/Error.svelte"] = `<p>error</p>`
+	td.Files["view/users/index.svelte"] = `<h1>users</h1>`
+	td.NodeModules["livebud"] = "*"
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	vm, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	bfs := budfs.New(module, log)
+	bfs.FileServer("bud/view", dom.New(module, transformer.DOM))
+	code, err := fs.ReadFile(bfs, "bud/view/users/_index.svelte.js")
+	is.NoErr(err)
+	// Frames are mounted from the outermost frame in
+	is.True(strings.Contains(string(code), `element("main")`))
+	is.True(strings.Contains(string(code), `element("section")`))
+	is.True(strings.Contains(string(code), `element("h1")`))
+	is.True(regexp.MustCompile(`frames: \[\s*"/bud/view/Frame.svelte",\s*"/bud/view/users/Frame.svelte"\s*\]`).Match(code))
+	// Errors fallback to the nearest error view
+	is.True(strings.Contains(string(code), `element("p")`))
+	is.True(strings.Contains(string(code), `error: "/bud/view/users/Error.svelte",`))
+}
+
+func TestGraphEntries(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/Frame.svelte"] = `<main><slot /></main>`
+	td.Files["view/Nav.svelte"] = `<nav>nav</nav>`
+	td.Files["view/index.svelte"] = `<script>import Nav from "./Nav.svelte"</script><Nav />`
+	td.Files["view/about/index.svelte"] = `<h1>about</h1>`
+	td.NodeModules["livebud"] = "*"
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	vm, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	bfs := budfs.New(module, log)
+	compiler := dom.New(module, transformer.DOM)
+	compiler.Graph = esmeta.NewGraph()
+	bfs.FileServer("bud/view", compiler)
+	_, err = fs.ReadFile(bfs, "bud/view/_index.svelte.js")
+	is.NoErr(err)
+	_, err = fs.ReadFile(bfs, "bud/view/about/_index.svelte.js")
+	is.NoErr(err)
+	// Each page tracks the files bundled into it
+	is.Equal(compiler.Graph.Entries("view/Nav.svelte"), []string{"view/index.svelte"})
+	is.Equal(compiler.Graph.Entries("view/about/index.svelte"), []string{"view/about/index.svelte"})
+	is.Equal(compiler.Graph.Entries("view/Frame.svelte"), []string{"view/about/index.svelte", "view/index.svelte"})
+}
+
+func TestIslands(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/Frame.svelte"] = `<main><slot /></main><style>main { color: blue }</style>`
+	td.Files["view/Counter.island.svelte"] = `
+		<script>
+			export let count = 0
+		</script>
+		<button on:click={() => count++}>{count}</button>
+		<style>button { color: green }</style>
+	`
+	td.Files["view/blog/index.svelte"] = `
+		<script context="module">
+			export const islands = true
+		</script>
+		<script>
+			import Counter from "../Counter.island.svelte"
+		</script>
+		<h1>blog</h1>
+		<Counter count={1} />
+		<style>h1 { color: red }</style>
+	`
+	td.Files["view/index.svelte"] = `
+		<script>
+			import Counter from "./Counter.island.svelte"
+		</script>
+		<h1>index</h1>
+		<Counter />
+	`
+	td.NodeModules["livebud"] = "*"
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	vm, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	bfs := budfs.New(module, log)
+	compiler := dom.New(module, transformer.DOM)
+	compiler.Hot = true
+	bfs.FileServer("bud/view", compiler)
+	// Only the islands are bundled into pages in islands mode
+	code, err := fs.ReadFile(bfs, "bud/view/blog/_index.svelte.js")
+	is.NoErr(err)
+	is.True(strings.Contains(string(code), `from "/bud/node_modules/livebud/runtime/island"`))
+	is.True(strings.Contains(string(code), `"/bud/view/Counter.island.svelte": `))
+	is.True(strings.Contains(string(code), `element("button")`))
+	is.True(!strings.Contains(string(code), `element("h1")`))
+	is.True(!strings.Contains(string(code), `element("main")`))
+	is.True(strings.Contains(string(code), `hot: new Hot("/bud/hot/view/blog/index.svelte", components)`))
+	// The styles of the server-rendered components are still bundled
+	code, err = fs.ReadFile(bfs, "bud/view/blog/_index.svelte.css")
+	is.NoErr(err)
+	is.True(strings.Contains(string(code), `color: red`))
+	is.True(strings.Contains(string(code), `color: blue`))
+	is.True(strings.Contains(string(code), `color: green`))
+	// Other pages are hydrated in full, islands included
+	code, err = fs.ReadFile(bfs, "bud/view/_index.svelte.js")
+	is.NoErr(err)
+	is.True(strings.Contains(string(code), `element("h1")`))
+	is.True(strings.Contains(string(code), `element("button")`))
+	is.True(strings.Contains(string(code), `page: "/bud/view/index.svelte",`))
+	// Embedded pages in islands mode link to their stylesheets too
+	files, manifest, err := dom.New(module, transformer.DOM).Compile(ctx, module)
+	is.NoErr(err)
+	is.True(len(files) > 0)
+	blog := manifest["view/blog/index.svelte"]
+	is.True(blog != nil)
+	is.True(blog.Script != "")
+	is.True(len(blog.Styles) > 0)
+}
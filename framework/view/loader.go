@@ -6,6 +6,7 @@ import (
 	"path"
 
 	"github.com/livebud/bud/framework"
+	"github.com/livebud/bud/framework/public"
 	"github.com/livebud/bud/framework/view/dom"
 	"github.com/livebud/bud/framework/view/ssr"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/livebud/bud/internal/embed"
 	"github.com/livebud/bud/internal/entrypoint"
 	"github.com/livebud/bud/internal/imports"
+	"github.com/livebud/bud/internal/precompress"
 	"github.com/livebud/bud/package/budfs"
 	"github.com/livebud/bud/package/gomod"
 )
@@ -56,28 +58,49 @@ func (l *loader) Load(ctx context.Context) (state *State, err error) {
 		return nil, fs.ErrNotExist
 	}
 	if l.flag.Embed {
-		// Add SSR
-		ssrCompiler := ssr.New(l.module, l.transform.SSR)
-		ssrCode, err := ssrCompiler.Compile(ctx, l.fsys)
+		// Link the views to the fingerprinted public files
+		assets, err := public.LoadAssets(l.fsys, l.flag)
 		if err != nil {
 			return nil, err
 		}
-		state.Embeds = append(state.Embeds, &embed.File{
-			Path: "bud/view/_ssr.js",
-			Data: ssrCode,
-		})
-		// Add DOM
+		// Add DOM first, so the SSR views can link to the compiled assets
 		domCompiler := dom.New(l.module, l.transform.DOM)
-		files, err := domCompiler.Compile(ctx, l.fsys)
+		domCompiler.Assets = assets
+		domCompiler.Minify = l.flag.Minify
+		domCompiler.Hot = l.flag.Hot
+		domCompiler.Dev = l.flag.Dev
+		files, manifest, err := domCompiler.Compile(ctx, l.fsys)
 		if err != nil {
 			return nil, err
 		}
+		var clientEmbeds []*embed.File
 		for _, file := range files {
-			state.Embeds = append(state.Embeds, &embed.File{
+			clientEmbeds = append(clientEmbeds, &embed.File{
 				Path: path.Join("bud/view", file.Path),
 				Data: file.Contents,
 			})
 		}
+		// Compress the client files ahead of time, so they're not compressed on
+		// every request
+		variants, err := precompress.Compress(clientEmbeds)
+		if err != nil {
+			return nil, err
+		}
+		state.Embeds = append(state.Embeds, clientEmbeds...)
+		state.Embeds = append(state.Embeds, variants...)
+		// Add SSR
+		ssrCompiler := ssr.New(l.module, l.transform.SSR)
+		ssrCompiler.Manifest = manifest
+		ssrCompiler.Assets = assets
+		ssrCompiler.Minify = l.flag.Minify
+		ssrCode, err := ssrCompiler.Compile(ctx, l.fsys)
+		if err != nil {
+			return nil, err
+		}
+		state.Embeds = append(state.Embeds, &embed.File{
+			Path: "bud/view/_ssr.js",
+			Data: ssrCode,
+		})
 	}
 	// fmt.Println(l.Flag.Embed, l.Transform.SSR, views)
 	if l.flag.Embed {
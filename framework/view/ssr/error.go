@@ -0,0 +1,87 @@
+package ssr
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/livebud/bud/internal/sourcemap"
+	"github.com/livebud/bud/package/js"
+)
+
+// Error from rendering a view, with the stack trace mapped back to the
+// original files
+type Error struct {
+	Message string
+	Stack   string
+	// Where the error happened. The line and column are 1-based. The path is
+	// empty when the error didn't happen within one of the app's files.
+	Path   string
+	Line   int
+	Column int
+}
+
+func (e *Error) Error() string {
+	if e.Stack == "" {
+		return e.Message
+	}
+	return e.Stack
+}
+
+// Location of the error within the app's files
+func (e *Error) Location() (file string, line, column int) {
+	return e.Path, e.Line, e.Column
+}
+
+// reStackLocation matches the locations within bud/view/_ssr.js in the stack
+// trace (e.g. at create_ssr_component (_ssr.js:1204:13))
+var reStackLocation = regexp.MustCompile(`\b_ssr\.js:(\d+):(\d+)`)
+
+// Remap the stack trace of an error thrown while evaluating bud/view/_ssr.js
+// back to the original files, using the source map inlined into the script.
+// Other errors are returned as is.
+func Remap(script []byte, err error) error {
+	var jsErr *js.Error
+	if !errors.As(err, &jsErr) || jsErr.Stack == "" {
+		return err
+	}
+	sm, smErr := sourcemap.Extract(script)
+	if smErr != nil {
+		return err
+	}
+	out := &Error{Message: jsErr.Message}
+	out.Stack = reStackLocation.ReplaceAllStringFunc(jsErr.Stack, func(match string) string {
+		submatch := reStackLocation.FindStringSubmatch(match)
+		line, _ := strconv.Atoi(submatch[1])
+		column, _ := strconv.Atoi(submatch[2])
+		position, ok := sm.Find(line, column-1)
+		if !ok {
+			return match
+		}
+		source := sourcePath(position.Source)
+		// The error happened in the innermost frame within the app's files
+		if out.Path == "" && isAppFile(source) {
+			out.Path, out.Line, out.Column = source, position.Line, position.Column+1
+		}
+		return source + ":" + strconv.Itoa(position.Line) + ":" + strconv.Itoa(position.Column+1)
+	})
+	return out
+}
+
+// sourcePath makes the source map's sources relative to the module, rather
+// than to bud/view/ where _ssr.js is written. Generated files are prefixed by
+// their plugin's namespace (e.g. ssr_runtime:./bud/view/_ssr_runtime.ts).
+func sourcePath(source string) string {
+	if strings.Contains(source, ":") {
+		return source
+	}
+	return path.Join("bud/view", source)
+}
+
+func isAppFile(source string) bool {
+	return !strings.Contains(source, ":") &&
+		!strings.HasPrefix(source, "node_modules/") &&
+		!strings.HasPrefix(source, "bud/")
+}
@@ -2,38 +2,79 @@ import ReactSSR from "react-dom/server"
 import React from "react"
 
 type View = {
+  path: string
   page: any
   frames: any[]
   layout: any
   error?: any
   client: string
+  preloads?: string[]
+  styles?: string[]
 }
 
 export function createView(view: View) {
   return function ({ props, context }) {
-    let component = React.createElement(view.page, props, [])
-    for (let frame of view.frames) {
-      component = React.createElement(frame, props, component)
-    }
-    let component2 = React.createElement("div", { id: "bud_target" }, component)
     const layout = view.layout || defaultLayout
-    let component3 = React.createElement(layout, props, component2)
-    let html = ReactSSR.renderToString(component3)
+    let status = 200
+    let html = ""
+    try {
+      html = render(layout, view.page, view.frames, props)
+    } catch (err) {
+      // Fallback to the nearest error view
+      if (!view.error) {
+        throw err
+      }
+      status = 500
+      html = render(layout, view.error, view.frames, {
+        ...props,
+        error: toError(err),
+      })
+    }
     let inject = ""
+    for (let href of view.styles || []) {
+      inject += `<link rel="stylesheet" href="${href}">`
+    }
+    for (let href of view.preloads || []) {
+      inject += `<link rel="modulepreload" href="${href}">`
+    }
     const hydrate = JSON.stringify(props)
     inject += `<script id="bud_props" type="text/template" defer>${hydrate}</script>`
     inject += `<script type="module" src="${view.client}" defer></script>`
     html = html.replace("</head>", inject + `</head>`)
     return {
-      status: 200,
+      status: status,
       headers: {
         "Content-Type": "text/html",
       },
       body: html,
+      page: {
+        path: view.path,
+        client: view.client,
+        styles: view.styles,
+      },
     }
   }
 }
 
+// Render the page within its frames and layout. Frames are ordered from the
+// outermost to the innermost frame.
+function render(layout: any, page: any, frames: any[], props: any) {
+  let component = React.createElement(page, props, [])
+  for (let i = frames.length - 1; i >= 0; i--) {
+    component = React.createElement(frames[i], props, component)
+  }
+  const target = React.createElement("div", { id: "bud_target" }, component)
+  return ReactSSR.renderToString(React.createElement(layout, props, target))
+}
+
+// Errors are passed into the error view as props
+function toError(err: any) {
+  return {
+    message: err && err.message ? err.message : String(err),
+    stack: err && err.stack ? err.stack : "",
+  }
+}
+
 function defaultLayout(props) {
   return React.createElement(
     "html",
@@ -14,6 +14,7 @@ import (
 	_ "embed"
 
 	esbuild "github.com/evanw/esbuild/pkg/api"
+	"github.com/livebud/bud/framework/public/publicjs"
 	"github.com/livebud/bud/framework/transform/transformrt"
 	"github.com/livebud/bud/internal/entrypoint"
 	"github.com/livebud/bud/internal/esmeta"
@@ -27,6 +28,15 @@ type Response struct {
 	Status  int               `json:"status,omitempty"`
 	Headers map[string]string `json:"headers,omitempty"`
 	Body    string            `json:"body,omitempty"`
+	Page    *Page             `json:"page,omitempty"`
+}
+
+// Page that was rendered. The livebud runtime uses the page to render it in the
+// browser during client-side navigation.
+type Page struct {
+	Path   string   `json:"path,omitempty"`
+	Client string   `json:"client,omitempty"`
+	Styles []string `json:"styles,omitempty"`
 }
 
 func (res *Response) Write(w http.ResponseWriter) {
@@ -39,12 +49,21 @@ func (res *Response) Write(w http.ResponseWriter) {
 }
 
 func New(module *gomod.Module, transformer transformrt.Transformer) *Compiler {
-	return &Compiler{module, transformer}
+	return &Compiler{module: module, transformer: transformer}
 }
 
 type Compiler struct {
 	module      *gomod.Module
 	transformer transformrt.Transformer
+	// Manifest links the views to their compiled assets. It's set when the
+	// client has been compiled ahead of time.
+	Manifest entrypoint.Manifest
+	// Assets map the public files to the hash of their contents, linking them
+	// to their fingerprinted URLs. They're set when the public files are
+	// embedded.
+	Assets map[string]string
+	// Minify the server-rendered bundle
+	Minify bool
 }
 
 func (c *Compiler) Compile(ctx context.Context, fsys budfs.FS) ([]byte, error) {
@@ -65,23 +84,27 @@ func (c *Compiler) Compile(ctx context.Context, fsys budfs.FS) ([]byte, error) {
 		JSXFragment:   "__budReact__.Fragment",
 		Bundle:        true,
 		Metafile:      true,
+		// Minifying keeps the global name, so the bundle can still be called
+		MinifyIdentifiers: c.Minify,
+		MinifySyntax:      c.Minify,
+		MinifyWhitespace:  c.Minify,
+		// Inline the source map, so render errors can be traced back to the
+		// views. The views are read from disk when needed.
+		Sourcemap:      esbuild.SourceMapInline,
+		SourcesContent: esbuild.SourcesContentExclude,
 		Plugins: append([]esbuild.Plugin{
 			ssrPlugin(fsys, dir),
 			ssrRuntimePlugin(fsys, dir),
-			jsxPlugin(fsys, dir),
+			jsxPlugin(fsys, dir, c.Manifest),
 			jsxRuntimePlugin(fsys, dir),
 			jsxTransformPlugin(fsys, dir),
-			sveltePlugin(fsys, dir),
+			sveltePlugin(fsys, dir, c.Manifest),
 			svelteRuntimePlugin(fsys, dir),
-		}, c.transformer.Plugins()...),
+			publicjs.Plugin(fsys, c.Assets),
+		}, c.transformer.Plugins(ctx)...),
 	})
 	if len(result.Errors) > 0 {
-		msgs := esbuild.FormatMessages(result.Errors, esbuild.FormatMessagesOptions{
-			Color:         true,
-			Kind:          esbuild.ErrorMessage,
-			TerminalWidth: 80,
-		})
-		return nil, fmt.Errorf(strings.Join(msgs, "\n"))
+		return nil, esmeta.Errors(result.Errors)
 	}
 	// Expect exactly 1 output file
 	if len(result.OutputFiles) != 1 {
@@ -172,7 +195,7 @@ var jsxTemplate string
 var jsxGenerator = gotemplate.MustParse("jsx.gotext", jsxTemplate)
 
 // Generate the jsx entry file: bud/view/$page.jsx
-func jsxPlugin(osfs fs.FS, dir string) esbuild.Plugin {
+func jsxPlugin(osfs fs.FS, dir string, manifest entrypoint.Manifest) esbuild.Plugin {
 	return esbuild.Plugin{
 		Name: "jsx",
 		Setup: func(epb esbuild.PluginBuild) {
@@ -186,6 +209,7 @@ func jsxPlugin(osfs fs.FS, dir string) esbuild.Plugin {
 				if err != nil {
 					return result, err
 				}
+				manifest.Apply(view)
 				code, err := jsxGenerator.Generate(view)
 				if err != nil {
 					return result, err
@@ -234,7 +258,9 @@ func jsxTransformPlugin(osfs fs.FS, dir string) esbuild.Plugin {
 					return result, err
 				}
 				contents := string(code)
-				contents = `import * as __budReact__ from "react"` + "\n\n" + contents
+				// Keep the import on the first line, so the lines in the source map
+				// still line up with the file
+				contents = `import * as __budReact__ from "react";` + contents
 				result.ResolveDir = filepath.Dir(args.Path)
 				result.Contents = &contents
 				result.Loader = esbuild.LoaderJSX
@@ -249,12 +275,13 @@ var svelteTemplate string
 
 var svelteGenerator = gotemplate.MustParse("svelte.gotext", svelteTemplate)
 
-// Generate the svelte entry file: bud/view/$page.svelte
-func sveltePlugin(osfs fs.FS, dir string) esbuild.Plugin {
+// Generate the svelte entry file: bud/view/$page.svelte. Markdown pages are
+// rendered as Svelte too.
+func sveltePlugin(osfs fs.FS, dir string, manifest entrypoint.Manifest) esbuild.Plugin {
 	return esbuild.Plugin{
 		Name: "svelte",
 		Setup: func(epb esbuild.PluginBuild) {
-			epb.OnResolve(esbuild.OnResolveOptions{Filter: `^\./bud/view/.*\.svelte$`}, func(args esbuild.OnResolveArgs) (result esbuild.OnResolveResult, err error) {
+			epb.OnResolve(esbuild.OnResolveOptions{Filter: `^\./bud/view/.*\.(?:svelte|md|mdx)$`}, func(args esbuild.OnResolveArgs) (result esbuild.OnResolveResult, err error) {
 				result.Path = args.Path
 				result.Namespace = "svelte"
 				return result, nil
@@ -264,6 +291,7 @@ func sveltePlugin(osfs fs.FS, dir string) esbuild.Plugin {
 				if err != nil {
 					return result, err
 				}
+				manifest.Apply(view)
 				code, err := svelteGenerator.Generate(view)
 				if err != nil {
 					return result, err
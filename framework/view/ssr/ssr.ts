@@ -9,6 +9,11 @@ type Response = {
   status: number
   headers: Record<string, string>
   body: string
+  page?: {
+    path: string
+    client: string
+    styles?: string[]
+  }
 }
 
 export function renderHTML(input: Input): Response {
@@ -3,6 +3,7 @@ package ssr_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io/fs"
 	"net/http"
 	"net/http/httptest"
@@ -14,11 +15,13 @@ import (
 	"github.com/livebud/bud/framework/transform/transformrt"
 	"github.com/livebud/bud/framework/view/ssr"
 	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/internal/sourcemap"
 	"github.com/livebud/bud/internal/testdir"
 	"github.com/livebud/bud/internal/versions"
 	"github.com/livebud/bud/package/budfs"
 	"github.com/livebud/bud/package/gomod"
 	"github.com/livebud/bud/package/js"
+	"github.com/livebud/bud/package/js/goja"
 	v8 "github.com/livebud/bud/package/js/v8"
 	"github.com/livebud/bud/package/log/testlog"
 	"github.com/livebud/bud/package/svelte"
@@ -33,9 +36,9 @@ func TestSvelteHello(t *testing.T) {
 	td.Files["view/index.svelte"] = `<h1>hi world</h1>`
 	td.NodeModules["svelte"] = versions.Svelte
 	is.NoErr(td.Write(ctx))
-	vm, err := v8.Load()
+	compilerVM, err := v8.Load()
 	is.NoErr(err)
-	svelteCompiler, err := svelte.Load(vm)
+	svelteCompiler, err := svelte.Load(compilerVM)
 	is.NoErr(err)
 	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
 	module, err := gomod.Find(dir)
@@ -49,18 +52,21 @@ func TestSvelteHello(t *testing.T) {
 	is.True(strings.Contains(string(code), `create_ssr_component(`))
 	is.True(strings.Contains(string(code), `<h1>hi world</h1>`))
 	is.True(strings.Contains(string(code), `views["/"] = `))
-	result, err := vm.Eval("render.js", string(code)+`; bud.render("/", {})`)
-	is.NoErr(err)
-	var res ssr.Response
-	err = json.Unmarshal([]byte(result), &res)
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.True(strings.Contains(res.Body, `<script id="bud_props" type="text/template" defer>{}</script>`))
-	is.True(strings.Contains(res.Body, `<script type="module" src="/bud/view/_index.svelte.js" defer></script>`))
-	is.True(strings.Contains(res.Body, `<div id="bud_target">`))
-	is.True(strings.Contains(res.Body, `<h1>hi world</h1>`))
+	eachVM(t, func(t *testing.T, vm js.VM) {
+		is := is.New(t)
+		result, err := vm.Eval("render.js", string(code)+`; bud.render("/", {})`)
+		is.NoErr(err)
+		var res ssr.Response
+		err = json.Unmarshal([]byte(result), &res)
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.True(strings.Contains(res.Body, `<script id="bud_props" type="text/template" defer>{}</script>`))
+		is.True(strings.Contains(res.Body, `<script type="module" src="/bud/view/_index.svelte.js" defer></script>`))
+		is.True(strings.Contains(res.Body, `<div id="bud_target">`))
+		is.True(strings.Contains(res.Body, `<h1>hi world</h1>`))
+	})
 }
 
 func TestSvelteAwait(t *testing.T) {
@@ -116,11 +122,119 @@ func TestSvelteAwait(t *testing.T) {
 	is.True(strings.Contains(res.Body, `Loading...`))
 }
 
+func TestSvelteFetchUnsupported(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = `
+		<script>
+			let promise = fetch("http://localhost").then(res => res.text())
+		</script>
+		{#await promise}
+			Loading...
+		{/await}
+	`
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	compilerVM, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(compilerVM)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	bfs := budfs.New(module, log)
+	bfs.FileGenerator("bud/view/_ssr.js", ssr.New(module, transformer.SSR))
+	code, err := fs.ReadFile(bfs, "bud/view/_ssr.js")
+	is.NoErr(err)
+	// Goja doesn't support fetch yet, so the page fails with a clear error
+	vm, err := goja.Load()
+	is.NoErr(err)
+	_, err = render(vm, string(code), "/", map[string]interface{}{})
+	is.True(err != nil)
+	is.In(err.Error(), "fetch is not supported by the goja javascript VM")
+}
+
+func TestSvelteFrames(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/Frame.svelte"] = `<main><slot /></main>`
+	td.Files["view/users/Frame.svelte"] = `<section><slot /></section>`
+	td.Files["view/users/Error.svelte"] = `
+		<script>
+			export let error = {}
+		</script>
+		<p>{error.message}</p>
+	`
+	td.Files["view/users/index.svelte"] = `<h1>users</h1>`
+	td.Files["view/users/show.svelte"] = `
+		<script>
+			export let user = {}
+		</script>
+		<h2>{user.name.first}</h2>
+	`
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	compilerVM, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(compilerVM)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	bfs := budfs.New(module, log)
+	bfs.FileGenerator("bud/view/_ssr.js", ssr.New(module, transformer.SSR))
+	code, err := fs.ReadFile(bfs, "bud/view/_ssr.js")
+	is.NoErr(err)
+	eachVM(t, func(t *testing.T, vm js.VM) {
+		is := is.New(t)
+		// Pages are rendered within their frames from the outermost frame in
+		res, err := render(vm, string(code), "/users", map[string]interface{}{})
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.True(strings.Contains(res.Body, `<div id="bud_target"><main><section><h1>users</h1></section></main></div>`))
+		// Errors fallback to the nearest error view
+		res, err = render(vm, string(code), "/users/:id", wrap("user", map[string]interface{}{}))
+		is.NoErr(err)
+		is.Equal(res.Status, 500)
+		is.True(strings.Contains(res.Body, `<main><section><p>`))
+		is.True(strings.Contains(res.Body, `Cannot read`))
+	})
+}
+
 // Wrap props with key
 func wrap(key string, props interface{}) map[string]interface{} {
 	return map[string]interface{}{key: props}
 }
 
+// eachVM runs the test against each VM that can render the views. The views
+// are always compiled with V8.
+func eachVM(t *testing.T, test func(t *testing.T, vm js.VM)) {
+	t.Helper()
+	vms := []struct {
+		name string
+		load func() (js.VM, error)
+	}{
+		{"v8", func() (js.VM, error) { return v8.Load() }},
+		{"goja", func() (js.VM, error) { return goja.Load() }},
+	}
+	for _, vm := range vms {
+		load := vm.load
+		t.Run(vm.name, func(t *testing.T) {
+			vm, err := load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			test(t, vm)
+		})
+	}
+}
+
 func render(vm js.VM, code, path string, props interface{}) (*ssr.Response, error) {
 	input, err := json.Marshal(props)
 	if err != nil {
@@ -187,9 +301,9 @@ func TestSvelteProps(t *testing.T) {
 	`
 	td.NodeModules["svelte"] = versions.Svelte
 	is.NoErr(td.Write(ctx))
-	vm, err := v8.Load()
+	compilerVM, err := v8.Load()
 	is.NoErr(err)
-	svelteCompiler, err := svelte.Load(vm)
+	svelteCompiler, err := svelte.Load(compilerVM)
 	is.NoErr(err)
 	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
 	module, err := gomod.Find(dir)
@@ -200,75 +314,78 @@ func TestSvelteProps(t *testing.T) {
 	// Read the wrapped version of index.svelte with node_modules rewritten
 	code, err := fs.ReadFile(bfs, "bud/view/_ssr.js")
 	is.NoErr(err)
-	// index
-	type User struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
-	}
-	res, err := render(vm, string(code), "/", wrap("users", []*User{
-		{"Alice", "alice@livebud.com"},
-		{"Tom", "tom@livebud.com"},
-	}))
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.True(strings.Contains(res.Body, `<h1><!-- HTML_TAG_START -->[{"name":"Alice","email":"alice@livebud.com"},{"name":"Tom","email":"tom@livebud.com"}]<!-- HTML_TAG_END --></h1>`))
-	// show
-	res, err = render(vm, string(code), "/:id", wrap("user", &User{"Alice", "alice@livebud.com"}))
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.True(strings.Contains(res.Body, `<h2><!-- HTML_TAG_START -->{"name":"Alice","email":"alice@livebud.com"}<!-- HTML_TAG_END --></h2>`))
-	// users/index
-	res, err = render(vm, string(code), "/users", wrap("users", []*User{
-		{"Alice", "alice@livebud.com"},
-		{"Tom", "tom@livebud.com"},
-	}))
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.True(strings.Contains(res.Body, `<h3><!-- HTML_TAG_START -->[{"name":"Alice","email":"alice@livebud.com"},{"name":"Tom","email":"tom@livebud.com"}]<!-- HTML_TAG_END --></h3>`))
-	// users/show
-	res, err = render(vm, string(code), "/users/:id", wrap("user", &User{"Alice", "alice@livebud.com"}))
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.True(strings.Contains(res.Body, `<h4><!-- HTML_TAG_START -->{"name":"Alice","email":"alice@livebud.com"}<!-- HTML_TAG_END --></h4>`))
-	// posts/comments/index
-	type Comment struct {
-		Name  string `json:"name"`
-		Title string `json:"title"`
-	}
-	res, err = render(vm, string(code), "/posts/:post_id/comments", wrap("comments", []*Comment{
-		{"Alice", "first"},
-		{"Tom", "second"},
-	}))
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.True(strings.Contains(res.Body, `<h5><!-- HTML_TAG_START -->[{"name":"Alice","title":"first"},{"name":"Tom","title":"second"}]<!-- HTML_TAG_END --></h5>`))
-	// posts/comments/:id
-	res, err = render(vm, string(code), "/posts/:post_id/comments/:id", wrap("comment", &Comment{"Alice", "first"}))
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.True(strings.Contains(res.Body, `<h6><!-- HTML_TAG_START -->{"name":"Alice","title":"first"}<!-- HTML_TAG_END --></h6>`))
-	// /vip_users
-	res, err = render(vm, string(code), "/vip_users", wrap("users", []*User{
-		{"Alice", "alice@livebud.com"},
-		{"Tom", "tom@livebud.com"},
-	}))
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.In(res.Body, `<aside><!-- HTML_TAG_START -->[{"name":"Alice","email":"alice@livebud.com"},{"name":"Tom","email":"tom@livebud.com"}]<!-- HTML_TAG_END --></aside>`)
+	eachVM(t, func(t *testing.T, vm js.VM) {
+		is := is.New(t)
+		// index
+		type User struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		}
+		res, err := render(vm, string(code), "/", wrap("users", []*User{
+			{"Alice", "alice@livebud.com"},
+			{"Tom", "tom@livebud.com"},
+		}))
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.True(strings.Contains(res.Body, `<h1><!-- HTML_TAG_START -->[{"name":"Alice","email":"alice@livebud.com"},{"name":"Tom","email":"tom@livebud.com"}]<!-- HTML_TAG_END --></h1>`))
+		// show
+		res, err = render(vm, string(code), "/:id", wrap("user", &User{"Alice", "alice@livebud.com"}))
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.True(strings.Contains(res.Body, `<h2><!-- HTML_TAG_START -->{"name":"Alice","email":"alice@livebud.com"}<!-- HTML_TAG_END --></h2>`))
+		// users/index
+		res, err = render(vm, string(code), "/users", wrap("users", []*User{
+			{"Alice", "alice@livebud.com"},
+			{"Tom", "tom@livebud.com"},
+		}))
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.True(strings.Contains(res.Body, `<h3><!-- HTML_TAG_START -->[{"name":"Alice","email":"alice@livebud.com"},{"name":"Tom","email":"tom@livebud.com"}]<!-- HTML_TAG_END --></h3>`))
+		// users/show
+		res, err = render(vm, string(code), "/users/:id", wrap("user", &User{"Alice", "alice@livebud.com"}))
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.True(strings.Contains(res.Body, `<h4><!-- HTML_TAG_START -->{"name":"Alice","email":"alice@livebud.com"}<!-- HTML_TAG_END --></h4>`))
+		// posts/comments/index
+		type Comment struct {
+			Name  string `json:"name"`
+			Title string `json:"title"`
+		}
+		res, err = render(vm, string(code), "/posts/:post_id/comments", wrap("comments", []*Comment{
+			{"Alice", "first"},
+			{"Tom", "second"},
+		}))
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.True(strings.Contains(res.Body, `<h5><!-- HTML_TAG_START -->[{"name":"Alice","title":"first"},{"name":"Tom","title":"second"}]<!-- HTML_TAG_END --></h5>`))
+		// posts/comments/:id
+		res, err = render(vm, string(code), "/posts/:post_id/comments/:id", wrap("comment", &Comment{"Alice", "first"}))
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.True(strings.Contains(res.Body, `<h6><!-- HTML_TAG_START -->{"name":"Alice","title":"first"}<!-- HTML_TAG_END --></h6>`))
+		// /vip_users
+		res, err = render(vm, string(code), "/vip_users", wrap("users", []*User{
+			{"Alice", "alice@livebud.com"},
+			{"Tom", "tom@livebud.com"},
+		}))
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.In(res.Body, `<aside><!-- HTML_TAG_START -->[{"name":"Alice","email":"alice@livebud.com"},{"name":"Tom","email":"tom@livebud.com"}]<!-- HTML_TAG_END --></aside>`)
+	})
 }
 
 func TestSvelteLocalImports(t *testing.T) {
@@ -305,9 +422,9 @@ func TestSvelteLocalImports(t *testing.T) {
 	`
 	td.NodeModules["svelte"] = versions.Svelte
 	is.NoErr(td.Write(ctx))
-	vm, err := v8.Load()
+	compilerVM, err := v8.Load()
 	is.NoErr(err)
-	svelteCompiler, err := svelte.Load(vm)
+	svelteCompiler, err := svelte.Load(compilerVM)
 	is.NoErr(err)
 	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
 	module, err := gomod.Find(dir)
@@ -318,27 +435,30 @@ func TestSvelteLocalImports(t *testing.T) {
 	// Read the wrapped version of index.svelte with node_modules rewritten
 	code, err := fs.ReadFile(bfs, "bud/view/_ssr.js")
 	is.NoErr(err)
-	type Comment struct {
-		Message string `json:"message"`
-	}
-	type Story struct {
-		Title    string     `json:"title"`
-		Comments []*Comment `json:"comments"`
-	}
-	props := wrap("story", &Story{
-		Title: "first story",
-		Comments: []*Comment{
-			{Message: "first comment"},
-			{Message: "second comment"},
-		},
+	eachVM(t, func(t *testing.T, vm js.VM) {
+		is := is.New(t)
+		type Comment struct {
+			Message string `json:"message"`
+		}
+		type Story struct {
+			Title    string     `json:"title"`
+			Comments []*Comment `json:"comments"`
+		}
+		props := wrap("story", &Story{
+			Title: "first story",
+			Comments: []*Comment{
+				{Message: "first comment"},
+				{Message: "second comment"},
+			},
+		})
+		res, err := render(vm, string(code), "/:id", props)
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.Equal(len(res.Headers), 1)
+		is.Equal(res.Headers["Content-Type"], "text/html")
+		is.True(strings.Contains(res.Body, `<h1>first story</h1>`))
+		is.True(strings.Contains(res.Body, `<h2>first comment</h2><h2>second comment</h2>`))
 	})
-	res, err := render(vm, string(code), "/:id", props)
-	is.NoErr(err)
-	is.Equal(res.Status, 200)
-	is.Equal(len(res.Headers), 1)
-	is.Equal(res.Headers["Content-Type"], "text/html")
-	is.True(strings.Contains(res.Body, `<h1>first story</h1>`))
-	is.True(strings.Contains(res.Body, `<h2>first comment</h2><h2>second comment</h2>`))
 }
 
 func TestUpdateFile(t *testing.T) {
@@ -426,3 +546,132 @@ func TestUpdateFile(t *testing.T) {
 	is.NoErr(err)
 	is.True(!strings.Contains(string(code), `views["/:id"] = `), "cached version shouldn't contain /:id")
 }
+
+func TestRemap(t *testing.T) {
+	is := is.New(t)
+	script := "var bud = (() => {\n  throw new Error(\"boom\")\n})();\n" +
+		sourcemap.Inline([]byte(`{"version":3,"sources":["../../view/index.svelte","ssr_runtime:./bud/view/_ssr_runtime.ts"],"names":[],"mappings":"AAAA;ACEE"}`)) + "\n"
+	stack := "Error: boom\n    at renderHTML (_ssr.js:2:9)\n    at _ssr.js:1:1"
+	err := ssr.Remap([]byte(script), &js.Error{Message: "Error: boom", Stack: stack})
+	var renderErr *ssr.Error
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Message, "Error: boom")
+	is.Equal(renderErr.Stack, "Error: boom\n    at renderHTML (ssr_runtime:./bud/view/_ssr_runtime.ts:3:3)\n    at view/index.svelte:1:1")
+	// The error is located in the innermost frame within the app
+	file, line, column := renderErr.Location()
+	is.Equal(file, "view/index.svelte")
+	is.Equal(line, 1)
+	is.Equal(column, 1)
+	// Errors without a stack are returned as is
+	jsErr := &js.Error{Message: "SyntaxError: Unexpected token"}
+	is.Equal(ssr.Remap([]byte(script), jsErr), jsErr)
+	// Scripts without a source map
+	jsErr = &js.Error{Message: "Error: boom", Stack: stack}
+	is.Equal(ssr.Remap([]byte("var bud = {}"), jsErr), jsErr)
+}
+
+func TestSvelteErrorStack(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = "<script lang=\"ts\">\n" +
+		"  type User = {\n" +
+		"    name: string\n" +
+		"  }\n" +
+		"  export let user: User = { name: \"\" }\n" +
+		"  throw new Error(\"unable to render \" + user.name)\n" +
+		"</script>\n" +
+		"<h1>{user.name}</h1>\n"
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	compilerVM, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(compilerVM)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	bfs := budfs.New(module, log)
+	bfs.FileGenerator("bud/view/_ssr.js", ssr.New(module, transformer.SSR))
+	code, err := fs.ReadFile(bfs, "bud/view/_ssr.js")
+	is.NoErr(err)
+	eachVM(t, func(t *testing.T, vm js.VM) {
+		is := is.New(t)
+		_, err := vm.Eval("_ssr.js", string(code)+`; bud.render("/", {"user":{"name":"alice"}})`)
+		is.True(err != nil)
+		// The stack points into the component, before it was preprocessed
+		err = ssr.Remap(code, err)
+		var renderErr *ssr.Error
+		is.True(errors.As(err, &renderErr))
+		is.Equal(renderErr.Message, "Error: unable to render alice")
+		is.Equal(renderErr.Path, "view/index.svelte")
+		is.Equal(renderErr.Line, 6)
+		is.In(renderErr.Error(), "view/index.svelte:6:")
+		is.True(!strings.Contains(renderErr.Error(), "_ssr.js"))
+	})
+}
+
+func TestSvelteIslands(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/Counter.island.svelte"] = `
+		<script>
+			export let count = 0
+		</script>
+		<button on:click={() => count++}>{count}</button>
+	`
+	td.Files["view/blog/index.svelte"] = `
+		<script context="module">
+			export const islands = true
+		</script>
+		<script>
+			import Counter from "../Counter.island.svelte"
+			export let title = ""
+		</script>
+		<h1>{title}</h1>
+		<Counter count={1} />
+	`
+	td.Files["view/index.svelte"] = `
+		<script>
+			import Counter from "./Counter.island.svelte"
+		</script>
+		<Counter count={2} />
+	`
+	td.NodeModules["svelte"] = versions.Svelte
+	is.NoErr(td.Write(ctx))
+	compilerVM, err := v8.Load()
+	is.NoErr(err)
+	svelteCompiler, err := svelte.Load(compilerVM)
+	is.NoErr(err)
+	transformer := transformrt.MustLoad(svelte.NewTransformable(svelteCompiler))
+	module, err := gomod.Find(dir)
+	is.NoErr(err)
+	bfs := budfs.New(module, log)
+	bfs.FileGenerator("bud/view/_ssr.js", ssr.New(module, transformer.SSR))
+	code, err := fs.ReadFile(bfs, "bud/view/_ssr.js")
+	is.NoErr(err)
+	eachVM(t, func(t *testing.T, vm js.VM) {
+		is := is.New(t)
+		// Islands are rendered within markers along with their props
+		res, err := render(vm, string(code), "/blog", wrap("title", "blog"))
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.In(res.Body, `<h1>blog</h1>`)
+		is.In(res.Body, `<bud-island style="display:contents" data-component="/bud/view/Counter.island.svelte" data-props="{&quot;count&quot;:1}"><button>1</button></bud-island>`)
+		is.In(res.Body, `<script type="module" src="/bud/view/blog/_index.svelte.js" defer></script>`)
+		// Pages in islands mode are loaded in full rather than navigated to
+		is.Equal(res.Page, nil)
+		// Islands within other pages are hydrated with the rest of the page
+		res, err = render(vm, string(code), "/", map[string]interface{}{})
+		is.NoErr(err)
+		is.Equal(res.Status, 200)
+		is.In(res.Body, `<button>2</button>`)
+		is.True(!strings.Contains(res.Body, `bud-island`))
+		is.True(res.Page != nil)
+	})
+}
@@ -284,19 +284,57 @@ var require_jsesc = __commonJS({
 
 // svelte.ts
 var import_jsesc = __toESM(require_jsesc());
+var partial = false;
+function island(path, component) {
+  return {
+    ...component,
+    $$render(result, props, bindings, slots, context) {
+      const html = component.$$render(result, props, bindings, slots, context);
+      if (!partial) {
+        return html;
+      }
+      const data = escapeAttribute(JSON.stringify(props || {}));
+      return `<bud-island style="display:contents" data-component="${path}" data-props="${data}">${html}</bud-island>`;
+    }
+  };
+}
+function escapeAttribute(value) {
+  return value.replace(/&/g, "&amp;").replace(/"/g, "&quot;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+}
 function createView(view) {
   view.layout = view.layout || defaultLayout;
   return function({ props, context }) {
-    const page = view.page.render(props);
-    let css = page.css.code;
-    let html = page.html;
-    let head = page.head;
+    let status = 200;
+    let html = "";
+    let head = "";
+    partial = !!view.islands;
+    try {
+      const page = renderStack(view.page, view.frames, props);
+      html = page.html;
+      head = page.head;
+    } catch (err) {
+      if (!view.error) {
+        throw err;
+      }
+      status = 500;
+      const page = renderStack(view.error, view.frames, {
+        ...props,
+        error: toError(err)
+      });
+      html = page.html;
+      head = page.head;
+    } finally {
+      partial = false;
+    }
+    let links = (view.styles || []).map((href) => `<link rel="stylesheet" href="${href}">`).join("");
+    links += (view.preloads || []).map((href) => `<link rel="modulepreload" href="${href}">`).join("");
     const hydrate = (0, import_jsesc.default)(props, { isScriptContext: true, json: true });
     const layout = view.layout.render(props, {
       head: function() {
         return `
           ${head}
-          <style>#bud{}${css}</style>
+          ${links}
+          <style>#bud{}</style>
           <script id="bud_props" type="text/template" defer>${hydrate}<\/script>
           <script type="module" src="${view.client}" defer><\/script>
         `;
@@ -307,14 +345,39 @@ function createView(view) {
     });
     html = layout.html.replace("#bud{}", layout.css.code);
     return {
-      status: 200,
+      status,
       headers: {
         "Content-Type": "text/html"
       },
-      body: html
+      body: html,
+      page: view.islands ? void 0 : {
+        path: view.path,
+        client: view.client,
+        styles: view.styles
+      }
     };
   };
 }
+function renderStack(page, frames, props) {
+  let rendered = page.render(props);
+  let html = rendered.html;
+  let head = rendered.head;
+  for (let i = frames.length - 1; i >= 0; i--) {
+    const inner = html;
+    rendered = frames[i].render(props, {
+      $$slots: { default: () => inner }
+    });
+    html = rendered.html;
+    head = rendered.head + head;
+  }
+  return { html, head };
+}
+function toError(err) {
+  return {
+    message: err && err.message ? err.message : String(err),
+    stack: err && err.stack ? err.stack : ""
+  };
+}
 var defaultLayout = {
   render(props, slots) {
     return {
@@ -624,5 +687,6 @@ summary {
 }
 `;
 export {
-  createView
+  createView,
+  island
 };
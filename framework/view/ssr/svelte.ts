@@ -1,32 +1,91 @@
 import jsesc from 'jsesc'
 
 type View = {
+  path: string
   page: any
   frames: any[]
   layout: any
   error?: any
   client: string
+  preloads?: string[]
+  styles?: string[]
+  // Only hydrate the islands within the page
+  islands?: boolean
+}
+
+// Whether the page that's rendering is in islands mode. Rendering is
+// synchronous, so only one page renders at a time.
+let partial = false
+
+// island wraps an interactive component, so it can be found and hydrated on
+// its own within pages in islands mode. Elsewhere, the component renders as
+// usual, since the whole page is hydrated.
+export function island(path: string, component: any) {
+  return {
+    ...component,
+    $$render(result: any, props: any, bindings: any, slots: any, context: any) {
+      const html = component.$$render(result, props, bindings, slots, context)
+      if (!partial) {
+        return html
+      }
+      const data = escapeAttribute(JSON.stringify(props || {}))
+      return `<bud-island style="display:contents" data-component="${path}" data-props="${data}">${html}</bud-island>`
+    },
+  }
+}
+
+function escapeAttribute(value: string) {
+  return value
+    .replace(/&/g, "&amp;")
+    .replace(/"/g, "&quot;")
+    .replace(/</g, "&lt;")
+    .replace(/>/g, "&gt;")
 }
 
 // TODO:
 // - Test custom layouts
-// - Support frames
-// - Support default errors
-// - Support custom errors
 export function createView(view: View) {
   view.layout = view.layout || defaultLayout
   return function ({ props, context }) {
-    const page = view.page.render(props)
-    let css = page.css.code
-    let html = page.html
-    let head = page.head
+    let status = 200
+    let html = ""
+    let head = ""
+    partial = !!view.islands
+    try {
+      const page = renderStack(view.page, view.frames, props)
+      html = page.html
+      head = page.head
+    } catch (err) {
+      // Fallback to the nearest error view
+      if (!view.error) {
+        throw err
+      }
+      status = 500
+      const page = renderStack(view.error, view.frames, {
+        ...props,
+        error: toError(err),
+      })
+      html = page.html
+      head = page.head
+    } finally {
+      partial = false
+    }
+    // Page styles are bundled into stylesheets by the client
+    let links = (view.styles || [])
+      .map((href) => `<link rel="stylesheet" href="${href}">`)
+      .join("")
+    // Preload the chunks the client imports to avoid a request waterfall
+    links += (view.preloads || [])
+      .map((href) => `<link rel="modulepreload" href="${href}">`)
+      .join("")
     // Render the layout
     const hydrate = jsesc(props, { isScriptContext: true, json: true })
     const layout = view.layout.render(props, {
       head: function () {
         return `
           ${head}
-          <style>#bud{}${css}</style>
+          ${links}
+          <style>#bud{}</style>
           <script id="bud_props" type="text/template" defer>${hydrate}</script>
           <script type="module" src="${view.client}" defer></script>
         `
@@ -37,15 +96,49 @@ export function createView(view: View) {
     })
     html = layout.html.replace("#bud{}", layout.css.code)
     return {
-      status: 200,
+      status: status,
       headers: {
         "Content-Type": "text/html",
       },
       body: html,
+      // Pages in islands mode aren't rendered during client-side navigation,
+      // so the browser loads them in full instead
+      page: view.islands
+        ? undefined
+        : {
+            path: view.path,
+            client: view.client,
+            styles: view.styles,
+          },
     }
   }
 }
 
+// Render the page within its frames. Frames are ordered from the outermost to
+// the innermost frame.
+function renderStack(page: any, frames: any[], props: any) {
+  let rendered = page.render(props)
+  let html = rendered.html
+  let head = rendered.head
+  for (let i = frames.length - 1; i >= 0; i--) {
+    const inner = html
+    rendered = frames[i].render(props, {
+      $$slots: { default: () => inner },
+    })
+    html = rendered.html
+    head = rendered.head + head
+  }
+  return { html, head }
+}
+
+// Errors are passed into the error view as props
+function toError(err: any) {
+  return {
+    message: err && err.message ? err.message : String(err),
+    stack: err && err.stack ? err.stack : "",
+  }
+}
+
 const defaultLayout = {
   render(props, slots) {
     return {
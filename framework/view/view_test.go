@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -88,6 +89,242 @@ func TestHello(t *testing.T) {
 	is.NoErr(app.Close())
 }
 
+func TestStyleOnlyChange(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "" }
+	`
+	td.Files["view/index.svelte"] = `<h1>hello</h1><style>h1 { color: red }</style>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	hot, err := app.Hot("/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	defer hot.Close()
+	// Only change the styles
+	indexFile := filepath.Join(dir, "view/index.svelte")
+	is.NoErr(os.WriteFile(indexFile, []byte(`<h1>hello</h1><style>h1 { color: blue }</style>`), 0644))
+	app.Ready(ctx)
+	// Stylesheets are swapped without reloading the scripts
+	event, err := hot.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "css")
+	is.Equal(string(event.Data), `{"stylesheets":["/bud/"]}`)
+	res, err := app.Get("/bud/view/_index.svelte.css")
+	is.NoErr(err)
+	is.In(res.Body().String(), "color: blue")
+	// Changing the markup reloads the scripts
+	is.NoErr(os.WriteFile(indexFile, []byte(`<h1>hi</h1><style>h1 { color: blue }</style>`), 0644))
+	app.Ready(ctx)
+	event, err = hot.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "")
+	is.In(string(event.Data), `{"scripts":["/bud/view/index.svelte?ts=`)
+	is.NoErr(app.Close())
+}
+
+func TestHotNewSelector(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "" }
+	`
+	td.Files["view/index.svelte"] = `<h1>hello</h1><p>world</p><style>h1 { color: red }</style>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	hot, err := app.Hot("/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	defer hot.Close()
+	// Style the paragraph, which wasn't styled before
+	indexFile := filepath.Join(dir, "view/index.svelte")
+	is.NoErr(os.WriteFile(indexFile, []byte(`<h1>hello</h1><p>world</p><style>h1 { color: red } p { color: blue }</style>`), 0644))
+	app.Ready(ctx)
+	// The paragraph needs the scoped class, so the scripts are reloaded
+	event, err := hot.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "")
+	is.In(string(event.Data), `{"scripts":["/bud/view/index.svelte?ts=`)
+	is.NoErr(app.Close())
+}
+
+func TestPageTargetedUpdate(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "" }
+	`
+	td.Files["controller/about/controller.go"] = `
+		package about
+		type Controller struct {}
+		func (c *Controller) Index() string { return "" }
+	`
+	td.Files["view/index.svelte"] = `<h1>index</h1>`
+	td.Files["view/about/index.svelte"] = `<h1>about</h1>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	// Load both pages, so bud knows what they depend on
+	_, err = app.Get("/bud/view/_index.svelte.js")
+	is.NoErr(err)
+	_, err = app.Get("/bud/view/about/_index.svelte.js")
+	is.NoErr(err)
+	indexHot, err := app.Hot("/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	defer indexHot.Close()
+	aboutHot, err := app.Hot("/bud/hot/view/about/index.svelte")
+	is.NoErr(err)
+	defer aboutHot.Close()
+	// Changing the about page only updates the about page
+	aboutFile := filepath.Join(dir, "view/about/index.svelte")
+	is.NoErr(os.WriteFile(aboutFile, []byte(`<h1>about us</h1>`), 0644))
+	app.Ready(ctx)
+	event, err := aboutHot.Next(ctx)
+	is.NoErr(err)
+	is.In(string(event.Data), `"modules":["view/about/index.svelte"]`)
+	// The index page only hears about its own changes
+	indexFile := filepath.Join(dir, "view/index.svelte")
+	is.NoErr(os.WriteFile(indexFile, []byte(`<h1>home</h1>`), 0644))
+	app.Ready(ctx)
+	event, err = indexHot.Next(ctx)
+	is.NoErr(err)
+	is.In(string(event.Data), `"modules":["view/index.svelte"]`)
+	is.NoErr(app.Close())
+}
+
+func TestCompileErrorOverlay(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "" }
+	`
+	td.Files["view/index.svelte"] = `<h1>hello</h1>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	hot, err := app.Hot("/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	defer hot.Close()
+	// Break the component
+	indexFile := filepath.Join(dir, "view/index.svelte")
+	is.NoErr(os.WriteFile(indexFile, []byte("<h1>hello</h1>\n<p>world</h1>"), 0644))
+	app.Ready(ctx)
+	res, err := app.Get("/bud/view/_index.svelte.js")
+	is.NoErr(err)
+	is.Equal(res.Status(), 500)
+	// The compile error is sent to the browser's overlay
+	for {
+		event, err := hot.Next(ctx)
+		is.NoErr(err)
+		if event.Type != "overlay" {
+			continue
+		}
+		is.In(string(event.Data), `"file":"view/index.svelte","line":2,"column":9`)
+		is.In(string(event.Data), `attempted to close an element that was not open`)
+		break
+	}
+	is.NoErr(app.Close())
+}
+
+func TestRunMinifiedDev(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = `<h1>hello</h1>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run", "--minify")
+	is.NoErr(err)
+	defer app.Close()
+	res, err := app.Get("/bud/view/_index.svelte.js")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	// Minifying doesn't turn off development mode
+	is.In(res.Body().String(), "SvelteComponentDev")
+	is.NoErr(app.Close())
+}
+
+func TestRenderErrorOverlay(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "" }
+	`
+	td.Files["view/index.svelte"] = "<script>\n  export let name = \"\"\n  throw new Error(\"unable to render\")\n</script>\n<h1>{name}</h1>\n"
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	hot, err := app.Hot("/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	defer hot.Close()
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 500)
+	// The stack trace points at the component rather than the bundle
+	body := res.Body().String()
+	is.In(body, `Error: unable to render`)
+	is.In(body, `view/index.svelte:3:`)
+	is.In(body, `3:   throw new Error("unable to render")`)
+	is.True(!strings.Contains(body, `_ssr.js:`))
+	// The render error is sent to the browser's overlay
+	for {
+		event, err := hot.Next(ctx)
+		is.NoErr(err)
+		if event.Type != "overlay" {
+			continue
+		}
+		is.In(string(event.Data), `"file":"view/index.svelte","line":3`)
+		is.In(string(event.Data), `unable to render`)
+		is.In(string(event.Data), `"frame":`)
+		break
+	}
+	is.NoErr(app.Close())
+}
+
 func TestHelloEmbed(t *testing.T) {
 	is := is.New(t)
 	ctx := context.Background()
@@ -137,11 +374,14 @@ func TestHelloEmbed(t *testing.T) {
 	`))
 	is.In(res.Body().String(), "<h1>hello</h1>")
 	// Try the entrypoint
-	res, err = app.Get("/bud/view/_index.svelte.js")
+	entryPath, err := findEntry("/", res.Body().String())
+	is.NoErr(err)
+	res, err = app.Get(entryPath)
 	is.NoErr(err)
 	is.NoErr(res.DiffHeaders(`
 		HTTP/1.1 200 OK
 		Accept-Ranges: bytes
+		Cache-Control: public, max-age=31536000, immutable
 		Content-Type: application/javascript
 	`))
 	is.In(res.Body().String(), "bud_target")
@@ -150,6 +390,16 @@ func TestHelloEmbed(t *testing.T) {
 
 var chunkRe = regexp.MustCompile(`chunk-[A-Za-z0-9]+\.js`)
 
+var entryRe = regexp.MustCompile(`<script type="module" src="(/bud/view/[^"]+\.js)"`)
+
+func findEntry(name, html string) (string, error) {
+	match := entryRe.FindStringSubmatch(html)
+	if match == nil {
+		return "", fmt.Errorf("unable to find an entry in %q", name)
+	}
+	return match[1], nil
+}
+
 func findChunk(name, src string) (string, error) {
 	chunks := chunkRe.FindAllString(src, -1)
 	if len(chunks) == 0 {
@@ -188,11 +438,15 @@ func TestChunks(t *testing.T) {
 	`))
 	is.In(res.Body().String(), "<h1>index</h1>")
 	// Try the index entrypoint
-	res, err = app.Get("/bud/view/_index.svelte.js")
+	entryPath, err := findEntry("/", res.Body().String())
+	is.NoErr(err)
+	is.True(strings.HasPrefix(entryPath, "/bud/view/_index.svelte."))
+	res, err = app.Get(entryPath)
 	is.NoErr(err)
 	is.NoErr(res.DiffHeaders(`
 		HTTP/1.1 200 OK
 		Accept-Ranges: bytes
+		Cache-Control: public, max-age=31536000, immutable
 		Content-Type: application/javascript
 	`))
 	is.In(res.Body().String(), "bud_target")
@@ -205,29 +459,84 @@ func TestChunks(t *testing.T) {
 		Content-Type: text/html
 	`))
 	is.In(res.Body().String(), "<h1>show</h1>")
+	// The shared chunk is preloaded
+	is.In(res.Body().String(), `<link rel="modulepreload" href="/bud/view/chunk-`)
 	// Try the show entrypoint
-	res, err = app.Get("/bud/view/_show.svelte.js")
+	entryPath, err = findEntry("/10", res.Body().String())
+	is.NoErr(err)
+	is.True(strings.HasPrefix(entryPath, "/bud/view/_show.svelte."))
+	res, err = app.Get(entryPath)
 	is.NoErr(err)
 	is.NoErr(res.DiffHeaders(`
 		HTTP/1.1 200 OK
 		Accept-Ranges: bytes
+		Cache-Control: public, max-age=31536000, immutable
 		Content-Type: application/javascript
 	`))
 	is.In(res.Body().String(), "bud_target")
 	// Ensure the code's been split and find the name of the chunk
-	chunkName, err := findChunk("bud/view/_show.svelte.js", res.Body().String())
+	chunkName, err := findChunk(entryPath, res.Body().String())
 	is.NoErr(err)
 	res, err = app.Get("/bud/view/" + chunkName)
 	is.NoErr(err)
 	is.NoErr(res.DiffHeaders(`
 		HTTP/1.1 200 OK
 		Accept-Ranges: bytes
+		Cache-Control: public, max-age=31536000, immutable
 		Content-Type: application/javascript
 	`))
 	is.In(res.Body().String(), "bud_props")
 	is.NoErr(app.Close())
 }
 
+func TestNavigate(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "" }
+		func (c *Controller) Show(id string) string { return id }
+	`
+	td.Files["view/index.svelte"] = `<a href="/10">10</a>`
+	td.Files["view/show.svelte"] = `<script>export let _string = ""</script><h1>{_string}</h1>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	// Request the page's data, like the runtime does during navigation
+	req, err := app.GetRequest("/10")
+	is.NoErr(err)
+	req.Header.Set("Accept", "application/vnd.bud.page+json")
+	res, err := app.Do(req)
+	is.NoErr(err)
+	is.NoErr(res.DiffHeaders(`
+		HTTP/1.1 200 OK
+		Content-Type: application/vnd.bud.page+json
+		Vary: Accept
+	`))
+	is.In(res.Body().String(), `"path":"/bud/view/show.svelte"`)
+	is.In(res.Body().String(), `"client":"/bud/view/_show.svelte.js"`)
+	is.In(res.Body().String(), `"props":{"_string":"10"}`)
+	// Browsers still get HTML
+	req, err = app.GetRequest("/10")
+	is.NoErr(err)
+	req.Header.Set("Accept", "text/html,*/*;q=0.8")
+	res, err = app.Do(req)
+	is.NoErr(err)
+	is.In(res.Body().String(), "<h1>10</h1>")
+	// JSON requests get the controller's response
+	res, err = app.GetJSON("/10")
+	is.NoErr(err)
+	is.Equal(res.Body().String(), `"10"`)
+	is.NoErr(app.Close())
+}
+
 func TestConsoleLog(t *testing.T) {
 	is := is.New(t)
 	ctx := context.Background()
@@ -454,3 +763,181 @@ func TestSvelteImportFromSvelte(t *testing.T) {
 	is.In(res.Body().String(), "<h1>The Time</h1>")
 	is.NoErr(app.Close())
 }
+
+func TestMarkdownView(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) About() {}
+		func (c *Controller) Index() string { return "" }
+	`
+	td.Files["view/about.md"] = dedent.Dedent(`
+		---
+		title: About us
+		description: Everything about us
+		---
+		# {title}
+
+		We **like** markdown.
+	`)
+	td.Files["view/index.svelte"] = `
+		<script>
+			import About, { metadata } from "./about.md"
+		</script>
+		<a href="/about">{metadata.title}</a>
+	`
+	td.Files["view/Frame.svelte"] = `<main><slot /></main>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	hot, err := app.Hot("/bud/hot/view/about.md")
+	is.NoErr(err)
+	defer hot.Close()
+	res, err := app.Get("/about")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	body := res.Body().String()
+	// Pages inherit the nearest frame
+	is.In(body, "<main>")
+	// Braces aren't expressions in markdown
+	is.In(body, "<h1>{title}</h1>")
+	is.In(body, "<p>We <strong>like</strong> markdown.</p>")
+	// The front matter is added to the head
+	is.In(body, "<title>About us</title>")
+	is.In(body, `<meta name="description" content="Everything about us">`)
+	is.In(body, `src="/bud/view/_about.md.js"`)
+	// Pages can link to the metadata of other pages
+	res, err = app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), `<a href="/about">About us</a>`)
+	// The client is compiled from markdown too
+	res, err = app.Get("/bud/view/_about.md.js")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), "like")
+	// Change the markdown
+	is.NoErr(os.WriteFile(filepath.Join(dir, "view/about.md"), []byte("# Changed"), 0644))
+	is.NoErr(app.Ready(ctx))
+	event, err := hot.Next(ctx)
+	is.NoErr(err)
+	is.In(string(event.Data), `{"scripts":["/bud/view/about.md?ts=`)
+	res, err = app.Get("/about")
+	is.NoErr(err)
+	is.In(res.Body().String(), "<h1>Changed</h1>")
+	is.NoErr(app.Close())
+}
+
+func TestMDXView(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() {}
+		func (c *Controller) Show(id string) (title string) {
+			return "From the controller"
+		}
+	`
+	td.Files["view/index.mdx"] = dedent.Dedent(`
+		---
+		title: Posts
+		---
+		# {title}
+	`)
+	td.Files["view/show.mdx"] = dedent.Dedent(`
+		---
+		title: From the front matter
+		---
+		import Counter from "./Counter.svelte"
+
+		# {title || "Untitled"}
+
+		<Counter count={2} />
+	`)
+	td.Files["view/Counter.svelte"] = `
+		<script>
+			export let count = 0
+		</script>
+		<button>{count} clicks</button>
+	`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run", "--embed")
+	is.NoErr(err)
+	defer app.Close()
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), "<h1>Posts</h1>")
+	res, err = app.Get("/10")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	// Props take precedence over the front matter
+	is.In(res.Body().String(), "<h1>From the controller</h1>")
+	is.In(res.Body().String(), "<button>2 clicks</button>")
+	is.NoErr(app.Close())
+}
+
+func TestTypeScriptView(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	// A tiny stand-in for sass that expands $variables
+	td.Files["transform/scss.js"] = `
+		export default {
+			from: ".scss",
+			to: ".css",
+			transform(file) {
+				return file.code.replace(/\$red/g, "#ff0000")
+			},
+		}
+	`
+	td.Files["view/index.svelte"] = `
+		<script lang="ts">
+			import Counter from "./Counter.svelte"
+			export let greeting: string = "hello"
+			const shout = (s: string): string => s.toUpperCase()
+		</script>
+		<h1>{shout(greeting)}</h1>
+		<Counter />
+		<style lang="scss">
+			h1 { color: $red }
+		</style>
+	`
+	td.Files["view/Counter.svelte"] = `<button>0 clicks</button>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	app, err := cli.Start(ctx, "run")
+	is.NoErr(err)
+	defer app.Close()
+	res, err := app.Get("/")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), "HELLO</h1>")
+	is.In(res.Body().String(), "<button>0 clicks</button>")
+	res, err = app.Get("/bud/view/_index.svelte.js")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.True(!strings.Contains(res.Body().String(), ": string"))
+	res, err = app.Get("/bud/view/_index.svelte.css")
+	is.NoErr(err)
+	is.Equal(res.Status(), 200)
+	is.In(res.Body().String(), "#ff0000")
+	is.NoErr(app.Close())
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io/fs"
 	"net/http"
+	"path"
 	"strings"
 
+	"github.com/livebud/bud/framework/controller/controllerrt/request"
 	"github.com/livebud/bud/framework/view/ssr"
+	"github.com/livebud/bud/internal/precompress"
 	"github.com/livebud/bud/package/budhttp"
 	"github.com/livebud/bud/package/js"
 	"github.com/livebud/bud/package/log"
@@ -55,8 +58,7 @@ func (s *liveServer) Middleware(next http.Handler) http.Handler {
 			return
 		}
 		// Maintain support to resolve and run "/bud/node_modules/livebud/runtime".
-		if strings.HasPrefix(r.URL.Path, "/bud/node_modules/") ||
-			strings.HasSuffix(r.URL.Path, ".svelte") {
+		if strings.HasPrefix(r.URL.Path, "/bud/node_modules/") || isComponent(r.URL.Path) {
 			w.Header().Set("Content-Type", "application/javascript")
 		}
 		http.ServeContent(w, r, r.URL.Path, stat.ModTime(), file)
@@ -65,10 +67,25 @@ func (s *liveServer) Middleware(next http.Handler) http.Handler {
 
 func (s *liveServer) Handler(route string, props interface{}) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if request.AcceptsPage(r) {
+			s.respondPage(w, route, props)
+			return
+		}
 		s.respond(w, route, props)
 	})
 }
 
+// respondPage responds with the page data for client-side navigation
+func (s *liveServer) respondPage(w http.ResponseWriter, path string, props interface{}) {
+	res, err := s.render(path, props)
+	if err != nil {
+		s.log.Error("view: render error", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePage(w, res, props)
+}
+
 // Respond is a convenience function for render
 func (s *liveServer) respond(w http.ResponseWriter, path string, props interface{}) {
 	res, err := s.render(path, props)
@@ -104,6 +121,11 @@ type staticServer struct {
 
 var _ Server = (*staticServer)(nil)
 
+// Open an embedded file. This allows the embedded views to be exported.
+func (s *staticServer) Open(name string) (fs.File, error) {
+	return s.fsys.Open(name)
+}
+
 // Map is a convenience function for the common case of passing a map of props
 // into a view
 type Map map[string]interface{}
@@ -137,7 +159,7 @@ func (s *staticServer) render(path string, props interface{}) (*ssr.Response, er
 	expr := fmt.Sprintf(`%s; bud.render(%q, %s)`, script, path, propBytes)
 	result, err := s.vm.Eval("_ssr.js", expr)
 	if err != nil {
-		return nil, err
+		return nil, ssr.Remap(script, err)
 	}
 	// Unmarshal the response
 	res := new(ssr.Response)
@@ -168,26 +190,92 @@ func (s *staticServer) Middleware(next http.Handler) http.Handler {
 // Handler returns a handler for a specific server-side route
 func (s *staticServer) Handler(route string, props interface{}) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if request.AcceptsPage(r) {
+			s.respondPage(w, route, props)
+			return
+		}
 		s.respond(w, route, props)
 	})
 }
 
+// respondPage responds with the page data for client-side navigation
+func (s *staticServer) respondPage(w http.ResponseWriter, path string, props interface{}) {
+	res, err := s.render(path, props)
+	if err != nil {
+		s.log.Error("view: render error", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePage(w, res, s.wrapProps(path, props))
+}
+
+// page data used by the livebud runtime to render the page in the browser
+type page struct {
+	*ssr.Page
+	Props interface{} `json:"props"`
+}
+
+// writePage writes the page data out. Responses that didn't render a page
+// (e.g. errors) are not acceptable, causing the runtime to fallback to a full
+// page load.
+func writePage(w http.ResponseWriter, res *ssr.Response, props interface{}) {
+	header := w.Header()
+	header.Set("Vary", "Accept")
+	if res.Page == nil || res.Status != http.StatusOK {
+		http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return
+	}
+	body, err := json.Marshal(&page{res.Page, props})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	header.Set("Content-Type", request.PageType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
 func (s *staticServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
-	file, err := s.hfs.Open(r.URL.Path)
+	// Serve the compressed variant if the browser accepts it
+	file, err := precompress.Open(w, r, s.hfs, r.URL.Path)
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		s.log.Error("view: open error", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer file.Close()
 	stat, err := file.Stat()
 	if err != nil {
 		s.log.Error("view: stat error", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if stat.IsDir() {
+		http.Error(w, fs.ErrNotExist.Error(), http.StatusNotFound)
+		return
+	}
 	// Maintain support to resolve and run "/bud/node_modules/livebud/runtime".
 	if strings.HasPrefix(r.URL.Path, "/bud/node_modules/") {
-		w.Header().Add("Content-Type", "text/javascript")
+		w.Header().Set("Content-Type", "text/javascript")
+	}
+	// Embedded views are content-hashed, so they never change
+	if strings.HasPrefix(r.URL.Path, "/bud/view/") {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
 	}
 	http.ServeContent(w, r, r.URL.Path, stat.ModTime(), file)
 }
+
+// isComponent returns true for the components that are compiled into JS when
+// they're requested in development
+func isComponent(urlPath string) bool {
+	switch path.Ext(urlPath) {
+	case ".svelte", ".md", ".mdx":
+		return true
+	default:
+		return false
+	}
+}
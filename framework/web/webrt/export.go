@@ -0,0 +1,175 @@
+package webrt
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/livebud/bud/package/router"
+)
+
+// NewExporter creates an exporter that renders the app's pages with handler.
+// Servers that embed files (e.g. the view and public servers) also implement
+// fs.FS and have their files copied into the export.
+func NewExporter(handler http.Handler, router *router.Router, servers ...interface{}) *Exporter {
+	exporter := &Exporter{handler: handler, router: router}
+	for _, server := range servers {
+		if fsys, ok := server.(fs.FS); ok {
+			exporter.files = append(exporter.files, fsys)
+		}
+	}
+	return exporter
+}
+
+// Exporter prerenders the app into a static site
+type Exporter struct {
+	handler http.Handler
+	router  *router.Router
+	files   []fs.FS
+}
+
+// Report of what was exported
+type Report struct {
+	Pages   []string // Paths to the prerendered pages
+	Skipped []*Skip  // Routes that couldn't be prerendered
+}
+
+// Skip is a route that couldn't be prerendered
+type Skip struct {
+	Route  string
+	Reason string
+}
+
+// Export crawls every static GET route, along with the given URLs, and writes
+// the rendered HTML and embedded files into dir.
+func (e *Exporter) Export(ctx context.Context, dir string, urls ...string) (*Report, error) {
+	report := new(Report)
+	pages := map[string]bool{}
+	var paths []string
+	for _, route := range e.router.Routes() {
+		if route.Method != http.MethodGet {
+			continue
+		}
+		if route.Dynamic() {
+			// Dynamic routes are only exported through the URLs passed in
+			if !matchesAny(route, urls) {
+				report.Skipped = append(report.Skipped, &Skip{
+					Route:  route.Path,
+					Reason: "route has parameters, but no URLs were given for it",
+				})
+			}
+			continue
+		}
+		if !pages[route.Path] {
+			pages[route.Path] = true
+			paths = append(paths, route.Path)
+		}
+	}
+	for _, url := range urls {
+		if !pages[url] {
+			pages[url] = true
+			paths = append(paths, url)
+		}
+	}
+	for _, urlPath := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		html, reason := e.render(urlPath)
+		if reason != "" {
+			report.Skipped = append(report.Skipped, &Skip{
+				Route:  urlPath,
+				Reason: reason,
+			})
+			continue
+		}
+		if err := writeFile(dir, pagePath(urlPath), html); err != nil {
+			return nil, err
+		}
+		report.Pages = append(report.Pages, urlPath)
+	}
+	// Copy over the embedded files
+	for _, fsys := range e.files {
+		if err := copyFiles(fsys, dir); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(report.Pages)
+	return report, nil
+}
+
+// render the page at urlPath, returning a reason if it can't be prerendered
+func (e *Exporter) render(urlPath string) (html []byte, reason string) {
+	req := httptest.NewRequest(http.MethodGet, urlPath, nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	e.handler.ServeHTTP(rec, req)
+	res := rec.Result()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Sprintf("responded with status %d", res.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" {
+		return nil, fmt.Sprintf("responded with %q rather than HTML", mediaType)
+	}
+	return rec.Body.Bytes(), ""
+}
+
+// matchesAny returns true if any of the URLs match the route
+func matchesAny(route *router.Route, urls []string) bool {
+	matcher := router.New()
+	matched := false
+	matcher.Get(route.Path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matched = true
+	}))
+	for _, url := range urls {
+		matcher.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// pagePath converts the URL path into an HTML file path
+//
+//	e.g. / => index.html, /about => about/index.html
+func pagePath(urlPath string) string {
+	return path.Join(strings.TrimPrefix(urlPath, "/"), "index.html")
+}
+
+func copyFiles(fsys fs.FS, dir string) error {
+	return fs.WalkDir(fsys, ".", func(fpath string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if de.IsDir() {
+			return nil
+		}
+		// The server-side renderer isn't needed by the browser
+		if fpath == "bud/view/_ssr.js" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, fpath)
+		if err != nil {
+			return err
+		}
+		// Public files are served from the root
+		return writeFile(dir, strings.TrimPrefix(fpath, "public/"), data)
+	})
+}
+
+func writeFile(dir, fpath string, data []byte) error {
+	fullpath := filepath.Join(dir, filepath.FromSlash(fpath))
+	if err := os.MkdirAll(filepath.Dir(fullpath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fullpath, data, 0644)
+}
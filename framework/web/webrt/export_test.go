@@ -0,0 +1,76 @@
+package webrt_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/livebud/bud/framework/web/webrt"
+	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/package/router"
+	"github.com/livebud/bud/package/virtual"
+)
+
+func html(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(body + r.URL.Query().Get("id")))
+	})
+}
+
+// embedServer embeds files like the view and public servers
+type embedServer struct {
+	virtual.Tree
+}
+
+func TestExport(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	router := router.New()
+	is.NoErr(router.Get("/", html("index")))
+	is.NoErr(router.Get("/about", html("about")))
+	is.NoErr(router.Get("/posts/:id", html("post ")))
+	is.NoErr(router.Get("/users/:id", html("user ")))
+	is.NoErr(router.Post("/users", html("create")))
+	is.NoErr(router.Get("/api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})))
+	is.NoErr(router.Get("/broken", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	})))
+	server := &embedServer{virtual.Tree{
+		"bud/view/_index.svelte.3f9a2c1d.js": &virtual.File{Data: []byte("index.js")},
+		"bud/view/_ssr.js":                   &virtual.File{Data: []byte("ssr.js")},
+		"public/favicon.ico":                 &virtual.File{Data: []byte("favicon")},
+	}}
+	exporter := webrt.NewExporter(router, router, server, "not a filesystem")
+	report, err := exporter.Export(ctx, dir, "/posts/1", "/posts/2")
+	is.NoErr(err)
+	is.Equal(report.Pages, []string{"/", "/about", "/posts/1", "/posts/2"})
+	is.Equal(len(report.Skipped), 3)
+	is.Equal(report.Skipped[0].Route, "/users/:id")
+	is.Equal(report.Skipped[1].Route, "/api")
+	is.Equal(report.Skipped[1].Reason, `responded with "application/json" rather than HTML`)
+	is.Equal(report.Skipped[2].Route, "/broken")
+	is.Equal(report.Skipped[2].Reason, "responded with status 500")
+	// Check the written files
+	read := func(path string) string {
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		is.NoErr(err)
+		return string(data)
+	}
+	is.Equal(read("index.html"), "index")
+	is.Equal(read("about/index.html"), "about")
+	is.Equal(read("posts/1/index.html"), "post 1")
+	is.Equal(read("posts/2/index.html"), "post 2")
+	is.Equal(read("bud/view/_index.svelte.3f9a2c1d.js"), "index.js")
+	is.Equal(read("favicon.ico"), "favicon")
+	_, err = os.Stat(filepath.Join(dir, "bud/view/_ssr.js"))
+	is.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "users"))
+	is.True(os.IsNotExist(err))
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	"github.com/livebud/bud/internal/current"
+	"github.com/livebud/bud/internal/diskcache"
+	"github.com/livebud/bud/internal/esmeta"
 	"github.com/livebud/bud/internal/pubsub"
 	"golang.org/x/mod/semver"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/livebud/bud/framework/app"
 	"github.com/livebud/bud/framework/controller"
 	"github.com/livebud/bud/framework/public"
+	"github.com/livebud/bud/framework/transform"
 	"github.com/livebud/bud/framework/transform/transformrt"
 	"github.com/livebud/bud/framework/view"
 	"github.com/livebud/bud/framework/view/dom"
@@ -25,10 +28,13 @@ import (
 	"github.com/livebud/bud/package/commander"
 	"github.com/livebud/bud/package/di"
 	"github.com/livebud/bud/package/gomod"
+	"github.com/livebud/bud/package/js"
+	"github.com/livebud/bud/package/js/goja"
 	v8 "github.com/livebud/bud/package/js/v8"
 	"github.com/livebud/bud/package/log"
 	"github.com/livebud/bud/package/log/console"
 	"github.com/livebud/bud/package/log/filter"
+	"github.com/livebud/bud/package/markdown"
 	"github.com/livebud/bud/package/parser"
 	"github.com/livebud/bud/package/socket"
 	"github.com/livebud/bud/package/svelte"
@@ -118,7 +124,30 @@ func Log(stderr io.Writer, logFilter string) (log.Interface, error) {
 	return log.New(handler), nil
 }
 
-func FileSystem(ctx context.Context, log log.Interface, module *gomod.Module, flag *framework.Flag, in *Input) (*budfs.FileSystem, error) {
+// VM loads the javascript VM by name
+func VM(name string) (js.VM, error) {
+	switch name {
+	case "", "v8":
+		vm, err := v8.Load()
+		if err != nil {
+			return nil, err
+		}
+		return vm, nil
+	case "goja":
+		vm, err := goja.Load()
+		if err != nil {
+			return nil, err
+		}
+		return vm, nil
+	default:
+		return nil, fmt.Errorf("bud: unknown javascript VM %q", name)
+	}
+}
+
+// FileSystem loads the generator filesystem. The graph is updated with the
+// imports of the views served in development and can be nil. The bus sends
+// compiler warnings to the browser in development and can also be nil.
+func FileSystem(ctx context.Context, log log.Interface, module *gomod.Module, flag *framework.Flag, in *Input, graph *esmeta.Graph, bus pubsub.Publisher) (*budfs.FileSystem, error) {
 	bfs := budfs.New(module, log)
 	parser := parser.New(bfs, module)
 	injector := di.New(bfs, log, module, parser)
@@ -130,21 +159,49 @@ func FileSystem(ctx context.Context, log log.Interface, module *gomod.Module, fl
 	if err != nil {
 		return nil, err
 	}
-	transforms, err := transformrt.Load(svelte.NewTransformable(svelteCompiler))
+	svelteCompiler.Dev = flag.Dev
+	svelteCompiler.Hot = flag.Hot
+	svelteCompiler.Log = log
+	svelteCompiler.Bus = bus
+	svelteCompiler.Strict = flag.Strict
+	// Cache the compiled components, so restarting doesn't recompile them
+	svelteCompiler.Cache = diskcache.New(module.Directory("bud", "cache", "svelte"), svelteCacheSize)
+	// The app and plugin transforms replace the built-in transforms between the
+	// same extensions
+	transformables, err := transform.Load(module, vm)
 	if err != nil {
 		return nil, err
 	}
+	builtins := append(markdown.NewTransformables(), svelte.NewTransformable(svelteCompiler))
+	transforms, err := transformrt.Load(transformrt.Merge(transformables, builtins)...)
+	if err != nil {
+		return nil, err
+	}
+	// Preprocess Svelte styles with the app and plugin transforms, so SCSS can
+	// be compiled by a transform from ".scss" to ".css"
+	svelteCompiler.Preprocessors["scss"] = svelte.Transformed(transforms.SSR, ".scss", ".css")
+	svelteCompiler.Preprocessors["sass"] = svelte.Transformed(transforms.SSR, ".sass", ".css")
 	bfs.FileGenerator("bud/internal/app/main.go", app.New(injector, module, flag))
 	bfs.FileGenerator("bud/internal/app/web/web.go", web.New(module, parser))
 	bfs.FileGenerator("bud/internal/app/controller/controller.go", controller.New(injector, module, parser))
 	bfs.FileGenerator("bud/internal/app/view/view.go", view.New(module, transforms, flag))
 	bfs.FileGenerator("bud/internal/app/public/public.go", public.New(flag, module))
-	bfs.FileGenerator("bud/view/_ssr.js", ssr.New(module, transforms.SSR))
-	bfs.FileServer("bud/view", dom.New(module, transforms.DOM))
+	ssrCompiler := ssr.New(module, transforms.SSR)
+	ssrCompiler.Minify = flag.Minify
+	bfs.FileGenerator("bud/view/_ssr.js", ssrCompiler)
+	domCompiler := dom.New(module, transforms.DOM)
+	domCompiler.Graph = graph
+	domCompiler.Hot = flag.Hot
+	domCompiler.Dev = flag.Dev
+	bfs.FileServer("bud/view", domCompiler)
 	bfs.FileServer("bud/node_modules", dom.NodeModules(module))
+	bfs.FileServer("bud/public", public.Images(flag))
 	return bfs, nil
 }
 
+// svelteCacheSize is the most that compiled components can take up on disk
+const svelteCacheSize = 128 << 20
+
 // EnsureVersionAlignment ensures that the CLI and runtime versions are aligned.
 // If they're not aligned, the CLI will correct the go.mod file to align them.
 func EnsureVersionAlignment(ctx context.Context, module *gomod.Module, budVersion string) error {
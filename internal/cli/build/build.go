@@ -2,6 +2,8 @@ package build
 
 import (
 	"context"
+	"fmt"
+	"os/exec"
 
 	"github.com/livebud/bud/framework"
 	"github.com/livebud/bud/internal/cli/bud"
@@ -23,10 +25,19 @@ type Command struct {
 	bud  *bud.Command
 	in   *bud.Input
 	Flag *framework.Flag
+
+	// Static exports the app into a static site
+	Static    bool
+	StaticDir string
+	URLs      []string
 }
 
 // Run the build command
 func (c *Command) Run(ctx context.Context) error {
+	// Static sites are served without bud, so the assets must be embedded
+	if c.Static && !c.Flag.Embed {
+		return fmt.Errorf("build: --static requires --embed")
+	}
 	// Find go.mod
 	module, err := bud.Module(c.bud.Dir)
 	if err != nil {
@@ -41,7 +52,7 @@ func (c *Command) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	bfs, err := bud.FileSystem(ctx, log, module, c.Flag, c.in)
+	bfs, err := bud.FileSystem(ctx, log, module, c.Flag, c.in, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -50,5 +61,21 @@ func (c *Command) Run(ctx context.Context) error {
 		return err
 	}
 	builder := gobuild.New(module)
-	return builder.Build(ctx, "bud/internal/app/main.go", "bud/app")
+	if err := builder.Build(ctx, "bud/internal/app/main.go", "bud/app"); err != nil {
+		return err
+	}
+	if !c.Static {
+		return nil
+	}
+	// Export the static site using the app we just built
+	args := []string{"export", "--dir", c.StaticDir}
+	for _, url := range c.URLs {
+		args = append(args, "--url", url)
+	}
+	cmd := exec.CommandContext(ctx, module.Directory("bud", "app"), args...)
+	cmd.Dir = module.Directory()
+	cmd.Stdout = c.in.Stdout
+	cmd.Stderr = c.in.Stderr
+	cmd.Env = c.in.Env
+	return cmd.Run()
 }
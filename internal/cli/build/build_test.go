@@ -2,11 +2,15 @@ package build_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/livebud/bud/internal/cli/testcli"
 	"github.com/livebud/bud/internal/is"
 	"github.com/livebud/bud/internal/testdir"
+	"github.com/livebud/bud/internal/versions"
 )
 
 func TestBuildEmpty(t *testing.T) {
@@ -44,3 +48,152 @@ func TestBuildTwice(t *testing.T) {
 	is.Equal(result.Stderr(), "")
 	is.NoErr(td.Exists("bud/app"))
 }
+
+func TestBuildStatic(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["controller/controller.go"] = `
+		package controller
+		type Controller struct {}
+		func (c *Controller) Index() string { return "" }
+		func (c *Controller) Show(id string) string { return id }
+	`
+	td.Files["view/index.svelte"] = `<h1>index</h1>`
+	td.Files["view/show.svelte"] = `<script>export let _string = ""</script><h1>show {_string}</h1>`
+	td.Files["public/robots.txt"] = `User-agent: *`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	result, err := cli.Run(ctx, "build", "--static", "--url=/10")
+	is.NoErr(err)
+	is.Equal(result.Stdout(), "")
+	is.NoErr(td.Exists("bud/app"))
+	is.NoErr(td.Exists("bud/static/index.html"))
+	is.NoErr(td.Exists("bud/static/10/index.html"))
+	is.NoErr(td.Exists("bud/static/robots.txt"))
+	is.NoErr(td.Exists("bud/static/favicon.ico"))
+	is.NoErr(td.NotExists("bud/static/bud/view/_ssr.js"))
+	index, err := os.ReadFile(filepath.Join(dir, "bud/static/index.html"))
+	is.NoErr(err)
+	is.In(string(index), "<h1>index</h1>")
+	show, err := os.ReadFile(filepath.Join(dir, "bud/static/10/index.html"))
+	is.NoErr(err)
+	is.In(string(show), "<h1>show 10</h1>")
+}
+
+func TestBuildStaticWithoutEmbed(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	_, err := cli.Run(ctx, "build", "--static", "--embed=false")
+	is.True(err != nil)
+	is.In(err.Error(), "--static requires --embed")
+}
+
+func TestBuildProduction(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = `<script>export let greeting = "hello"</script><h1>{greeting}</h1>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	_, err := cli.Run(ctx, "build", "--static")
+	is.NoErr(err)
+	scripts, err := filepath.Glob(filepath.Join(dir, "bud/static/bud/view/_index.svelte.*.js"))
+	is.NoErr(err)
+	is.Equal(len(scripts), 1)
+	script, err := os.ReadFile(scripts[0])
+	is.NoErr(err)
+	// Components are compiled for production without the hot reload client
+	is.True(!strings.Contains(string(script), "SvelteComponentDev"))
+	is.True(!strings.Contains(string(script), "__bud_register__"))
+	is.True(!strings.Contains(string(script), "/bud/hot/"))
+	// Scripts are minified
+	is.True(!strings.Contains(string(script), "\n  "))
+}
+
+func TestBuildUnminified(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = `<script>export let greeting = "hello"</script><h1>{greeting}</h1>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	_, err := cli.Run(ctx, "build", "--static", "--minify=false")
+	is.NoErr(err)
+	scripts, err := filepath.Glob(filepath.Join(dir, "bud/static/bud/view/_index.svelte.*.js"))
+	is.NoErr(err)
+	is.Equal(len(scripts), 1)
+	script, err := os.ReadFile(scripts[0])
+	is.NoErr(err)
+	// Unminified builds are still production builds
+	is.True(!strings.Contains(string(script), "SvelteComponentDev"))
+	is.True(!strings.Contains(string(script), `"development"`))
+	is.In(string(script), "\n  ")
+}
+
+func TestBuildStrict(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = `<img src="/hero.png">`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	result, err := cli.Run(ctx, "build")
+	is.NoErr(err)
+	is.In(result.Stderr(), "a11y-missing-attribute")
+	// Warnings fail strict builds
+	_, err = cli.Run(ctx, "build", "--strict")
+	is.True(err != nil)
+	is.In(err.Error(), "view/index.svelte:1:1")
+	is.In(err.Error(), "a11y-missing-attribute")
+}
+
+func TestBuildGoja(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = `<script>export let greeting = "hello"</script><h1>{greeting}</h1>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	_, err := cli.Run(ctx, "build", "--js=goja", "--static")
+	is.NoErr(err)
+	// The app renders with goja instead of linking V8
+	main, err := os.ReadFile(filepath.Join(dir, "bud/internal/app/main.go"))
+	is.NoErr(err)
+	is.In(string(main), `"github.com/livebud/bud/package/js/goja"`)
+	is.True(!strings.Contains(string(main), `"github.com/livebud/bud/package/js/v8"`))
+	index, err := os.ReadFile(filepath.Join(dir, "bud/static/index.html"))
+	is.NoErr(err)
+	is.In(string(index), "<h1>hello</h1>")
+}
+
+func TestBuildUnknownJS(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	_, err := cli.Run(ctx, "build", "--js=node")
+	is.True(err != nil)
+	is.In(err.Error(), `invalid javascript VM "node"`)
+}
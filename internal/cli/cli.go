@@ -3,7 +3,10 @@ package cli
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/livebud/bud/internal/cli/bud"
 	"github.com/livebud/bud/internal/cli/build"
@@ -61,6 +64,8 @@ func (c *CLI) Run(ctx context.Context, args ...string) error {
 		cli.Flag("embed", "embed assets").Bool(&cmd.Flag.Embed).Default(false)
 		cli.Flag("hot", "hot reloading").Bool(&cmd.Flag.Hot).Default(true)
 		cli.Flag("minify", "minify assets").Bool(&cmd.Flag.Minify).Default(false)
+		cli.Flag("image-widths", "widths to resize public images to").Custom(imageWidths(&cmd.Flag.ImageWidths)).Optional()
+		cli.Flag("js", "javascript VM to render views with (v8 or goja)").Custom(jsVM(&cmd.Flag.JS)).Default("v8")
 		cli.Flag("listen", "address to listen to").String(&cmd.Listen).Default(":3000")
 		cli.Run(cmd.Run)
 	}
@@ -70,6 +75,12 @@ func (c *CLI) Run(ctx context.Context, args ...string) error {
 		cli := cli.Command("build", "build your app into a single binary")
 		cli.Flag("embed", "embed assets").Bool(&cmd.Flag.Embed).Default(true)
 		cli.Flag("minify", "minify assets").Bool(&cmd.Flag.Minify).Default(true)
+		cli.Flag("image-widths", "widths to resize public images to").Custom(imageWidths(&cmd.Flag.ImageWidths)).Optional()
+		cli.Flag("strict", "fail on compiler warnings").Bool(&cmd.Flag.Strict).Default(false)
+		cli.Flag("js", "javascript VM to render views with (v8 or goja)").Custom(jsVM(&cmd.Flag.JS)).Default("v8")
+		cli.Flag("static", "export a static site").Bool(&cmd.Static).Default(false)
+		cli.Flag("static-dir", "directory to export the static site into").String(&cmd.StaticDir).Default("bud/static")
+		cli.Flag("url", "url with parameters to export").Strings(&cmd.URLs).Optional()
 		cli.Run(cmd.Run)
 	}
 
@@ -154,10 +165,16 @@ func (c *CLI) Run(ctx context.Context, args ...string) error {
 		{ // $ bud tool cache
 			cli := cli.Command("cache", "manage the build cache")
 
+			{ // $ bud tool cache ls
+				cmd := toolcache.New(cmd, c.in)
+				cli := cli.Command("ls", "list what's in the cache directory")
+				cli.Run(cmd.List)
+			}
+
 			{ // $ bud tool cache clean
-				cmd := toolcache.New(cmd)
+				cmd := toolcache.New(cmd, c.in)
 				cli := cli.Command("clean", "clear the cache directory")
-				cli.Run(cmd.Run)
+				cli.Run(cmd.Clean)
 			}
 		}
 	}
@@ -179,3 +196,33 @@ func (c *CLI) Run(ctx context.Context, args ...string) error {
 	}
 	return nil
 }
+
+// imageWidths parses a comma-separated list of widths (e.g. 320,640,1280)
+func imageWidths(target *[]int) func(string) error {
+	return func(value string) error {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			width, err := strconv.Atoi(part)
+			if err != nil || width <= 0 {
+				return fmt.Errorf("cli: invalid image width %q", part)
+			}
+			*target = append(*target, width)
+		}
+		return nil
+	}
+}
+
+func jsVM(target *string) func(string) error {
+	return func(value string) error {
+		switch value {
+		case "v8", "goja":
+			*target = value
+			return nil
+		default:
+			return fmt.Errorf("cli: invalid javascript VM %q. Use v8 or goja", value)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package run
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Crashes within this long of starting count as immediate crashes
+	immediateCrash = 2 * time.Second
+	// Stop restarting after this many immediate crashes in a row
+	maxCrashes = 3
+	// Longest we'll wait before restarting a crashed app
+	maxBackoff = 5 * time.Second
+	// How much of the app's stderr we keep around to report crashes
+	maxTail = 16 << 10
+	// How many lines of stderr we report when the app didn't panic
+	tailLines = 20
+)
+
+// backoff returns how long to wait before restarting after the nth crash
+func backoff(crashes int) time.Duration {
+	delay := 100 * time.Millisecond
+	for i := 1; i < crashes && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
+
+// stderrTail keeps the end of the app's stderr, so we can tell why it crashed
+type stderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxTail {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-maxTail:]...)
+	}
+	return len(p), nil
+}
+
+// Reset the tail when the app starts again
+func (t *stderrTail) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = t.buf[:0]
+}
+
+// String returns the panic trace if the app panicked, otherwise the last few
+// lines of stderr
+func (t *stderrTail) String() string {
+	t.mu.Lock()
+	out := strings.TrimRight(string(t.buf), "\n")
+	t.mu.Unlock()
+	for _, prefix := range []string{"panic: ", "fatal error: "} {
+		if i := lastLineWith(out, prefix); i >= 0 {
+			return out[i:]
+		}
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) > tailLines {
+		lines = lines[len(lines)-tailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastLineWith returns the index of the last line that starts with prefix
+func lastLineWith(s, prefix string) int {
+	if i := strings.LastIndex(s, "\n"+prefix); i >= 0 {
+		return i + 1
+	}
+	if strings.HasPrefix(s, prefix) {
+		return 0
+	}
+	return -1
+}
+
+// crashError describes why the app exited
+type crashError struct {
+	err    error  // exit error, if any
+	stderr string // tail of stderr
+}
+
+// Summary is a one line description of the crash for the terminal. The app's
+// stderr has already been written to the terminal.
+func (c *crashError) Summary() string {
+	summary := "app exited unexpectedly"
+	if c.err != nil {
+		summary = fmt.Sprintf("app crashed with %s", c.err)
+	}
+	if strings.HasPrefix(c.stderr, "panic: ") || strings.HasPrefix(c.stderr, "fatal error: ") {
+		summary += ". " + strings.SplitN(c.stderr, "\n", 2)[0]
+	}
+	return summary
+}
+
+func (c *crashError) Error() string {
+	if c.stderr == "" {
+		return c.Summary()
+	}
+	return c.Summary() + "\n\n" + c.stderr
+}
@@ -0,0 +1,180 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livebud/bud/internal/pubsub"
+	"github.com/livebud/bud/package/budhttp"
+	"github.com/livebud/bud/package/hot"
+	"github.com/livebud/bud/package/log"
+)
+
+// holdTimeout is how long requests are held while the app is restarting
+const holdTimeout = 30 * time.Second
+
+// newFrontProxy creates a proxy that forwards requests from the web listener
+// to the app. Hot reload streams are sent straight to bud, so they stay
+// connected while the app restarts.
+func newFrontProxy(log log.Interface, bus pubsub.Subscriber, app http.RoundTripper, bud budhttp.Client) *frontProxy {
+	proxy := &frontProxy{
+		log:     log,
+		bus:     bus,
+		changed: make(chan struct{}),
+	}
+	reverseProxy := &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+			r.URL.Host = r.Host
+		},
+		Transport:     app,
+		FlushInterval: -1,
+		ErrorHandler:  proxy.unavailable,
+	}
+	proxy.handler = budhttp.Hot(bud, reverseProxy)
+	return proxy
+}
+
+// frontProxy holds requests while the app is restarting and replays them once
+// the app is ready. If the app fails to build, a page with the error is shown
+// instead.
+type frontProxy struct {
+	log     log.Interface
+	bus     pubsub.Subscriber
+	handler http.Handler
+
+	mu      sync.Mutex
+	ready   bool
+	err     *hot.Error    // set when the app failed to build or start
+	changed chan struct{} // closed and replaced whenever the state changes
+}
+
+// Run tracks the state of the app until the context is canceled
+func (p *frontProxy) Run(ctx context.Context) error {
+	readySub := p.bus.Subscribe("app:ready")
+	defer readySub.Close()
+	errorSub := p.bus.Subscribe("app:error")
+	defer errorSub.Close()
+	restartSub := p.bus.Subscribe("backend:update")
+	defer restartSub.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-readySub.Wait():
+			p.log.Debug("run: app is ready, releasing requests")
+			p.set(true, nil)
+		case data := <-errorSub.Wait():
+			p.log.Debug("run: app failed, serving the error page")
+			herr := new(hot.Error)
+			if err := json.Unmarshal(data, herr); err != nil {
+				herr.Message = string(data)
+			}
+			p.set(false, herr)
+		case <-restartSub.Wait():
+			p.log.Debug("run: app is restarting, holding requests")
+			p.set(false, nil)
+		}
+	}
+}
+
+func (p *frontProxy) set(ready bool, err *hot.Error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ready = ready
+	p.err = err
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func (p *frontProxy) state() (ready bool, err *hot.Error, changed <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ready, p.err, p.changed
+}
+
+func (p *frontProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Hot reload streams don't depend on the app
+	if r.URL.Path == "/bud/hot" || strings.HasPrefix(r.URL.Path, "/bud/hot/") {
+		p.handler.ServeHTTP(w, r)
+		return
+	}
+	timeout := time.NewTimer(holdTimeout)
+	defer timeout.Stop()
+	for {
+		ready, err, changed := p.state()
+		if err != nil {
+			p.showError(w, err)
+			return
+		} else if ready {
+			p.handler.ServeHTTP(w, r)
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-timeout.C:
+			http.Error(w, "bud: timed out waiting for the app to be ready", http.StatusServiceUnavailable)
+			return
+		}
+	}
+}
+
+// unavailable responds when the app can't be reached
+func (p *frontProxy) unavailable(w http.ResponseWriter, r *http.Request, err error) {
+	p.log.Debug("run: unable to proxy to the app", "err", err)
+	http.Error(w, "bud: the app is unavailable. "+err.Error(), http.StatusBadGateway)
+}
+
+// showError serves a page with the error that reloads once the app is ready
+func (p *frontProxy) showError(w http.ResponseWriter, err *hot.Error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusInternalServerError)
+	if err := errorPage.Execute(w, err); err != nil {
+		p.log.Error("run: unable to render the error page", "err", err)
+	}
+}
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8"/>
+	<title>{{ or .Title "Build failed" }}</title>
+	<style>
+		body { margin: 0; padding: 60px 20px; background: #181818; color: #e8e8e8; font: 14px/1.5 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+		main { max-width: 960px; margin: 0 auto; }
+		h1 { color: #ff7b72; font-size: 18px; }
+		.file { color: #8ab4f8; }
+		pre { white-space: pre-wrap; }
+		.frame { background: #0f0f0f; padding: 12px 16px; border-radius: 4px; overflow-x: auto; white-space: pre; }
+		.tip { color: #999; font-size: 12px; }
+	</style>
+</head>
+<body>
+	<main>
+		<h1>{{ or .Title "Build failed" }}</h1>
+		{{- if .File }}
+		<div class="file">{{ .File }}{{ if .Line }}:{{ .Line }}:{{ .Column }}{{ end }}</div>
+		{{- end }}
+		<pre>{{ .Message }}</pre>
+		{{- if .Frame }}
+		<pre class="frame">{{ .Frame }}</pre>
+		{{- end }}
+		<p class="tip">Fix the error and save. This page will reload once the app is ready.</p>
+	</main>
+	<script>
+		const sse = new EventSource("/bud/hot")
+		sse.addEventListener("ready", () => location.reload())
+		sse.addEventListener("message", () => location.reload())
+	</script>
+</body>
+</html>
+`))
@@ -2,9 +2,13 @@ package run
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"net"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -13,6 +17,7 @@ import (
 	"github.com/livebud/bud/framework"
 	"github.com/livebud/bud/framework/web/webrt"
 	"github.com/livebud/bud/internal/cli/bud"
+	"github.com/livebud/bud/internal/esmeta"
 	"github.com/livebud/bud/internal/exe"
 	"github.com/livebud/bud/internal/extrafile"
 	"github.com/livebud/bud/internal/gobuild"
@@ -20,9 +25,10 @@ import (
 	"github.com/livebud/bud/internal/pubsub"
 	"github.com/livebud/bud/internal/versions"
 	"github.com/livebud/bud/package/budfs"
+	"github.com/livebud/bud/package/budhttp"
 	"github.com/livebud/bud/package/budhttp/budsvr"
 	"github.com/livebud/bud/package/gomod"
-	v8 "github.com/livebud/bud/package/js/v8"
+	"github.com/livebud/bud/package/hot"
 	"github.com/livebud/bud/package/log"
 	"github.com/livebud/bud/package/socket"
 	"github.com/livebud/bud/package/watcher"
@@ -33,7 +39,7 @@ func New(bud *bud.Command, in *bud.Input) *Command {
 	return &Command{
 		bud:  bud,
 		in:   in,
-		Flag: new(framework.Flag),
+		Flag: &framework.Flag{Dev: true},
 	}
 }
 
@@ -84,48 +90,74 @@ func (c *Command) Run(ctx context.Context) (err error) {
 	// Setup the bud listener
 	budln := c.in.BudLn
 	if budln == nil {
-		budln, err = socket.Listen(":35729")
+		// The app proxies requests from the browser to bud, so bud only needs to
+		// be reachable locally and can listen on any available port
+		budln, err = socket.Listen("127.0.0.1:0")
 		if err != nil {
 			return err
 		}
 		defer budln.Close()
 		log.Debug("run: bud server is listening", "url", "http://"+budln.Addr().String())
 	}
-	// Load the generator filesystem
-	bfs, err := bud.FileSystem(ctx, log, module, c.Flag, c.in)
-	if err != nil {
-		return err
-	}
-	defer bfs.Close()
+	// Track the imports between views, so we can hot swap the changed views
+	graph := esmeta.NewGraph()
 	// Create a bus if we don't have one yet
 	bus := c.in.Bus
 	if bus == nil {
 		bus = pubsub.New()
 	}
+	// Load the generator filesystem
+	bfs, err := bud.FileSystem(ctx, log, module, c.Flag, c.in, graph, bus)
+	if err != nil {
+		return err
+	}
+	defer bfs.Close()
 	// Initialize the bud server
 	budServer := &budServer{
 		budln: budln,
 		bus:   bus,
 		fsys:  bfs,
+		graph: graph,
 		log:   log,
+		js:    c.Flag.JS,
 	}
+	// Keep the end of the app's stderr to report crashes
+	stderr := new(stderrTail)
 	// Setup the starter command
 	starter := &exe.Command{
 		Stdin:  c.in.Stdin,
 		Stdout: c.in.Stdout,
-		Stderr: c.in.Stderr,
+		Stderr: io.MultiWriter(c.in.Stderr, stderr),
 		Dir:    module.Directory(),
 		Env: append(c.in.Env,
 			"BUD_LISTEN="+budln.Addr().String(),
 		),
 	}
-	// Get the file descriptor for the web listener
-	webFile, err := webln.File()
+	// The app listens on its own port behind a proxy on the web listener, so
+	// requests can be held while the app restarts
+	appln, err := socket.Listen("127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer appln.Close()
+	log.Debug("run: app is listening", "url", "http://"+appln.Addr().String())
+	// Get the file descriptor for the app listener
+	appFile, err := appln.File()
 	if err != nil {
 		return err
 	}
 	// Inject that file into the starter's extrafiles
-	extrafile.Inject(&starter.ExtraFiles, &starter.Env, "WEB", webFile)
+	extrafile.Inject(&starter.ExtraFiles, &starter.Env, "WEB", appFile)
+	// Setup the proxy in front of the app
+	appTransport, err := socket.Transport(appln.Addr().String())
+	if err != nil {
+		return err
+	}
+	budClient, err := budhttp.Load(log, budln.Addr().String())
+	if err != nil {
+		return err
+	}
+	frontProxy := newFrontProxy(log, bus, appTransport, budClient)
 	// Initialize the app server
 	appServer := &appServer{
 		dir:      module.Directory(),
@@ -133,12 +165,19 @@ func (c *Command) Run(ctx context.Context) (err error) {
 		prompter: &prompter,
 		bus:      bus,
 		bfs:      bfs,
+		graph:    graph,
 		log:      log,
 		module:   module,
 		starter:  starter,
+		stderr:   stderr,
+		styles:   newStyleTracker(module.Directory()),
 	}
 	// Start the servers
 	eg, ctx := errgroup.WithContext(ctx)
+	// Track the state of the app before it starts
+	eg.Go(func() error { return frontProxy.Run(ctx) })
+	// Start the proxy in front of the app
+	eg.Go(func() error { return webrt.Serve(ctx, webln, frontProxy) })
 	// Start the internal bud server
 	eg.Go(func() error { return budServer.Run(ctx) })
 	// Start the internal app server
@@ -154,16 +193,19 @@ type budServer struct {
 	budln net.Listener
 	bus   pubsub.Client
 	fsys  fs.FS
+	graph *esmeta.Graph
 	log   log.Interface
+	// VM that renders the views
+	js string
 }
 
 // Run the bud server
 func (s *budServer) Run(ctx context.Context) error {
-	vm, err := v8.Load()
+	vm, err := bud.VM(s.js)
 	if err != nil {
 		return err
 	}
-	devServer := budsvr.New(s.fsys, s.bus, s.log, vm)
+	devServer := budsvr.New(s.fsys, s.bus, s.log, vm, s.graph)
 	err = webrt.Serve(ctx, s.budln, devServer)
 	s.log.Debug("run: bud server closed", "err", err)
 	return err
@@ -176,82 +218,221 @@ type appServer struct {
 	prompter *prompter.Prompter
 	bus      pubsub.Client
 	bfs      *budfs.FileSystem
+	graph    *esmeta.Graph
 	log      log.Interface
 	module   *gomod.Module
 	starter  *exe.Command
+	stderr   *stderrTail
+	styles   *styleTracker
+
+	// The running process or nil if the app crashed
+	process   *exe.Process
+	startedAt time.Time
+	crashes   int // immediate crashes in a row
 }
 
 // Run the app server
 func (a *appServer) Run(ctx context.Context) error {
-	// Generate the app
-	if err := a.bfs.Sync(a.module, "bud/internal"); err != nil {
-		a.bus.Publish("app:error", []byte(err.Error()))
-		a.log.Debug("run: published event", "event", "app:error")
-		return err
+	// Generate, build and start the app. If that fails, the error is shown in
+	// the browser and we keep watching for the change that fixes it.
+	if err := a.build(ctx); err != nil {
+		a.publishError(err)
+		a.log.Error(err.Error())
+	} else if err := a.start(ctx); err != nil {
+		a.publishError(err)
+		a.log.Error(err.Error())
+	}
+	// Remember the components, so we can tell when only their styles change
+	if err := a.styles.Load(); err != nil {
+		a.log.Debug("run: unable to load the components' styles", "err", err)
+	}
+	// Watch for changes. Changes are handled in the loop below, so they don't
+	// race with restarting a crashed app.
+	changes := make(chan []watcher.Event)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watcher.Watch(ctx, a.dir, func(events []watcher.Event) error {
+			select {
+			case changes <- events:
+			case <-ctx.Done():
+			}
+			return nil
+		})
+	}()
+	reload := catchError(a.prompter, func(events []watcher.Event) error {
+		return a.reload(ctx, events)
+	})
+	var retry <-chan time.Time
+	for {
+		select {
+		case err := <-watchErr:
+			return err
+		case events := <-changes:
+			// A change restarts a crashed app right away
+			retry = nil
+			reload(events)
+		case <-a.exited():
+			// The app exits along with bud
+			if ctx.Err() != nil {
+				return <-watchErr
+			}
+			retry = a.crashed()
+		case <-retry:
+			retry = nil
+			a.prompter.Reloading(nil)
+			if err := a.start(ctx); err != nil {
+				a.publishError(err)
+				a.prompter.FailReload(err.Error())
+				continue
+			}
+			a.prompter.SuccessReload()
+		}
 	}
-	// Build the app
-	if err := a.builder.Build(ctx, "bud/internal/app/main.go", "bud/app"); err != nil {
-		a.bus.Publish("app:error", []byte(err.Error()))
-		a.log.Debug("run: published event", "event", "app:error")
+}
+
+// build generates the app and builds it into bud/app
+func (a *appServer) build(ctx context.Context) error {
+	if err := a.bfs.Sync(a.module, "bud/internal"); err != nil {
 		return err
 	}
-	// Start the built app
+	return a.builder.Build(ctx, "bud/internal/app/main.go", "bud/app")
+}
+
+// start the built app
+func (a *appServer) start(ctx context.Context) error {
+	a.stderr.Reset()
 	process, err := a.starter.Start(ctx, filepath.Join("bud", "app"))
 	if err != nil {
-		a.bus.Publish("app:error", []byte(err.Error()))
-		a.log.Debug("run: published event", "event", "app:error")
 		return err
 	}
-	// Watch for changes
-	return watcher.Watch(ctx, a.dir, catchError(a.prompter, func(events []watcher.Event) error {
-		// Trigger reloading
-		a.prompter.Reloading(events)
-		// Inform the bud filesystem of the changes
-		changes := make([]string, len(events))
-		for i, event := range events {
-			a.log.Debug("run: file changed", "path", event.Path)
-			changes[i] = event.Path
-		}
-		a.bfs.Change(changes...)
-		// Check if we can incrementally reload
-		if canIncrementallyReload(events) {
-			a.log.Debug("run: incrementally reloading")
-			// Publish the frontend:update event
-			a.bus.Publish("frontend:update", nil)
-			a.log.Debug("run: published event", "event", "frontend:update")
-			// Publish the app:ready event
-			a.bus.Publish("app:ready", nil)
-			a.log.Debug("run: published event", "event", "app:ready")
-			a.prompter.SuccessReload()
-			return nil
-		}
-		now := time.Now()
-		a.log.Debug("run: restarting the process")
-		if err := process.Close(); err != nil {
-			return err
-		}
-		a.bus.Publish("backend:update", nil)
-		a.log.Debug("run: published event", "event", "backend:update")
-		// Generate the app
-		if err := a.bfs.Sync(a.module, "bud/internal"); err != nil {
-			return err
-		}
-		// Build the app
-		if err := a.builder.Build(ctx, "bud/internal/app/main.go", "bud/app"); err != nil {
+	a.process = process
+	a.startedAt = time.Now()
+	return nil
+}
+
+// exited returns a channel that's closed when the running app exits
+func (a *appServer) exited() <-chan struct{} {
+	if a.process == nil {
+		return nil
+	}
+	return a.process.Done()
+}
+
+// crashed reports why the app exited and returns when to restart it. Returns
+// nil if the app keeps crashing right away, in which case we wait for changes.
+func (a *appServer) crashed() (retry <-chan time.Time) {
+	crash := &crashError{a.process.Wait(), a.stderr.String()}
+	a.process = nil
+	if time.Since(a.startedAt) < immediateCrash {
+		a.crashes++
+	} else {
+		a.crashes = 1
+	}
+	a.log.Debug("run: app crashed", "err", crash.err, "crashes", a.crashes)
+	a.publishError(crash)
+	if a.crashes >= maxCrashes {
+		a.prompter.Crashed(crash.Summary() + ". Waiting for changes to restart.")
+		return nil
+	}
+	delay := backoff(a.crashes)
+	a.prompter.Crashed(fmt.Sprintf("%s. Restarting in %s.", crash.Summary(), delay))
+	return time.After(delay)
+}
+
+// reload the app after the files change
+func (a *appServer) reload(ctx context.Context, events []watcher.Event) error {
+	// Trigger reloading
+	a.prompter.Reloading(events)
+	// Inform the bud filesystem of the changes
+	changes := make([]string, len(events))
+	for i, event := range events {
+		a.log.Debug("run: file changed", "path", event.Path)
+		changes[i] = event.Path
+	}
+	a.bfs.Change(changes...)
+	// Swap the stylesheets in place if only the styles changed
+	if a.process != nil && a.styles.OnlyStyles(events) {
+		a.log.Debug("run: only styles changed")
+		data, err := json.Marshal(changes)
+		if err != nil {
 			return err
 		}
-		// Restart the process
-		p, err := process.Restart(ctx)
+		a.bus.Publish("frontend:css", data)
+		a.log.Debug("run: published event", "event", "frontend:css")
+		a.bus.Publish("app:ready", nil)
+		a.log.Debug("run: published event", "event", "app:ready")
+		a.prompter.SuccessReload()
+		return nil
+	}
+	// Check if we can incrementally reload
+	if a.process != nil && canIncrementallyReload(events) {
+		a.log.Debug("run: incrementally reloading")
+		// Publish the frontend:update event with the changed paths
+		data, err := json.Marshal(changes)
 		if err != nil {
-			a.bus.Publish("app:error", nil)
-			a.log.Debug("run: published event", "event", "app:error")
 			return err
 		}
+		// Only update the pages that depend on the changes when we know them
+		if pages := affectedPages(a.graph, changes); pages != nil {
+			for _, page := range pages {
+				a.bus.Publish("frontend:update:"+page, data)
+				a.log.Debug("run: published event", "event", "frontend:update:"+page)
+			}
+		} else {
+			a.bus.Publish("frontend:update", data)
+			a.log.Debug("run: published event", "event", "frontend:update")
+		}
+		// Publish the app:ready event
+		a.bus.Publish("app:ready", nil)
+		a.log.Debug("run: published event", "event", "app:ready")
 		a.prompter.SuccessReload()
-		a.log.Debug("restarted the process", "in", time.Since(now))
-		process = p
 		return nil
-	}))
+	}
+	now := time.Now()
+	// Generate and build the app while the previous process keeps running,
+	// so the browser stays connected to show any errors
+	if err := a.build(ctx); err != nil {
+		a.publishError(err)
+		return err
+	}
+	// Hold new requests until the restarted process is ready
+	a.bus.Publish("backend:update", nil)
+	a.log.Debug("run: published event", "event", "backend:update")
+	a.log.Debug("run: restarting the process")
+	if a.process != nil {
+		if err := a.process.Close(); err != nil {
+			return err
+		}
+		a.process = nil
+	}
+	// Changes give a crashing app a fresh start
+	a.crashes = 0
+	// Restart the process
+	if err := a.start(ctx); err != nil {
+		a.publishError(err)
+		return err
+	}
+	a.prompter.SuccessReload()
+	a.log.Debug("restarted the process", "in", time.Since(now))
+	return nil
+}
+
+// publishError sends the error to the browser's error overlay
+func (a *appServer) publishError(err error) {
+	herr := hot.NewError(err)
+	herr.LoadFrame(os.DirFS(a.dir))
+	// Crashes happen after the app built successfully
+	var crash *crashError
+	if errors.As(err, &crash) {
+		herr.Title = "App crashed"
+	}
+	data, err := json.Marshal(herr)
+	if err != nil {
+		a.log.Error("run: unable to marshal error", "err", err)
+		return
+	}
+	a.bus.Publish("app:error", data)
+	a.log.Debug("run: published event", "event", "app:error")
 }
 
 // logWrap wraps the watch function in a handler that logs the error instead of
@@ -265,6 +446,27 @@ func catchError(prompter *prompter.Prompter, fn func(events []watcher.Event) err
 	}
 }
 
+// affectedPages returns the pages that depend on the changes. Returns nil if any
+// of the changes aren't part of a page that's been built, since we can't tell
+// which pages they affect.
+func affectedPages(graph *esmeta.Graph, changes []string) (pages []string) {
+	seen := map[string]bool{}
+	for _, change := range changes {
+		entries := graph.Entries(change)
+		if len(entries) == 0 {
+			return nil
+		}
+		for _, entry := range entries {
+			if seen[entry] {
+				continue
+			}
+			seen[entry] = true
+			pages = append(pages, entry)
+		}
+	}
+	return pages
+}
+
 // canIncrementallyReload returns true if we can incrementally reload a page
 func canIncrementallyReload(events []watcher.Event) bool {
 	for _, event := range events {
@@ -0,0 +1,123 @@
+package run
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/livebud/bud/internal/fingerprint"
+	"github.com/livebud/bud/package/watcher"
+)
+
+var reStyle = regexp.MustCompile(`(?is)<style[^>]*>(.*?)</style>`)
+var reComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// newStyleTracker creates a tracker for the components in dir
+func newStyleTracker(dir string) *styleTracker {
+	return &styleTracker{
+		dir:    dir,
+		hashes: map[string]string{},
+	}
+}
+
+// styleTracker remembers the markup, scripts and style selectors of each Svelte
+// component, so we can tell when only a component's styles changed. Svelte
+// only scopes the elements that a selector matches, so changing the selectors
+// changes the compiled scripts too.
+type styleTracker struct {
+	dir    string
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+// Load the components within the view directory
+func (s *styleTracker) Load() error {
+	fsys := os.DirFS(s.dir)
+	err := fs.WalkDir(fsys, "view", func(path string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if de.IsDir() || filepath.Ext(path) != ".svelte" {
+			return nil
+		}
+		_, err = s.update(path)
+		return err
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// OnlyStyles returns true if the events only changed stylesheets or the styles
+// within components. Every component in the events is updated, so the tracker
+// stays current.
+func (s *styleTracker) OnlyStyles(events []watcher.Event) bool {
+	onlyStyles := len(events) > 0
+	for _, event := range events {
+		if event.Op != watcher.OpUpdate {
+			onlyStyles = false
+			continue
+		}
+		switch filepath.Ext(event.Path) {
+		case ".css":
+			continue
+		case ".svelte":
+			same, err := s.update(event.Path)
+			if err != nil || !same {
+				onlyStyles = false
+			}
+		default:
+			onlyStyles = false
+		}
+	}
+	return onlyStyles
+}
+
+// update the component's hash, returning true if everything besides the
+// style declarations are the same as before
+func (s *styleTracker) update(path string) (same bool, err error) {
+	code, err := os.ReadFile(filepath.Join(s.dir, path))
+	if err != nil {
+		return false, err
+	}
+	var selectors []string
+	for _, match := range reStyle.FindAllSubmatch(code, -1) {
+		selectors = append(selectors, styleSelectors(string(match[1]))...)
+	}
+	sort.Strings(selectors)
+	markup := reStyle.ReplaceAll(code, nil)
+	hash := fingerprint.Hash(append(markup, strings.Join(selectors, "\n")...))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.hashes[path]
+	s.hashes[path] = hash
+	return ok && prev == hash, nil
+}
+
+// styleSelectors returns the selectors and at-rules within the stylesheet,
+// leaving out the declarations
+func styleSelectors(css string) (selectors []string) {
+	css = reComment.ReplaceAllString(css, "")
+	start := 0
+	for i, c := range css {
+		switch c {
+		case '{':
+			selectors = append(selectors, strings.Join(strings.Fields(css[start:i]), " "))
+			start = i + 1
+		case '}':
+			start = i + 1
+		case ';':
+			// Declarations and at-rules like @import end with a semicolon
+			if prelude := strings.TrimSpace(css[start:i]); strings.HasPrefix(prelude, "@") {
+				selectors = append(selectors, strings.Join(strings.Fields(prelude), " "))
+			}
+			start = i + 1
+		}
+	}
+	return selectors
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -157,9 +158,10 @@ func (c *CLI) Start(ctx context.Context, args ...string) (*Client, error) {
 			return eg.Wait()
 		},
 	}
-	// Wait for the client to be ready
+	// Wait for the client to be ready. The client is returned along with the
+	// error, since bud keeps watching for changes after the app fails to build.
 	if err := client.Ready(ctx); err != nil {
-		return nil, err
+		return client, err
 	}
 	return client, nil
 }
@@ -315,8 +317,12 @@ func (c *Client) Ready(ctx context.Context) error {
 			return nil
 		case <-ctx.Done():
 			return ctx.Err()
-		case err := <-errorSub.Wait():
-			return errors.New(string(err))
+		case data := <-errorSub.Wait():
+			herr := new(hot.Error)
+			if err := json.Unmarshal(data, herr); err != nil {
+				return errors.New(string(data))
+			}
+			return herr
 		case <-time.After(time.Second * 1):
 			c.log.Debug("testcli: waiting for app to be ready")
 		}
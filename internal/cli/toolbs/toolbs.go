@@ -6,6 +6,7 @@ import (
 	"github.com/livebud/bud/framework"
 	"github.com/livebud/bud/framework/web/webrt"
 	"github.com/livebud/bud/internal/cli/bud"
+	"github.com/livebud/bud/internal/esmeta"
 	"github.com/livebud/bud/internal/pubsub"
 	"github.com/livebud/bud/package/budhttp/budsvr"
 	v8 "github.com/livebud/bud/package/js/v8"
@@ -16,7 +17,7 @@ func New(bud *bud.Command, in *bud.Input) *Command {
 	return &Command{
 		bud:  bud,
 		in:   in,
-		Flag: new(framework.Flag),
+		Flag: &framework.Flag{Dev: true},
 	}
 }
 
@@ -40,12 +41,13 @@ func (c *Command) Run(ctx context.Context) error {
 		return err
 	}
 	// Load the file server
-	servefs, err := bud.FileSystem(ctx, log, module, c.Flag, c.in)
+	graph := esmeta.NewGraph()
+	bus := pubsub.New()
+	servefs, err := bud.FileSystem(ctx, log, module, c.Flag, c.in, graph, bus)
 	if err != nil {
 		return err
 	}
-	bus := pubsub.New()
-	server := budsvr.New(servefs, bus, log, vm)
+	server := budsvr.New(servefs, bus, log, vm, graph)
 	budln, err := socket.Listen(":35729")
 	if err != nil {
 		return err
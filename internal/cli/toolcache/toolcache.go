@@ -2,23 +2,79 @@ package toolcache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"text/tabwriter"
 
 	"github.com/livebud/bud/internal/cli/bud"
+	"github.com/livebud/bud/internal/diskcache"
 )
 
-func New(bud *bud.Command) *Command {
-	return &Command{bud: bud}
+func New(bud *bud.Command, in *bud.Input) *Command {
+	return &Command{bud: bud, in: in}
 }
 
 type Command struct {
 	bud *bud.Command
+	in  *bud.Input
 }
 
-func (c *Command) Run(ctx context.Context) error {
+// Clean the cache directory
+func (c *Command) Clean(ctx context.Context) error {
 	module, err := bud.Module(c.bud.Dir)
 	if err != nil {
 		return err
 	}
 	return os.RemoveAll(module.Directory("bud", "cache"))
 }
+
+// List what's in the cache directory. Built binaries are stored at the top of
+// the cache directory, while other caches have their own directory.
+func (c *Command) List(ctx context.Context) error {
+	module, err := bud.Module(c.bud.Dir)
+	if err != nil {
+		return err
+	}
+	dir := module.Directory("bud", "cache")
+	des, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	binaries := new(diskcache.Stats)
+	tw := tabwriter.NewWriter(c.in.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CACHE\tENTRIES\tSIZE")
+	for _, de := range des {
+		if !de.IsDir() {
+			fi, err := de.Info()
+			if err != nil {
+				return err
+			}
+			binaries.Entries++
+			binaries.Size += fi.Size()
+			continue
+		}
+		stats, err := diskcache.New(filepath.Join(dir, de.Name()), 0).Stat()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", de.Name(), stats.Entries, formatSize(stats.Size))
+	}
+	fmt.Fprintf(tw, "%s\t%d\t%s\n", "binaries", binaries.Entries, formatSize(binaries.Size))
+	return tw.Flush()
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,38 @@
+package toolcache_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/livebud/bud/internal/cli/testcli"
+	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/internal/testdir"
+	"github.com/livebud/bud/internal/versions"
+)
+
+func TestListClean(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	td.Files["view/index.svelte"] = `<h1>index</h1>`
+	td.NodeModules["svelte"] = versions.Svelte
+	td.NodeModules["livebud"] = "*"
+	is.NoErr(td.Write(ctx))
+	cli := testcli.New(dir)
+	_, err := cli.Run(ctx, "build")
+	is.NoErr(err)
+	result, err := cli.Run(ctx, "tool", "cache", "ls")
+	is.NoErr(err)
+	is.In(result.Stdout(), "CACHE")
+	// The compiled components are cached for both the DOM and SSR
+	is.True(regexp.MustCompile(`svelte\s+[2-9]\d*\s+\d`).MatchString(result.Stdout()))
+	is.True(regexp.MustCompile(`binaries\s+1\s+\d`).MatchString(result.Stdout()))
+	_, err = cli.Run(ctx, "tool", "cache", "clean")
+	is.NoErr(err)
+	result, err = cli.Run(ctx, "tool", "cache", "ls")
+	is.NoErr(err)
+	is.True(!regexp.MustCompile(`svelte`).MatchString(result.Stdout()))
+	is.True(regexp.MustCompile(`binaries\s+0\s+0B`).MatchString(result.Stdout()))
+}
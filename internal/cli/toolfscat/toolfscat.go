@@ -15,7 +15,7 @@ func New(bud *bud.Command, in *bud.Input) *Command {
 	return &Command{
 		bud:  bud,
 		in:   in,
-		Flag: new(framework.Flag),
+		Flag: &framework.Flag{Dev: true},
 	}
 }
 
@@ -35,7 +35,7 @@ func (c *Command) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	bfs, err := bud.FileSystem(ctx, log, module, c.Flag, c.in)
+	bfs, err := bud.FileSystem(ctx, log, module, c.Flag, c.in, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,164 @@
+package diskcache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// New cache within the directory. Once the cache grows past maxSize bytes, the
+// least recently used entries are evicted.
+func New(dir string, maxSize int64) *Cache {
+	return &Cache{dir: dir, maxSize: maxSize, size: -1}
+}
+
+// Cache stores entries on disk, so they survive between runs
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	mu sync.Mutex
+	// Total size of the entries. It's -1 until the directory is first read.
+	size int64
+}
+
+// Get an entry from the cache
+func (c *Cache) Get(key string) ([]byte, bool) {
+	fpath := filepath.Join(c.dir, key)
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, false
+	}
+	// Mark the entry as recently used
+	now := time.Now()
+	os.Chtimes(fpath, now, now)
+	return data, true
+}
+
+// Set an entry in the cache
+func (c *Cache) Set(key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	if c.size < 0 {
+		stats, err := c.stat()
+		if err != nil {
+			return err
+		}
+		c.size = stats.Size
+	}
+	fpath := filepath.Join(c.dir, key)
+	if fi, err := os.Stat(fpath); err == nil {
+		c.size -= fi.Size()
+	}
+	// Write to a temporary file first, so readers never see partial entries
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), fpath); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	c.size += int64(len(data))
+	if c.maxSize > 0 && c.size > c.maxSize {
+		return c.evict()
+	}
+	return nil
+}
+
+// entry in the cache directory
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// evict the least recently used entries until the cache is down to 3/4 of its
+// maximum size, so we're not evicting on every write
+func (c *Cache) evict() error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	target := c.maxSize * 3 / 4
+	for _, entry := range entries {
+		if c.size <= target {
+			break
+		}
+		if err := os.Remove(entry.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		c.size -= entry.size
+	}
+	return nil
+}
+
+func (c *Cache) entries() (entries []*entry, err error) {
+	des, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, de := range des {
+		if de.IsDir() || de.Name()[0] == '.' {
+			continue
+		}
+		fi, err := de.Info()
+		if err != nil {
+			// Removed while reading the directory
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, &entry{filepath.Join(c.dir, de.Name()), fi.Size(), fi.ModTime()})
+	}
+	return entries, nil
+}
+
+// Stats describe what's in the cache
+type Stats struct {
+	Entries int
+	Size    int64
+}
+
+// Stat the cache
+func (c *Cache) Stat() (*Stats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stat()
+}
+
+func (c *Cache) stat() (*Stats, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	stats := new(Stats)
+	for _, entry := range entries {
+		stats.Entries++
+		stats.Size += entry.size
+	}
+	return stats, nil
+}
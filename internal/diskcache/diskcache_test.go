@@ -0,0 +1,73 @@
+package diskcache_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livebud/bud/internal/diskcache"
+	"github.com/livebud/bud/internal/is"
+)
+
+func TestGetSet(t *testing.T) {
+	is := is.New(t)
+	dir := filepath.Join(t.TempDir(), "svelte")
+	cache := diskcache.New(dir, 0)
+	data, ok := cache.Get("a")
+	is.True(!ok)
+	is.Equal(data, nil)
+	is.NoErr(cache.Set("a", []byte("hello")))
+	data, ok = cache.Get("a")
+	is.True(ok)
+	is.Equal(string(data), "hello")
+	// Entries survive between runs
+	cache = diskcache.New(dir, 0)
+	data, ok = cache.Get("a")
+	is.True(ok)
+	is.Equal(string(data), "hello")
+	is.NoErr(cache.Set("a", []byte("hi")))
+	stats, err := cache.Stat()
+	is.NoErr(err)
+	is.Equal(stats.Entries, 1)
+	is.Equal(stats.Size, int64(2))
+}
+
+func TestEvict(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	cache := diskcache.New(dir, 40)
+	past := time.Now().Add(-time.Hour)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		is.NoErr(cache.Set(key, []byte(strings.Repeat(key, 10))))
+		// Order the entries by when they were used
+		at := past.Add(time.Duration(i) * time.Minute)
+		is.NoErr(os.Chtimes(filepath.Join(dir, key), at, at))
+	}
+	// Using an entry keeps it around
+	_, ok := cache.Get("a")
+	is.True(ok)
+	is.NoErr(cache.Set("e", []byte(strings.Repeat("e", 10))))
+	stats, err := cache.Stat()
+	is.NoErr(err)
+	is.Equal(stats.Entries, 3)
+	is.Equal(stats.Size, int64(30))
+	_, ok = cache.Get("b")
+	is.True(!ok)
+	_, ok = cache.Get("c")
+	is.True(!ok)
+	for _, key := range []string{"a", "d", "e"} {
+		_, ok = cache.Get(key)
+		is.True(ok)
+	}
+}
+
+func TestStatEmpty(t *testing.T) {
+	is := is.New(t)
+	cache := diskcache.New(filepath.Join(t.TempDir(), "missing"), 0)
+	stats, err := cache.Stat()
+	is.NoErr(err)
+	is.Equal(stats.Entries, 0)
+	is.Equal(stats.Size, int64(0))
+}
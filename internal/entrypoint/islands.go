@@ -0,0 +1,92 @@
+package entrypoint
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"regexp"
+
+	"github.com/livebud/bud/package/markdown"
+)
+
+// reIslands matches the directive that puts a Svelte page into islands mode:
+//
+//	<script context="module">export const islands = true</script>
+var reIslands = regexp.MustCompile(`\bexport\s+const\s+islands\s*=\s*true\b`)
+
+// reComponentImport matches the imports of other components
+//
+//	e.g. import Counter from "./Counter.island.svelte"
+var reComponentImport = regexp.MustCompile(`\bimport\s+(?:[A-Za-z_$][A-Za-z0-9_$]*\s+from\s+)?["'](\.\.?/[^"']+\.(?:svelte|md|mdx))["']`)
+
+// partial returns true if the page opted into islands mode. Svelte pages
+// export the islands directive from their module script, while markdown pages
+// set it in their front matter.
+func partial(page Path, code []byte) (bool, error) {
+	switch path.Ext(string(page)) {
+	case ".md", ".mdx":
+		matter, _, err := markdown.FrontMatter(code)
+		if err != nil {
+			return false, err
+		}
+		islands, _ := matter["islands"].(bool)
+		return islands, nil
+	default:
+		return reIslands.Match(code), nil
+	}
+}
+
+// loadIslands finds the islands rendered within the page if the page is in
+// islands mode. Islands are found by following the imports from the page,
+// frames and layout. The imports of islands aren't followed, since they're
+// bundled along with the island.
+func loadIslands(fsys fs.FS, view *View) error {
+	code, err := fs.ReadFile(fsys, string(view.Page))
+	if err != nil {
+		return err
+	}
+	if view.Partial, err = partial(view.Page, code); err != nil || !view.Partial {
+		return err
+	}
+	seen := map[Path]bool{}
+	var walk func(component Path) error
+	walk = func(component Path) error {
+		if seen[component] {
+			return nil
+		}
+		seen[component] = true
+		if component.Island() {
+			view.Islands = append(view.Islands, component)
+			return nil
+		}
+		code, err := fs.ReadFile(fsys, string(component))
+		if err != nil {
+			// Let the bundler report the missing imports
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		// The layout's styles aren't bundled into the page's stylesheet
+		if component != view.Layout {
+			view.Inert = append(view.Inert, component)
+		}
+		for _, match := range reComponentImport.FindAllSubmatch(code, -1) {
+			imported := path.Join(path.Dir(string(component)), string(match[1]))
+			if err := walk(Path(imported)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, component := range view.ServerImports() {
+		// The error page is only rendered when the page fails
+		if component == view.Error {
+			continue
+		}
+		if err := walk(component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,13 +15,14 @@ import (
 
 // List the views
 func List(fsys fs.FS, paths ...string) ([]*View, error) {
+	root := path.Clean(path.Join(paths...))
 	// Build a tree of reserved views (layout, frames, error)
-	tree, err := buildTree(fsys, path.Clean(path.Join(paths...)))
+	tree, err := buildTree(fsys, root)
 	if err != nil {
 		return nil, err
 	}
 	// Turn the tree of views into a list of views
-	views, err := listViews(fsys, tree, path.Clean(path.Join(paths...)))
+	views, err := listViews(fsys, tree, root, root)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +116,16 @@ func (t *tree) Frames(dir, ext string) (frames []Path) {
 	return frames
 }
 
+// relDir returns dir relative to root
+func relDir(root, dir string) string {
+	if root == "." {
+		return dir
+	} else if dir == root {
+		return ""
+	}
+	return strings.TrimPrefix(dir, root+"/")
+}
+
 func splitRoot(dir string) (root, rest string) {
 	parts := strings.SplitN(dir, "/", 2)
 	if len(parts) == 1 {
@@ -123,7 +134,7 @@ func splitRoot(dir string) (root, rest string) {
 	return parts[0], parts[1]
 }
 
-func listViews(fsys fs.FS, tree *tree, dir string) (views []*View, err error) {
+func listViews(fsys fs.FS, tree *tree, root, dir string) (views []*View, err error) {
 	fis, err := fs.ReadDir(fsys, dir)
 	if err != nil {
 		return nil, err
@@ -135,7 +146,7 @@ func listViews(fsys fs.FS, tree *tree, dir string) (views []*View, err error) {
 			if !valid.Dir(name) {
 				continue
 			}
-			subviews, err := listViews(fsys, tree, fullpath)
+			subviews, err := listViews(fsys, tree, root, fullpath)
 			if err != nil {
 				return nil, err
 			}
@@ -145,25 +156,49 @@ func listViews(fsys fs.FS, tree *tree, dir string) (views []*View, err error) {
 		if !valid.ViewEntry(name) {
 			continue
 		}
-		ext := path.Ext(name)
-		// TODO: remove this constraint after we have sufficient testing
-		if ext != ".svelte" {
+		// TODO: support more view types after we have sufficient testing
+		viewType, ok := Type(path.Ext(name))
+		if !ok {
 			continue
 		}
-		views = append(views, &View{
+		// The tree is relative to the root directory
+		rel := relDir(root, dir)
+		// Pages inherit the layout, frames and error page of their view type.
+		// For example, markdown pages are rendered within Svelte layouts.
+		ext := "." + viewType
+		view := &View{
 			Page:   Path(fullpath),
 			Client: client(fullpath),
+			Styles: []string{"/" + stylesheet(fullpath)},
 			Route:  route(dir, name),
-			Frames: tree.Frames(dir, ext),
-			Layout: tree.Layout(dir, ext),
-			Error:  tree.Error(dir, ext),
-			Type:   strings.TrimPrefix(ext, "."),
-			Hot:    ":35729", // TODO: configurable
-		})
+			Frames: tree.Frames(rel, ext),
+			Layout: tree.Layout(rel, ext),
+			Error:  tree.Error(rel, ext),
+			Type:   viewType,
+			Hot:    "/bud/hot/" + fullpath,
+		}
+		if err := loadIslands(fsys, view); err != nil {
+			return nil, err
+		}
+		views = append(views, view)
 	}
 	return views, nil
 }
 
+// types maps the extensions of the pages to the type of view they're rendered
+// as. Markdown and MDX are transformed into Svelte.
+var types = map[string]string{
+	".svelte": "svelte",
+	".md":     "svelte",
+	".mdx":    "svelte",
+}
+
+// Type returns the type of view that pages with the extension are rendered as
+func Type(ext string) (viewType string, ok bool) {
+	viewType, ok = types[ext]
+	return viewType, ok
+}
+
 // Generate the IDs for a nested route
 // TODO: consolidate with the function in internal/generator/action/loader.go.
 func routeDir(dir string) string {
@@ -215,3 +250,11 @@ func client(name string) string {
 	dir, path := filepath.Split(name)
 	return fmt.Sprintf("bud/%s_%s.js", dir, path)
 }
+
+// The stylesheet bundled from the client's entrypoint
+//
+//	e.g. view/index.svelte => bud/view/_index.svelte.css
+func stylesheet(name string) string {
+	dir, path := filepath.Split(name)
+	return fmt.Sprintf("bud/%s_%s.css", dir, path)
+}
@@ -37,50 +37,63 @@ func TestList(t *testing.T) {
 	}
 	views, err := entrypoint.List(fsys)
 	is.NoErr(err)
-	is.Equal(len(views), 6)
-	// index.svelte
-	is.Equal(views[0].Page, entrypoint.Path("view/index.svelte"))
+	is.Equal(len(views), 7)
+	// first-post.md is rendered with the Svelte layout, frames and error page
+	is.Equal(views[0].Page, entrypoint.Path("view/first-post.md"))
 	is.Equal(len(views[0].Frames), 1)
 	is.Equal(views[0].Frames[0], entrypoint.Path("view/Frame.svelte"))
 	is.Equal(views[0].Layout, entrypoint.Path("view/Layout.svelte"))
 	is.Equal(views[0].Error, entrypoint.Path("view/Error.svelte"))
 	is.Equal(views[0].Type, "svelte")
-	is.Equal(views[0].Route, "/")
-	is.Equal(views[0].Client, "bud/view/_index.svelte.js")
-	is.Equal(views[0].Hot, ":35729")
-	// user/edit.svelte
-	is.Equal(views[1].Page, entrypoint.Path("view/user/edit.svelte"))
-	is.Equal(len(views[1].Frames), 2)
+	is.Equal(views[0].Route, "/first_post")
+	is.Equal(views[0].Client, "bud/view/_first-post.md.js")
+	is.Equal(views[0].Styles, []string{"/bud/view/_first-post.md.css"})
+	is.Equal(views[0].Hot, "/bud/hot/view/first-post.md")
+	// index.svelte
+	is.Equal(views[1].Page, entrypoint.Path("view/index.svelte"))
+	is.Equal(len(views[1].Frames), 1)
 	is.Equal(views[1].Frames[0], entrypoint.Path("view/Frame.svelte"))
-	is.Equal(views[1].Frames[1], entrypoint.Path("view/user/Frame.svelte"))
 	is.Equal(views[1].Layout, entrypoint.Path("view/Layout.svelte"))
-	is.Equal(views[1].Error, entrypoint.Path("view/user/Error.svelte"))
+	is.Equal(views[1].Error, entrypoint.Path("view/Error.svelte"))
 	is.Equal(views[1].Type, "svelte")
-	is.Equal(views[1].Route, "/user/:id/edit")
-	is.Equal(views[1].Client, "bud/view/user/_edit.svelte.js")
-	is.Equal(views[1].Hot, ":35729")
-	// user/index.svelte
-	is.Equal(views[2].Page, entrypoint.Path("view/user/index.svelte"))
+	is.Equal(views[1].Route, "/")
+	is.Equal(views[1].Client, "bud/view/_index.svelte.js")
+	is.Equal(views[1].Styles, []string{"/bud/view/_index.svelte.css"})
+	is.Equal(views[1].Hot, "/bud/hot/view/index.svelte")
+	// user/edit.svelte
+	is.Equal(views[2].Page, entrypoint.Path("view/user/edit.svelte"))
 	is.Equal(len(views[2].Frames), 2)
 	is.Equal(views[2].Frames[0], entrypoint.Path("view/Frame.svelte"))
 	is.Equal(views[2].Frames[1], entrypoint.Path("view/user/Frame.svelte"))
 	is.Equal(views[2].Layout, entrypoint.Path("view/Layout.svelte"))
 	is.Equal(views[2].Error, entrypoint.Path("view/user/Error.svelte"))
 	is.Equal(views[2].Type, "svelte")
-	is.Equal(views[2].Route, "/user")
-	is.Equal(views[2].Client, "bud/view/user/_index.svelte.js")
-	is.Equal(views[2].Hot, ":35729")
-	// visitor/comments/index.svelte
-	is.Equal(views[3].Page, entrypoint.Path("view/visitor/comments/edit.svelte"))
+	is.Equal(views[2].Route, "/user/:id/edit")
+	is.Equal(views[2].Client, "bud/view/user/_edit.svelte.js")
+	is.Equal(views[2].Styles, []string{"/bud/view/user/_edit.svelte.css"})
+	is.Equal(views[2].Hot, "/bud/hot/view/user/edit.svelte")
+	// user/index.svelte
+	is.Equal(views[3].Page, entrypoint.Path("view/user/index.svelte"))
 	is.Equal(len(views[3].Frames), 2)
 	is.Equal(views[3].Frames[0], entrypoint.Path("view/Frame.svelte"))
-	is.Equal(views[3].Frames[1], entrypoint.Path("view/visitor/comments/Frame.svelte"))
-	is.Equal(views[3].Layout, entrypoint.Path("view/visitor/comments/Layout.svelte"))
-	is.Equal(views[3].Error, entrypoint.Path("view/visitor/comments/Error.svelte"))
+	is.Equal(views[3].Frames[1], entrypoint.Path("view/user/Frame.svelte"))
+	is.Equal(views[3].Layout, entrypoint.Path("view/Layout.svelte"))
+	is.Equal(views[3].Error, entrypoint.Path("view/user/Error.svelte"))
 	is.Equal(views[3].Type, "svelte")
-	is.Equal(views[3].Route, "/visitor/:visitor_id/comments/:id/edit")
-	is.Equal(views[3].Client, "bud/view/visitor/comments/_edit.svelte.js")
-	is.Equal(views[3].Hot, ":35729")
+	is.Equal(views[3].Route, "/user")
+	is.Equal(views[3].Client, "bud/view/user/_index.svelte.js")
+	is.Equal(views[3].Hot, "/bud/hot/view/user/index.svelte")
+	// visitor/comments/index.svelte
+	is.Equal(views[4].Page, entrypoint.Path("view/visitor/comments/edit.svelte"))
+	is.Equal(len(views[4].Frames), 2)
+	is.Equal(views[4].Frames[0], entrypoint.Path("view/Frame.svelte"))
+	is.Equal(views[4].Frames[1], entrypoint.Path("view/visitor/comments/Frame.svelte"))
+	is.Equal(views[4].Layout, entrypoint.Path("view/visitor/comments/Layout.svelte"))
+	is.Equal(views[4].Error, entrypoint.Path("view/visitor/comments/Error.svelte"))
+	is.Equal(views[4].Type, "svelte")
+	is.Equal(views[4].Route, "/visitor/:visitor_id/comments/:id/edit")
+	is.Equal(views[4].Client, "bud/view/visitor/comments/_edit.svelte.js")
+	is.Equal(views[4].Hot, "/bud/hot/view/visitor/comments/edit.svelte")
 }
 
 func TestListUnderscore(t *testing.T) {
@@ -101,7 +114,7 @@ func TestListUnderscore(t *testing.T) {
 	is.Equal(views[0].Type, "svelte")
 	is.Equal(views[0].Route, "/admin_users/:admin_user_id/comments/:id")
 	is.Equal(views[0].Client, "bud/admin_users/comments/_show.svelte.js")
-	is.Equal(views[0].Hot, ":35729")
+	is.Equal(views[0].Hot, "/bud/hot/admin_users/comments/show.svelte")
 
 	is.Equal(views[1].Page, entrypoint.Path("vip_users.svelte"))
 	is.Equal(len(views[1].Frames), 0)
@@ -110,5 +123,109 @@ func TestListUnderscore(t *testing.T) {
 	is.Equal(views[1].Type, "svelte")
 	is.Equal(views[1].Route, "/vip_users")
 	is.Equal(views[1].Client, "bud/_vip_users.svelte.js")
-	is.Equal(views[1].Hot, ":35729")
+	is.Equal(views[1].Hot, "/bud/hot/vip_users.svelte")
+}
+
+func TestListDir(t *testing.T) {
+	is := is.New(t)
+	fsys := vfs.Map{
+		"view/Frame.svelte":             []byte(""),
+		"view/Error.svelte":             []byte(""),
+		"view/index.svelte":             []byte(""),
+		"view/user/Frame.svelte":        []byte(""),
+		"view/user/Error.svelte":        []byte(""),
+		"view/user/index.svelte":        []byte(""),
+		"view/user/posts/index.svelte":  []byte(""),
+		"view/user/posts/Layout.svelte": []byte(""),
+	}
+	views, err := entrypoint.List(fsys, "view")
+	is.NoErr(err)
+	is.Equal(len(views), 3)
+	// index.svelte
+	is.Equal(views[0].Page, entrypoint.Path("view/index.svelte"))
+	is.Equal(views[0].Frames, []entrypoint.Path{"view/Frame.svelte"})
+	is.Equal(views[0].Error, entrypoint.Path("view/Error.svelte"))
+	// user/index.svelte
+	is.Equal(views[1].Page, entrypoint.Path("view/user/index.svelte"))
+	is.Equal(views[1].Frames, []entrypoint.Path{"view/Frame.svelte", "view/user/Frame.svelte"})
+	is.Equal(views[1].Error, entrypoint.Path("view/user/Error.svelte"))
+	// user/posts/index.svelte
+	is.Equal(views[2].Page, entrypoint.Path("view/user/posts/index.svelte"))
+	is.Equal(views[2].Frames, []entrypoint.Path{"view/Frame.svelte", "view/user/Frame.svelte"})
+	is.Equal(views[2].Error, entrypoint.Path("view/user/Error.svelte"))
+	is.Equal(views[2].Layout, entrypoint.Path("view/user/posts/Layout.svelte"))
+}
+
+func TestManifest(t *testing.T) {
+	is := is.New(t)
+	fsys := vfs.Map{
+		"view/index.svelte":      []byte(""),
+		"view/user/index.svelte": []byte(""),
+	}
+	views, err := entrypoint.List(fsys, "view")
+	is.NoErr(err)
+	is.Equal(len(views), 2)
+	manifest := entrypoint.Manifest{
+		"view/index.svelte": &entrypoint.Assets{
+			Script:   "/bud/view/_index.svelte.5e4d3c2b.js",
+			Preloads: []string{"/bud/view/chunk-24MBV2RM.js"},
+			Styles:   []string{"/bud/view/chunk-24MBV2RM.3f9a2c1d.css", "/bud/view/_index.svelte.8a7b6c5d.css"},
+		},
+	}
+	manifest.Apply(views[0])
+	is.Equal(views[0].Client, "bud/view/_index.svelte.5e4d3c2b.js")
+	is.Equal(views[0].Preloads, []string{"/bud/view/chunk-24MBV2RM.js"})
+	is.Equal(views[0].Styles, []string{"/bud/view/chunk-24MBV2RM.3f9a2c1d.css", "/bud/view/_index.svelte.8a7b6c5d.css"})
+	// Views missing from the manifest keep their defaults
+	manifest.Apply(views[1])
+	is.Equal(views[1].Client, "bud/view/user/_index.svelte.js")
+	is.Equal(len(views[1].Preloads), 0)
+	is.Equal(views[1].Styles, []string{"/bud/view/user/_index.svelte.css"})
+}
+
+func TestIslands(t *testing.T) {
+	is := is.New(t)
+	fsys := vfs.Map{
+		"view/Frame.svelte":      []byte(`<script>import Nav from "./Nav.svelte"</script><Nav /><slot />`),
+		"view/Nav.svelte":        []byte(`<script>import Search from "./Search.island.svelte"</script><Search />`),
+		"view/Layout.svelte":     []byte(`<slot />`),
+		"view/Error.svelte":      []byte(`<script>import Retry from "./Retry.island.svelte"</script><Retry />`),
+		"view/index.svelte":      []byte(`<script>import Counter from "./Counter.island.svelte"</script><Counter />`),
+		"view/blog/Frame.svelte": []byte(`<slot />`),
+		"view/blog/index.svelte": []byte(`<script context="module">
+			export const islands = true
+		</script>
+		<script>
+			import Counter from "../Counter.island.svelte"
+			import Card from "./Card.svelte"
+		</script>
+		<Card /><Counter />`),
+		"view/blog/Card.svelte":        []byte(`<script>import Counter from "../Counter.island.svelte"</script><Counter />`),
+		"view/blog/first-post.md":      []byte("---\nislands: true\n---\nimport Like from \"./Like.island.svelte\"\n\n<Like />"),
+		"view/blog/draft.md":           []byte("---\ntitle: Draft\n---\n# Draft"),
+		"view/Counter.island.svelte":   []byte(`<script>import Nav from "./Nav.svelte"</script><Nav />`),
+		"view/Retry.island.svelte":     []byte(``),
+		"view/Search.island.svelte":    []byte(``),
+		"view/blog/Like.island.svelte": []byte(``),
+	}
+	views, err := entrypoint.List(fsys, "view")
+	is.NoErr(err)
+	is.Equal(len(views), 4)
+	// Markdown pages opt in with their front matter
+	is.Equal(views[0].Page, entrypoint.Path("view/blog/draft.md"))
+	is.True(!views[0].Partial)
+	is.Equal(views[1].Page, entrypoint.Path("view/blog/first-post.md"))
+	is.True(views[1].Partial)
+	is.Equal(views[1].Islands, []entrypoint.Path{"view/blog/Like.island.svelte", "view/Search.island.svelte"})
+	is.Equal(views[1].Inert, []entrypoint.Path{"view/blog/first-post.md", "view/Frame.svelte", "view/Nav.svelte", "view/blog/Frame.svelte"})
+	// Svelte pages opt in with a module export
+	is.Equal(views[2].Page, entrypoint.Path("view/blog/index.svelte"))
+	is.True(views[2].Partial)
+	is.Equal(views[2].Islands, []entrypoint.Path{"view/Counter.island.svelte", "view/Search.island.svelte"})
+	is.Equal(views[2].Inert, []entrypoint.Path{"view/blog/index.svelte", "view/blog/Card.svelte", "view/Frame.svelte", "view/Nav.svelte", "view/blog/Frame.svelte"})
+	// Other pages are hydrated in full
+	is.Equal(views[3].Page, entrypoint.Path("view/index.svelte"))
+	is.True(!views[3].Partial)
+	is.Equal(len(views[3].Islands), 0)
+	is.Equal(len(views[3].Inert), 0)
 }
@@ -0,0 +1,27 @@
+package entrypoint
+
+import "strings"
+
+// Manifest maps pages to the client-side assets they link to. Embedded builds
+// use the manifest to link to content-hashed assets.
+type Manifest map[Path]*Assets
+
+// Assets linked from a page's server-rendered HTML
+type Assets struct {
+	Script   string   `json:"script,omitempty"`
+	Preloads []string `json:"preloads,omitempty"`
+	Styles   []string `json:"styles,omitempty"`
+}
+
+// Apply the manifest to the view, overriding the default assets
+func (m Manifest) Apply(view *View) {
+	assets, ok := m[view.Page]
+	if !ok {
+		return
+	}
+	if assets.Script != "" {
+		view.Client = strings.TrimPrefix(assets.Script, "/")
+	}
+	view.Preloads = assets.Preloads
+	view.Styles = assets.Styles
+}
@@ -12,14 +12,22 @@ import (
 )
 
 type View struct {
-	Page   Path   // Path to the page
-	Type   string // View extension
-	Route  string
-	Frames []Path
-	Layout Path
-	Error  Path
-	Client string
-	Hot    string
+	Page     Path   // Path to the page
+	Type     string // View extension
+	Route    string
+	Frames   []Path
+	Layout   Path
+	Error    Path
+	Client   string
+	Hot      string   // Path to the hot reload event stream
+	Preloads []string // Scripts preloaded from the server-rendered HTML
+	Styles   []string // Stylesheets linked from the server-rendered HTML
+	// Partial pages are in islands mode. Only the interactive components
+	// (islands) are hydrated, the rest of the page stays as it was rendered on
+	// the server.
+	Partial bool
+	Islands []Path // Islands rendered within a partial page
+	Inert   []Path // Components that are only rendered on the server
 }
 
 func (v *View) ServerImports() (imports []Path) {
@@ -105,6 +113,13 @@ func (path Path) Frame() bool {
 	return extless(filepath.Base(string(path))) == "frame"
 }
 
+// Island returns true for interactive components that are hydrated within
+// partial pages (e.g. view/Counter.island.svelte)
+func (path Path) Island() bool {
+	base := filepath.Base(string(path))
+	return strings.HasSuffix(strings.TrimSuffix(base, filepath.Ext(base)), ".island")
+}
+
 func (path Path) Error() bool {
 	return extless(filepath.Base(string(path))) == "error"
 }
@@ -0,0 +1,54 @@
+package esmeta
+
+import (
+	"strings"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+)
+
+// Errors returns an error for the messages of a failed build
+func Errors(messages []esbuild.Message) error {
+	return &Error{messages}
+}
+
+// Error from a failed build. The first message is used to locate the error.
+type Error struct {
+	Messages []esbuild.Message
+}
+
+func (e *Error) Error() string {
+	msgs := esbuild.FormatMessages(e.Messages, esbuild.FormatMessagesOptions{
+		Color:         true,
+		Kind:          esbuild.ErrorMessage,
+		TerminalWidth: 80,
+	})
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the error thrown by a plugin, if any. Plugin errors often
+// know more about where they happened than esbuild does.
+func (e *Error) Unwrap() error {
+	if len(e.Messages) == 0 {
+		return nil
+	}
+	err, _ := e.Messages[0].Detail.(error)
+	return err
+}
+
+// Location returns the file, line and 1-based column of the first message
+func (e *Error) Location() (file string, line, column int) {
+	if len(e.Messages) == 0 || e.Messages[0].Location == nil {
+		return "", 0, 0
+	}
+	location := e.Messages[0].Location
+	return location.File, location.Line, location.Column + 1
+}
+
+// CodeFrame returns the line of code where the first message happened
+func (e *Error) CodeFrame() string {
+	if len(e.Messages) == 0 || e.Messages[0].Location == nil {
+		return ""
+	}
+	location := e.Messages[0].Location
+	return location.LineText + "\n" + strings.Repeat(" ", location.Column) + "^"
+}
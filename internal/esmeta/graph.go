@@ -0,0 +1,106 @@
+package esmeta
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NewGraph creates an empty module graph
+func NewGraph() *Graph {
+	return &Graph{
+		importers: map[string]map[string]struct{}{},
+		entries:   map[string]map[string]struct{}{},
+	}
+}
+
+// Graph of the imports between modules, built up from the metafiles of many
+// builds. Graph is safe for concurrent use.
+type Graph struct {
+	mu        sync.RWMutex
+	importers map[string]map[string]struct{}
+	entries   map[string]map[string]struct{} // entry => dependencies
+}
+
+// Add the imports in the metafile to the graph. Modules that don't import
+// anything anymore are left alone, so stale edges may remain until the module
+// is rebuilt.
+func (g *Graph) Add(file *File) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for from, input := range file.Inputs {
+		if isVirtual(from) {
+			continue
+		}
+		for _, imp := range input.Imports {
+			if isVirtual(imp.Path) {
+				continue
+			}
+			importers, ok := g.importers[imp.Path]
+			if !ok {
+				importers = map[string]struct{}{}
+				g.importers[imp.Path] = importers
+			}
+			importers[from] = struct{}{}
+		}
+	}
+}
+
+// Importers returns the modules that import path, directly or through other
+// modules. Walking up the graph stops at modules that match stop, so only the
+// nearest matching importers are returned along with the modules in between.
+func (g *Graph) Importers(path string, stop func(path string) bool) (importers []string) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	seen := map[string]bool{path: true}
+	queue := []string{path}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for importer := range g.importers[current] {
+			if seen[importer] {
+				continue
+			}
+			seen[importer] = true
+			importers = append(importers, importer)
+			if stop != nil && stop(importer) {
+				continue
+			}
+			queue = append(queue, importer)
+		}
+	}
+	sort.Strings(importers)
+	return importers
+}
+
+// Entry replaces the dependencies of the entry with the files bundled in the
+// metafile
+func (g *Graph) Entry(entry string, file *File) {
+	deps := map[string]struct{}{}
+	for _, dep := range file.Dependencies() {
+		deps[dep] = struct{}{}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.entries[entry] = deps
+}
+
+// Entries returns the entries that depend on path. Only the entries that have
+// been built are known, so nil may also mean the path hasn't been built yet.
+func (g *Graph) Entries(path string) (entries []string) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for entry, deps := range g.entries {
+		if _, ok := deps[path]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// Virtual modules (e.g. dom:bud/view/_index.svelte.js) and external modules
+// aren't files that can change
+func isVirtual(path string) bool {
+	return strings.IndexByte(path, ':') >= 0
+}
@@ -0,0 +1,89 @@
+package esmeta_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/livebud/bud/internal/esmeta"
+	"github.com/livebud/bud/internal/is"
+)
+
+const metafile = `{
+	"inputs": {
+		"view/util.js": {"bytes": 10},
+		"view/format.js": {
+			"bytes": 10,
+			"imports": [{"path": "view/util.js", "kind": "import-statement"}]
+		},
+		"view/Nav.svelte": {
+			"bytes": 10,
+			"imports": [
+				{"path": "view/format.js", "kind": "import-statement"},
+				{"path": "__LIVEBUD_EXTERNAL__:svelte/internal", "kind": "import-statement"}
+			]
+		},
+		"view/index.svelte": {
+			"bytes": 10,
+			"imports": [{"path": "view/Nav.svelte", "kind": "import-statement"}]
+		},
+		"dom:bud/view/_index.svelte.js": {
+			"bytes": 10,
+			"imports": [{"path": "view/index.svelte", "kind": "import-statement"}]
+		}
+	}
+}`
+
+func isSvelte(p string) bool {
+	return path.Ext(p) == ".svelte"
+}
+
+func TestImporters(t *testing.T) {
+	is := is.New(t)
+	file, err := esmeta.Parse(metafile)
+	is.NoErr(err)
+	graph := esmeta.NewGraph()
+	graph.Add(file)
+	// Walk up to the nearest component
+	is.Equal(graph.Importers("view/util.js", isSvelte), []string{"view/Nav.svelte", "view/format.js"})
+	is.Equal(graph.Importers("view/Nav.svelte", isSvelte), []string{"view/index.svelte"})
+	// Walk all the way up, skipping virtual modules
+	is.Equal(graph.Importers("view/util.js", nil), []string{"view/Nav.svelte", "view/format.js", "view/index.svelte"})
+	is.Equal(len(graph.Importers("view/index.svelte", nil)), 0)
+	is.Equal(len(graph.Importers("svelte/internal", nil)), 0)
+}
+
+func TestEntries(t *testing.T) {
+	is := is.New(t)
+	index, err := esmeta.Parse(`{
+		"outputs": {
+			"_index.svelte.js": {
+				"inputs": {
+					"view/Nav.svelte": {},
+					"view/index.svelte": {},
+					"dom:bud/view/_index.svelte.js": {}
+				}
+			}
+		}
+	}`)
+	is.NoErr(err)
+	about, err := esmeta.Parse(`{
+		"outputs": {
+			"_about.svelte.js": {
+				"inputs": {
+					"view/Nav.svelte": {},
+					"view/about.svelte": {}
+				}
+			}
+		}
+	}`)
+	is.NoErr(err)
+	graph := esmeta.NewGraph()
+	graph.Entry("view/index.svelte", index)
+	graph.Entry("view/about.svelte", about)
+	is.Equal(graph.Entries("view/Nav.svelte"), []string{"view/about.svelte", "view/index.svelte"})
+	is.Equal(graph.Entries("view/about.svelte"), []string{"view/about.svelte"})
+	is.Equal(len(graph.Entries("view/unknown.svelte")), 0)
+	// Rebuilding an entry replaces its dependencies
+	graph.Entry("view/about.svelte", new(esmeta.File))
+	is.Equal(graph.Entries("view/Nav.svelte"), []string{"view/index.svelte"})
+}
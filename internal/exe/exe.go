@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"sync/atomic"
 
 	"github.com/livebud/bud/internal/once"
 )
@@ -59,16 +60,18 @@ func Start(ctx context.Context, cmd *exec.Cmd) (*Process, error) {
 // Wrap a command in a process
 func wrap(ctx context.Context, cmd *exec.Cmd) *Process {
 	return &Process{
-		ctx:    ctx,
-		cmd:    cmd,
-		exitCh: make(chan error),
+		ctx:  ctx,
+		cmd:  cmd,
+		done: make(chan struct{}),
 	}
 }
 
 type Process struct {
 	cmd       *exec.Cmd
 	ctx       context.Context
-	exitCh    chan error
+	done      chan struct{} // closed when the process exits
+	err       error         // exit error, set before done is closed
+	closed    int32         // set when the process is closed on purpose
 	closeOnce once.Error
 }
 
@@ -88,11 +91,13 @@ func (p *Process) Run() error {
 }
 
 func (p *Process) wait() {
-	p.exitCh <- p.cmd.Wait()
+	p.err = p.cmd.Wait()
+	close(p.done)
 }
 
 // Close command
 func (p *Process) close() error {
+	atomic.StoreInt32(&p.closed, 1)
 	sp := p.cmd.Process
 	if sp == nil {
 		return nil
@@ -107,11 +112,10 @@ func (p *Process) close() error {
 			return err
 		}
 	}
-	err := <-p.exitCh
-	close(p.exitCh)
-	if err != nil {
-		if !expectError(err) {
-			return err
+	<-p.done
+	if p.err != nil {
+		if !expectError(p.err) {
+			return p.err
 		}
 	}
 	return nil
@@ -128,11 +132,21 @@ func (p *Process) Wait() error {
 			return err
 		}
 		return nil
-	case err := <-p.exitCh:
-		return err
+	case <-p.done:
+		// Closing the process on purpose isn't an error
+		if atomic.LoadInt32(&p.closed) == 1 {
+			return p.Close()
+		}
+		return p.err
 	}
 }
 
+// Done is closed when the process exits, whether or not it was closed on
+// purpose.
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
 func (p *Process) Restart(ctx context.Context) (*Process, error) {
 	// Close the process first
 	if err := p.Close(); err != nil {
@@ -111,3 +111,27 @@ func TestCloseWaitWait(t *testing.T) {
 	}
 	testsub.Run(t, parent, child)
 }
+
+func TestDoneExited(t *testing.T) {
+	is := is.New(t)
+	parent := func(t testing.TB, cmd *exec.Cmd) {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		p, err := exe.Start(context.Background(), cmd)
+		is.NoErr(err)
+		select {
+		case <-p.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the process to exit")
+		}
+		err = p.Wait()
+		is.True(err != nil)
+		is.Equal(err.Error(), "exit status 2")
+		is.NoErr(p.Close())
+	}
+	child := func(t testing.TB) {
+		time.Sleep(100 * time.Millisecond)
+		os.Exit(2)
+	}
+	testsub.Run(t, parent, child)
+}
@@ -0,0 +1,47 @@
+package fingerprint
+
+import (
+	"encoding/hex"
+	"path"
+	"strings"
+
+	"github.com/cespare/xxhash"
+)
+
+// Hash the contents of a file into a short, URL-safe string
+func Hash(data []byte) string {
+	hash := xxhash.New()
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil))[:8]
+}
+
+// Path inserts the hash of data before the extension of path.
+//
+//	e.g. view/_index.svelte.css => view/_index.svelte.3f9a2c1d.css
+func Path(p string, data []byte) string {
+	return Join(p, Hash(data))
+}
+
+// Join inserts the hash before the extension of path.
+func Join(p, hash string) string {
+	ext := path.Ext(p)
+	return strings.TrimSuffix(p, ext) + "." + hash + ext
+}
+
+// Split removes the hash from a fingerprinted path. Returns false if the path
+// doesn't look fingerprinted.
+//
+//	e.g. logo.3f9a2c1d.png => logo.png, 3f9a2c1d
+func Split(p string) (original, hash string, ok bool) {
+	ext := path.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	hashExt := path.Ext(base)
+	hash = strings.TrimPrefix(hashExt, ".")
+	if len(hash) != 8 {
+		return p, "", false
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return p, "", false
+	}
+	return strings.TrimSuffix(base, hashExt) + ext, hash, true
+}
@@ -0,0 +1,41 @@
+package fingerprint_test
+
+import (
+	"testing"
+
+	"github.com/livebud/bud/internal/fingerprint"
+	"github.com/livebud/bud/internal/is"
+)
+
+func TestHash(t *testing.T) {
+	is := is.New(t)
+	hash := fingerprint.Hash([]byte("h1 { color: red }"))
+	is.Equal(len(hash), 8)
+	is.Equal(hash, fingerprint.Hash([]byte("h1 { color: red }")))
+	is.True(hash != fingerprint.Hash([]byte("h1 { color: blue }")))
+}
+
+func TestPath(t *testing.T) {
+	is := is.New(t)
+	data := []byte("h1 { color: red }")
+	hash := fingerprint.Hash(data)
+	is.Equal(fingerprint.Path("view/_index.svelte.css", data), "view/_index.svelte."+hash+".css")
+	is.Equal(fingerprint.Path("chunk-24MBV2RM.css", data), "chunk-24MBV2RM."+hash+".css")
+	is.Equal(fingerprint.Path("LICENSE", data), "LICENSE."+hash)
+}
+
+func TestSplit(t *testing.T) {
+	is := is.New(t)
+	original, hash, ok := fingerprint.Split("/logo.3f9a2c1d.png")
+	is.True(ok)
+	is.Equal(original, "/logo.png")
+	is.Equal(hash, "3f9a2c1d")
+	original, hash, ok = fingerprint.Split(fingerprint.Join("/js/app.min.js", "0123abcd"))
+	is.True(ok)
+	is.Equal(original, "/js/app.min.js")
+	is.Equal(hash, "0123abcd")
+	_, _, ok = fingerprint.Split("/logo.png")
+	is.True(!ok)
+	_, _, ok = fingerprint.Split("/app.notahash.js")
+	is.True(!ok)
+}
@@ -1,12 +1,15 @@
 package gobuild
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/livebud/bud/internal/imhash"
 	"github.com/livebud/bud/internal/symlink"
@@ -54,12 +57,17 @@ func (b *Builder) build(ctx context.Context, mainPath string, outPath string, fl
 	cmd.Env = append(os.Environ(),
 		"GOMODCACHE="+b.module.ModCache(),
 	)
+	// Capture the compiler errors, so they can be shown where they're needed
+	stderr := new(bytes.Buffer)
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = stderr
 	cmd.Stdin = os.Stdin
 	cmd.Dir = b.module.Directory()
 	err := cmd.Run()
 	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("gobuild: unable to build. %s", msg)
+		}
 		return err
 	}
 	return nil
@@ -0,0 +1,172 @@
+// Package precompress compresses embedded assets ahead of time and serves the
+// compressed variants that the browser accepts.
+package precompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/livebud/bud/internal/embed"
+)
+
+// MinSize is the smallest file worth compressing. Smaller files often grow
+// once compressed.
+const MinSize = 1024
+
+// encoding of a compressed variant
+type encoding struct {
+	name     string // Content-Encoding
+	ext      string // Extension added to the variant's path
+	compress func(data []byte) ([]byte, error)
+}
+
+// Encodings in order of preference
+var encodings = []*encoding{
+	{"br", ".br", compressBrotli},
+	{"gzip", ".gz", compressGzip},
+}
+
+// Extensions of the files that are worth compressing. Images, fonts and media
+// are already compressed.
+var compressible = map[string]bool{
+	".css":  true,
+	".html": true,
+	".ico":  true,
+	".js":   true,
+	".json": true,
+	".map":  true,
+	".mjs":  true,
+	".svg":  true,
+	".txt":  true,
+	".wasm": true,
+	".xml":  true,
+}
+
+// Compress returns the compressed variants of the files that are worth
+// compressing. Variants are only kept if they're smaller than the original.
+func Compress(files []*embed.File) (variants []*embed.File, err error) {
+	for _, file := range files {
+		if len(file.Data) < MinSize || !compressible[path.Ext(file.Path)] {
+			continue
+		}
+		for _, encoding := range encodings {
+			data, err := encoding.compress(file.Data)
+			if err != nil {
+				return nil, err
+			}
+			if len(data) >= len(file.Data) {
+				continue
+			}
+			variants = append(variants, &embed.File{
+				Path: file.Path + encoding.ext,
+				Data: data,
+			})
+		}
+	}
+	return variants, nil
+}
+
+func compressBrotli(data []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := brotli.NewWriterLevel(buf, brotli.BestCompression)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func compressGzip(data []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Open the compressed variant of name that the request accepts, falling back
+// to name itself. Sets the Content-Encoding and Vary headers when a variant is
+// served, so the response can be passed to http.ServeContent with the original
+// name.
+func Open(w http.ResponseWriter, r *http.Request, fsys http.FileSystem, name string) (http.File, error) {
+	header := w.Header()
+	accepts := acceptedEncodings(r.Header.Get("Accept-Encoding"))
+	vary := false
+	for _, encoding := range encodings {
+		file, err := fsys.Open(name + encoding.ext)
+		if err != nil {
+			continue
+		}
+		// The response depends on Accept-Encoding once there's a variant
+		if !vary {
+			header.Add("Vary", "Accept-Encoding")
+			vary = true
+		}
+		if !accepts[encoding.name] {
+			file.Close()
+			continue
+		}
+		header.Set("Content-Encoding", encoding.name)
+		// Don't let http.ServeContent sniff the compressed content
+		if header.Get("Content-Type") == "" {
+			if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+				header.Set("Content-Type", contentType)
+			}
+		}
+		return file, nil
+	}
+	return fsys.Open(name)
+}
+
+// acceptedEncodings parses the Accept-Encoding header. Encodings with a
+// quality of 0 aren't accepted.
+func acceptedEncodings(header string) map[string]bool {
+	accepts := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		accepts[name] = quality(params) > 0
+	}
+	if accepts["*"] {
+		for _, encoding := range encodings {
+			if _, ok := accepts[encoding.name]; !ok {
+				accepts[encoding.name] = true
+			}
+		}
+	}
+	return accepts
+}
+
+// quality returns the q parameter, defaulting to 1
+func quality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.TrimSpace(key) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
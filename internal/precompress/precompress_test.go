@@ -0,0 +1,93 @@
+package precompress_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andybalholm/brotli"
+	"github.com/livebud/bud/internal/embed"
+	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/internal/precompress"
+)
+
+var script = strings.Repeat("console.log('hello world')\n", 100)
+
+func TestCompress(t *testing.T) {
+	is := is.New(t)
+	variants, err := precompress.Compress([]*embed.File{
+		{Path: "public/app.js", Data: []byte(script)},
+		{Path: "public/small.js", Data: []byte("console.log('hi')")},
+		{Path: "public/image.png", Data: []byte(script)},
+	})
+	is.NoErr(err)
+	is.Equal(len(variants), 2)
+	is.Equal(variants[0].Path, "public/app.js.br")
+	is.Equal(variants[1].Path, "public/app.js.gz")
+	br, err := io.ReadAll(brotli.NewReader(bytes.NewReader(variants[0].Data)))
+	is.NoErr(err)
+	is.Equal(string(br), script)
+	gz, err := gzip.NewReader(bytes.NewReader(variants[1].Data))
+	is.NoErr(err)
+	data, err := io.ReadAll(gz)
+	is.NoErr(err)
+	is.Equal(string(data), script)
+}
+
+func serve(fsys fstest.MapFS, name, acceptEncoding string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/"+name, nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	file, err := precompress.Open(rec, req, http.FS(fsys), name)
+	if err != nil {
+		http.Error(rec, err.Error(), http.StatusInternalServerError)
+		return rec
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		http.Error(rec, err.Error(), http.StatusInternalServerError)
+		return rec
+	}
+	http.ServeContent(rec, req, name, stat.ModTime(), file)
+	return rec
+}
+
+func TestOpen(t *testing.T) {
+	is := is.New(t)
+	fsys := fstest.MapFS{
+		"app.js":    &fstest.MapFile{Data: []byte(script)},
+		"app.js.br": &fstest.MapFile{Data: []byte("br")},
+		"app.js.gz": &fstest.MapFile{Data: []byte("gz")},
+		"small.js":  &fstest.MapFile{Data: []byte("console.log('hi')")},
+	}
+	// Prefer brotli
+	rec := serve(fsys, "app.js", "gzip, deflate, br")
+	is.Equal(rec.Code, 200)
+	is.Equal(rec.Header().Get("Content-Encoding"), "br")
+	is.Equal(rec.Header().Get("Vary"), "Accept-Encoding")
+	is.In(rec.Header().Get("Content-Type"), "javascript")
+	is.Equal(rec.Body.String(), "br")
+	// Fallback to gzip
+	rec = serve(fsys, "app.js", "gzip;q=0.8, br;q=0")
+	is.Equal(rec.Header().Get("Content-Encoding"), "gzip")
+	is.Equal(rec.Body.String(), "gz")
+	// Fallback to the original
+	rec = serve(fsys, "app.js", "identity")
+	is.Equal(rec.Header().Get("Content-Encoding"), "")
+	is.Equal(rec.Header().Get("Vary"), "Accept-Encoding")
+	is.Equal(rec.Body.String(), script)
+	// Any encoding
+	rec = serve(fsys, "app.js", "*")
+	is.Equal(rec.Header().Get("Content-Encoding"), "br")
+	// No variants
+	rec = serve(fsys, "small.js", "gzip, br")
+	is.Equal(rec.Header().Get("Content-Encoding"), "")
+	is.Equal(rec.Header().Get("Vary"), "")
+	is.Equal(rec.Body.String(), "console.log('hi')")
+}
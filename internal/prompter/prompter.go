@@ -139,6 +139,15 @@ func (p *Prompter) FailReload(err string) {
 	console.Error(err)
 }
 
+// Prompt that the app crashed. The app can crash at any time, so this moves
+// straight to the failed state. Reset counter.
+func (p *Prompter) Crashed(err string) {
+	p.oldState = p.state
+	p.state = fail
+	p.Counter = 0 // Reset counter
+	console.Error(err)
+}
+
 func different(events, oldEvents []watcher.Event) bool {
 	if len(events) != len(oldEvents) {
 		return true
@@ -0,0 +1,147 @@
+// Package resize scales down public images to the widths that views ask for.
+package resize
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// MaxWidth is the widest that images can be resized to
+const MaxWidth = 4096
+
+// Supported returns true if we can resize images with this extension
+func Supported(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	default:
+		return false
+	}
+}
+
+// Path returns the path of the variant of p that's resized to width.
+//
+//	e.g. public/img/hero.png => bud/public/img/hero.w800.png
+func Path(p string, width int) string {
+	ext := path.Ext(p)
+	return path.Join("bud", strings.TrimSuffix(p, ext)+".w"+strconv.Itoa(width)+ext)
+}
+
+// Parse the path of a resized variant back into the original path and width.
+// Returns false if the path isn't a resized variant.
+func Parse(p string) (original string, width int, ok bool) {
+	if !strings.HasPrefix(p, "bud/") {
+		return "", 0, false
+	}
+	p = strings.TrimPrefix(p, "bud/")
+	ext := path.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	widthExt := path.Ext(base)
+	if !strings.HasPrefix(widthExt, ".w") {
+		return "", 0, false
+	}
+	width, err := strconv.Atoi(strings.TrimPrefix(widthExt, ".w"))
+	if err != nil || width <= 0 {
+		return "", 0, false
+	}
+	return strings.TrimSuffix(base, widthExt) + ext, width, true
+}
+
+// Size reads the width and height of an image without decoding all of it
+func Size(r io.Reader) (width, height int, err error) {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
+
+// File resizes the encoded image to width, keeping its aspect ratio and format.
+// Images are only ever scaled down, so the original is returned when it's
+// already narrow enough.
+func File(data []byte, width int) ([]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("resize: unable to decode image. %w", err)
+	}
+	if width >= img.Bounds().Dx() {
+		return data, nil
+	}
+	resized := Width(img, width)
+	buf := new(bytes.Buffer)
+	switch format {
+	case "png":
+		err = png.Encode(buf, resized)
+	case "jpeg":
+		err = jpeg.Encode(buf, resized, &jpeg.Options{Quality: 85})
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resize: unable to encode image. %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Width scales img down to width, keeping its aspect ratio. Each pixel is the
+// average of the pixels it covers in the original.
+func Width(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if width >= srcWidth || srcWidth == 0 {
+		return img
+	}
+	height := Height(srcWidth, srcHeight, width)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, srcHeight)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, srcWidth)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			// Colors are premultiplied by alpha, so they can be averaged directly
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
+
+// Height returns the height of an image that's scaled to width, keeping its
+// aspect ratio
+func Height(srcWidth, srcHeight, width int) int {
+	height := (srcHeight*width + srcWidth/2) / srcWidth
+	if height < 1 {
+		return 1
+	}
+	return height
+}
+
+// span returns the range of source pixels covered by the ith destination pixel
+func span(i, dstSize, srcSize int) (start, end int) {
+	start = i * srcSize / dstSize
+	end = (i + 1) * srcSize / dstSize
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
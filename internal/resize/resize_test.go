@@ -0,0 +1,92 @@
+package resize_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/internal/resize"
+)
+
+func checkerboard(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x+y)%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
+
+func TestPath(t *testing.T) {
+	is := is.New(t)
+	is.Equal(resize.Path("public/img/hero.png", 800), "bud/public/img/hero.w800.png")
+	original, width, ok := resize.Parse("bud/public/img/hero.w800.png")
+	is.True(ok)
+	is.Equal(original, "public/img/hero.png")
+	is.Equal(width, 800)
+	_, _, ok = resize.Parse("bud/public/img/hero.png")
+	is.True(!ok)
+	_, _, ok = resize.Parse("public/img/hero.w800.png")
+	is.True(!ok)
+	_, _, ok = resize.Parse("bud/public/img/hero.wide.png")
+	is.True(!ok)
+}
+
+func TestWidth(t *testing.T) {
+	is := is.New(t)
+	img := resize.Width(checkerboard(100, 50), 40)
+	is.Equal(img.Bounds().Dx(), 40)
+	is.Equal(img.Bounds().Dy(), 20)
+	// Black and white average out to gray
+	r, g, b, a := img.At(10, 10).RGBA()
+	is.True(r > 0x6000 && r < 0xa000)
+	is.Equal(r, g)
+	is.Equal(g, b)
+	is.Equal(a, uint32(0xffff))
+	// Images are never scaled up
+	img = resize.Width(checkerboard(10, 10), 40)
+	is.Equal(img.Bounds().Dx(), 10)
+}
+
+func TestFilePNG(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+	is.NoErr(png.Encode(buf, checkerboard(300, 200)))
+	data, err := resize.File(buf.Bytes(), 150)
+	is.NoErr(err)
+	width, height, err := resize.Size(bytes.NewReader(data))
+	is.NoErr(err)
+	is.Equal(width, 150)
+	is.Equal(height, 100)
+	_, format, err := image.Decode(bytes.NewReader(data))
+	is.NoErr(err)
+	is.Equal(format, "png")
+	// Already narrow enough
+	same, err := resize.File(buf.Bytes(), 300)
+	is.NoErr(err)
+	is.Equal(same, buf.Bytes())
+}
+
+func TestFileJPEG(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+	is.NoErr(jpeg.Encode(buf, checkerboard(300, 200), nil))
+	data, err := resize.File(buf.Bytes(), 100)
+	is.NoErr(err)
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	is.NoErr(err)
+	is.Equal(format, "jpeg")
+	width, height, err := resize.Size(bytes.NewReader(data))
+	is.NoErr(err)
+	is.Equal(width, 100)
+	is.Equal(height, 67)
+}
@@ -0,0 +1,234 @@
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Map is a decoded source map
+type Map struct {
+	Sources []string
+	Names   []string
+	// Segments by generated line
+	lines [][]segment
+}
+
+// segment maps a generated column to a position in the original source
+type segment struct {
+	column       int
+	source       int
+	sourceLine   int
+	sourceColumn int
+}
+
+// Position within a source. Lines are 1-based and columns are 0-based.
+type Position struct {
+	Source string
+	Line   int
+	Column int
+}
+
+// Parse a version 3 source map
+func Parse(data []byte) (*Map, error) {
+	var raw struct {
+		Version  int      `json:"version"`
+		Sources  []string `json:"sources"`
+		Names    []string `json:"names"`
+		Mappings string   `json:"mappings"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("sourcemap: unable to parse. %w", err)
+	} else if raw.Version != 3 {
+		return nil, fmt.Errorf("sourcemap: unsupported version %d", raw.Version)
+	}
+	lines, err := decode(raw.Mappings)
+	if err != nil {
+		return nil, err
+	}
+	return &Map{raw.Sources, raw.Names, lines}, nil
+}
+
+// decode the VLQ mappings. Fields are relative to the previous segment, except
+// for the generated column, which resets on each line.
+func decode(mappings string) (lines [][]segment, err error) {
+	var source, sourceLine, sourceColumn int
+	for _, line := range strings.Split(mappings, ";") {
+		var segments []segment
+		column := 0
+		for _, field := range strings.Split(line, ",") {
+			if field == "" {
+				continue
+			}
+			values, err := decodeVLQ(field)
+			if err != nil {
+				return nil, err
+			}
+			column += values[0]
+			// Segments without a source don't map back to anything
+			if len(values) < 4 {
+				continue
+			}
+			source += values[1]
+			sourceLine += values[2]
+			sourceColumn += values[3]
+			segments = append(segments, segment{column, source, sourceLine, sourceColumn})
+		}
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].column < segments[j].column
+		})
+		lines = append(lines, segments)
+	}
+	return lines, nil
+}
+
+const vlqDigits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+func decodeVLQ(field string) (values []int, err error) {
+	value, shift := 0, 0
+	for i := 0; i < len(field); i++ {
+		digit := strings.IndexByte(vlqDigits, field[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("sourcemap: invalid mapping %q", field)
+		}
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+		// The lowest bit is the sign
+		if value&1 != 0 {
+			values = append(values, -(value >> 1))
+		} else {
+			values = append(values, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, fmt.Errorf("sourcemap: invalid mapping %q", field)
+	}
+	return values, nil
+}
+
+// Find the original position of a generated position. Lines are 1-based and
+// columns are 0-based. Positions before the first segment of a line map to
+// that first segment.
+func (m *Map) Find(line, column int) (position Position, ok bool) {
+	if line < 1 || line > len(m.lines) || len(m.lines[line-1]) == 0 {
+		return position, false
+	}
+	segments := m.lines[line-1]
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].column > column
+	})
+	if i > 0 {
+		i--
+	}
+	seg := segments[i]
+	if seg.source >= 0 && seg.source < len(m.Sources) {
+		position.Source = m.Sources[seg.source]
+	}
+	position.Line = seg.sourceLine + 1
+	position.Column = seg.sourceColumn
+	return position, true
+}
+
+// Remap the original positions, for example when the original code was itself
+// generated from other code. Lines are 1-based and columns are 0-based.
+func (m *Map) Remap(fn func(line, column int) (int, int)) {
+	for _, segments := range m.lines {
+		for i, seg := range segments {
+			line, column := fn(seg.sourceLine+1, seg.sourceColumn)
+			segments[i].sourceLine, segments[i].sourceColumn = line-1, column
+		}
+	}
+}
+
+// MarshalJSON encodes the map as a version 3 source map
+func (m *Map) MarshalJSON() ([]byte, error) {
+	sources, names := m.Sources, m.Names
+	if sources == nil {
+		sources = []string{}
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return json.Marshal(struct {
+		Version  int      `json:"version"`
+		Sources  []string `json:"sources"`
+		Names    []string `json:"names"`
+		Mappings string   `json:"mappings"`
+	}{3, sources, names, encode(m.lines)})
+}
+
+// encode the segments into VLQ mappings
+func encode(lines [][]segment) string {
+	var source, sourceLine, sourceColumn int
+	mappings := new(strings.Builder)
+	for i, segments := range lines {
+		if i > 0 {
+			mappings.WriteByte(';')
+		}
+		column := 0
+		for j, seg := range segments {
+			if j > 0 {
+				mappings.WriteByte(',')
+			}
+			encodeVLQ(mappings, seg.column-column)
+			encodeVLQ(mappings, seg.source-source)
+			encodeVLQ(mappings, seg.sourceLine-sourceLine)
+			encodeVLQ(mappings, seg.sourceColumn-sourceColumn)
+			column, source, sourceLine, sourceColumn = seg.column, seg.source, seg.sourceLine, seg.sourceColumn
+		}
+	}
+	return mappings.String()
+}
+
+func encodeVLQ(sb *strings.Builder, value int) {
+	// The lowest bit is the sign
+	if value < 0 {
+		value = (-value << 1) | 1
+	} else {
+		value <<= 1
+	}
+	for {
+		digit := value & 31
+		value >>= 5
+		if value > 0 {
+			digit |= 32
+		}
+		sb.WriteByte(vlqDigits[digit])
+		if value == 0 {
+			return
+		}
+	}
+}
+
+// inlinePrefix starts the comment that inlines a source map into the code
+const inlinePrefix = "//# sourceMappingURL=data:application/json;base64,"
+
+// Inline returns the comment that inlines the source map into the generated
+// code
+func Inline(data []byte) string {
+	return inlinePrefix + base64.StdEncoding.EncodeToString(data)
+}
+
+// Extract the source map that was inlined into the generated code
+func Extract(code []byte) (*Map, error) {
+	index := bytes.LastIndex(code, []byte(inlinePrefix))
+	if index < 0 {
+		return nil, fmt.Errorf("sourcemap: no inline source map")
+	}
+	encoded := code[index+len(inlinePrefix):]
+	if end := bytes.IndexAny(encoded, "\r\n"); end >= 0 {
+		encoded = encoded[:end]
+	}
+	data, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("sourcemap: unable to decode the inline source map. %w", err)
+	}
+	return Parse(data)
+}
@@ -0,0 +1,120 @@
+package sourcemap_test
+
+import (
+	"strings"
+	"testing"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/internal/sourcemap"
+)
+
+func TestFind(t *testing.T) {
+	is := is.New(t)
+	source := strings.Join([]string{
+		`type Greeting = {`,
+		`  name: string`,
+		`}`,
+		``,
+		`const greeting: Greeting = { name: "world" }`,
+		`throw new Error("hello " + greeting.name)`,
+	}, "\n")
+	result := esbuild.Transform(source, esbuild.TransformOptions{
+		Loader:     esbuild.LoaderTS,
+		Sourcefile: "greeting.ts",
+		Sourcemap:  esbuild.SourceMapExternal,
+	})
+	is.Equal(len(result.Errors), 0)
+	sm, err := sourcemap.Parse(result.Map)
+	is.NoErr(err)
+	is.Equal(sm.Sources, []string{"greeting.ts"})
+	// Find the throw in the generated code
+	lines := strings.Split(string(result.Code), "\n")
+	line, column := 0, 0
+	for i, text := range lines {
+		if column = strings.Index(text, "throw"); column >= 0 {
+			line = i + 1
+			break
+		}
+	}
+	is.True(line > 0)
+	is.True(line < 6)
+	position, ok := sm.Find(line, column)
+	is.True(ok)
+	is.Equal(position.Source, "greeting.ts")
+	is.Equal(position.Line, 6)
+	is.Equal(position.Column, 0)
+	// Lines outside of the map aren't found
+	position, ok = sm.Find(100, 0)
+	is.True(!ok)
+	is.Equal(position, sourcemap.Position{})
+}
+
+func TestParseInvalid(t *testing.T) {
+	is := is.New(t)
+	sm, err := sourcemap.Parse([]byte(`{"version":2,"mappings":""}`))
+	is.True(err != nil)
+	is.Equal(err.Error(), "sourcemap: unsupported version 2")
+	is.Equal(sm, nil)
+	sm, err = sourcemap.Parse([]byte(`{"version":3,"mappings":"A!"}`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `sourcemap: invalid mapping "A!"`)
+	is.Equal(sm, nil)
+}
+
+func TestMarshalRemap(t *testing.T) {
+	is := is.New(t)
+	result := esbuild.Transform("let a: number = 1\nlet b: string = 'b'\nthrow new Error(b)", esbuild.TransformOptions{
+		Loader:     esbuild.LoaderTS,
+		Sourcefile: "index.ts",
+		Sourcemap:  esbuild.SourceMapExternal,
+	})
+	is.Equal(len(result.Errors), 0)
+	sm, err := sourcemap.Parse(result.Map)
+	is.NoErr(err)
+	position, ok := sm.Find(3, 0)
+	is.True(ok)
+	is.Equal(position.Line, 3)
+	// Encoding and decoding again finds the same positions
+	data, err := sm.MarshalJSON()
+	is.NoErr(err)
+	sm, err = sourcemap.Parse(data)
+	is.NoErr(err)
+	is.Equal(sm.Sources, []string{"index.ts"})
+	remapped, ok := sm.Find(3, 0)
+	is.True(ok)
+	is.Equal(remapped, position)
+	// Move the original code down 10 lines
+	sm.Remap(func(line, column int) (int, int) {
+		return line + 10, column
+	})
+	data, err = sm.MarshalJSON()
+	is.NoErr(err)
+	sm, err = sourcemap.Parse(data)
+	is.NoErr(err)
+	remapped, ok = sm.Find(3, 0)
+	is.True(ok)
+	is.Equal(remapped.Line, 13)
+	is.Equal(remapped.Column, position.Column)
+}
+
+func TestExtract(t *testing.T) {
+	is := is.New(t)
+	result := esbuild.Transform("let a = 1\nthrow new Error(a)", esbuild.TransformOptions{
+		Sourcefile: "index.js",
+		Sourcemap:  esbuild.SourceMapExternal,
+	})
+	is.Equal(len(result.Errors), 0)
+	code := string(result.Code) + sourcemap.Inline(result.Map) + "\n"
+	sm, err := sourcemap.Extract([]byte(code))
+	is.NoErr(err)
+	is.Equal(sm.Sources, []string{"index.js"})
+	position, ok := sm.Find(2, 0)
+	is.True(ok)
+	is.Equal(position.Line, 2)
+	// Code without an inline source map
+	sm, err = sourcemap.Extract(result.Code)
+	is.True(err != nil)
+	is.Equal(err.Error(), "sourcemap: no inline source map")
+	is.Equal(sm, nil)
+}
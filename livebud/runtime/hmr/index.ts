@@ -0,0 +1,131 @@
+/**
+ * Hot module replacement for components
+ *
+ * Components register themselves by their module ID and get back a stable
+ * constructor that always creates the latest version of the component. When a
+ * module is re-imported, the new version takes over and the page is re-rendered
+ * while carrying over each component's local state.
+ */
+
+type Entry = {
+  // Latest version of the component
+  component: any
+  // Stable constructor handed out to the importers
+  proxy: any
+  // Components that are currently mounted, in the order they were created
+  instances: any[]
+}
+
+type Registry = {
+  records: { [id: string]: Entry }
+  // State captured before re-rendering, by module ID in creation order
+  pending: { [id: string]: any[] } | undefined
+}
+
+// The registry is shared on the window, so it's the same across bundles
+const registry: Registry = getRegistry()
+
+function getRegistry(): Registry {
+  const global = window as any
+  if (!global.__bud_hmr__) {
+    global.__bud_hmr__ = { records: {}, pending: undefined }
+  }
+  return global.__bud_hmr__
+}
+
+// Register the component, returning a constructor that stays the same across
+// updates
+export function register(id: string, component: any): any {
+  const entry = registry.records[id]
+  if (entry) {
+    entry.component = component
+    return entry.proxy
+  }
+  registry.records[id] = {
+    component: component,
+    proxy: createProxy(id),
+    instances: [],
+  }
+  return registry.records[id].proxy
+}
+
+// Returns true if the module has mounted components that can be swapped
+export function accepts(id: string): boolean {
+  const entry = registry.records[id]
+  return !!entry && entry.instances.length > 0
+}
+
+// Render again while preserving the state of the mounted components. Returns
+// false if the state couldn't be captured.
+export function preserve(render: () => void): boolean {
+  const pending: { [id: string]: any[] } = {}
+  for (let id in registry.records) {
+    const states: any[] = []
+    for (let instance of registry.records[id].instances) {
+      // $capture_state is only available in development
+      if (typeof instance.$capture_state !== "function") {
+        return false
+      }
+      states.push(instance.$capture_state())
+    }
+    pending[id] = states
+  }
+  registry.pending = pending
+  try {
+    render()
+  } finally {
+    registry.pending = undefined
+  }
+  return true
+}
+
+function createProxy(id: string) {
+  // Returning an object from a constructor replaces the object that's created
+  // with `new`, so importers always get an instance of the latest component
+  return function HotComponent(options: any) {
+    const entry = registry.records[id]
+    const instance = new entry.component(options)
+    track(entry, instance)
+    restore(id, instance, options)
+    return instance
+  }
+}
+
+// Track the instance until it's destroyed
+function track(entry: Entry, instance: any) {
+  entry.instances.push(instance)
+  // Nested components are destroyed by their parent without calling $destroy,
+  // so hook into Svelte's destroy callbacks instead
+  instance.$$.on_destroy.push(() => {
+    const index = entry.instances.indexOf(instance)
+    if (index >= 0) {
+      entry.instances.splice(index, 1)
+    }
+  })
+}
+
+// Restore the state captured from the previous instance, keeping the props
+// passed in by the parent
+function restore(id: string, instance: any, options: any) {
+  const pending = registry.pending && registry.pending[id]
+  if (!pending || pending.length === 0) {
+    return
+  }
+  const state = pending.shift()
+  if (!state || typeof instance.$inject_state !== "function") {
+    return
+  }
+  const props = (options && options.props) || {}
+  const local: Record<string, any> = {}
+  for (let key in state) {
+    if (!(key in props)) {
+      local[key] = state[key]
+    }
+  }
+  try {
+    instance.$inject_state(local)
+  } catch (err) {
+    // The state no longer fits the component, so start fresh
+    console.warn(`livebud: unable to restore the state of "${id}"`, err)
+  }
+}
@@ -1,4 +1,6 @@
 import { parse } from "../../url"
+import * as hmr from "../hmr"
+import * as overlay from "../overlay"
 
 /**
  * Hot reload
@@ -6,30 +8,122 @@ import { parse } from "../../url"
 
 export default class Hot {
   private subs: Array<() => void> = []
-  private sse: EventSource
+  private sse: EventSource | undefined
   private queue = new Queue()
+  private disconnected = false
 
-  constructor(path: string, private readonly components: Record<string, any>) {
-    this.sse = new EventSource(path)
+  constructor(
+    private readonly path: string,
+    private readonly components: Record<string, any>
+  ) {
+    this.open()
+  }
+
+  // Connect to the event stream. Does nothing if we're already connected.
+  open() {
+    if (this.sse) {
+      return
+    }
+    this.disconnected = false
+    this.sse = new EventSource(this.path)
     this.sse.addEventListener("message", this.onmessage)
+    this.sse.addEventListener("css", this.oncss)
+    this.sse.addEventListener("overlay", this.onoverlay)
+    this.sse.addEventListener("warning", this.onwarning)
+    this.sse.addEventListener("ready", this.onready)
+    this.sse.addEventListener("open", this.onopen)
+    this.sse.addEventListener("error", this.onerror)
   }
 
   listen(fn: () => void) {
     this.subs.push(fn)
   }
 
+  // The stream is proxied through the app, so it drops while the app restarts.
+  // Reload once it's back, since we may have missed updates in between.
+  private onopen = () => {
+    if (this.disconnected) {
+      location.reload()
+    }
+  }
+
+  private onerror = () => {
+    this.disconnected = true
+  }
+
   private onmessage = (e: MessageEvent) => {
     // TODO: define a protocol
-    const payload: { scripts: string[]; reload: boolean } = JSON.parse(e.data)
+    const payload: { scripts: string[]; modules?: string[]; reload: boolean } =
+      JSON.parse(e.data)
     if (payload.reload) {
       location.reload()
       return
     }
     this.queue.enqueue(() => {
-      this.loadScripts(payload.scripts).catch((err) => console.error(err))
+      this.update(payload.scripts, payload.modules || []).catch((err) => {
+        console.error(err)
+        // Errors from the server are more helpful, so don't replace them
+        if (!overlay.showing()) {
+          overlay.show(toOverlayError(err))
+        }
+      })
     })
   }
 
+  // Show build, compile and render errors on top of the page
+  private onoverlay = (e: MessageEvent) => {
+    overlay.show(JSON.parse(e.data))
+  }
+
+  // Show compiler warnings without getting in the way of errors
+  private onwarning = (e: MessageEvent) => {
+    const warning: overlay.OverlayError = JSON.parse(e.data)
+    console.warn(warning.message)
+    overlay.warn(warning)
+  }
+
+  // The app rebuilt successfully, so clear any errors
+  private onready = () => {
+    overlay.clear()
+  }
+
+  // Only styles changed, so swap the stylesheets without re-rendering
+  private oncss = (e: MessageEvent) => {
+    const payload: { stylesheets: string[] } = JSON.parse(e.data)
+    this.queue.enqueue(() => {
+      this.reloadStyles(payload.stylesheets)
+    })
+  }
+
+  // Swap the changed modules, falling back to re-rendering the page with the
+  // new scripts
+  private async update(scripts: string[], modules: string[]) {
+    if (modules.length > 0 && modules.every(hmr.accepts)) {
+      try {
+        await this.swapModules(modules)
+        return
+      } catch (err) {
+        console.warn("livebud: unable to hot swap, re-rendering instead", err)
+      }
+    }
+    await this.loadScripts(scripts)
+  }
+
+  // Re-import the changed modules and re-render, keeping the components' state
+  private async swapModules(modules: string[]) {
+    const ts = Date.now()
+    await Promise.all(modules.map((id) => import(`/bud/${id}?ts=${ts}`)))
+    const swapped = hmr.preserve(() => {
+      for (let sub of this.subs) {
+        sub()
+      }
+    })
+    if (!swapped) {
+      throw new Error("livebud: components don't support capturing state")
+    }
+    this.reloadStyles(["/bud/"])
+  }
+
   private async loadScripts(scripts: string[]) {
     for (let scriptPath of scripts) {
       const imported = await import(scriptPath)
@@ -39,12 +133,59 @@ export default class Hot {
         sub()
       }
     }
+    this.reloadStyles(["/bud/"])
   }
 
+  // Refetch the stylesheets matching the paths. Paths ending in a slash match
+  // every stylesheet within that directory. The new stylesheet is loaded
+  // before the old one is removed to avoid a flash of unstyled content.
+  private reloadStyles(paths: string[]) {
+    const links = document.querySelectorAll<HTMLLinkElement>(
+      'link[rel="stylesheet"]'
+    )
+    const ts = Date.now()
+    for (let link of Array.from(links)) {
+      const url = parse(link.href)
+      if (url.host && url.host !== location.host) {
+        continue
+      }
+      const pathname = url.pathname || ""
+      const matches = paths.some((path) =>
+        path.endsWith("/") ? pathname.startsWith(path) : pathname === path
+      )
+      if (!matches) {
+        continue
+      }
+      const next = link.cloneNode() as HTMLLinkElement
+      next.href = `${pathname}?ts=${ts}`
+      next.onload = next.onerror = () => link.remove()
+      link.after(next)
+    }
+  }
+
+  // Disconnect from the event stream. It can be opened again later.
   close() {
+    if (!this.sse) {
+      return
+    }
     this.sse.removeEventListener("message", this.onmessage)
+    this.sse.removeEventListener("css", this.oncss)
+    this.sse.removeEventListener("overlay", this.onoverlay)
+    this.sse.removeEventListener("warning", this.onwarning)
+    this.sse.removeEventListener("ready", this.onready)
+    this.sse.removeEventListener("open", this.onopen)
+    this.sse.removeEventListener("error", this.onerror)
     this.sse.close()
+    this.sse = undefined
+  }
+}
+
+// Runtime errors while updating the page
+function toOverlayError(err: any): overlay.OverlayError {
+  if (err instanceof Error) {
+    return { message: err.stack || err.message }
   }
+  return { message: String(err) }
 }
 
 /**
@@ -1,4 +1,5 @@
 import Hot from "./hot"
+import Navigator, { Page } from "./navigate"
 
 export type HydrateInput<Props = Record<string, any>> = {
   page: any
@@ -6,9 +7,22 @@ export type HydrateInput<Props = Record<string, any>> = {
   error?: any
   props: Props
   target: HTMLElement | null
+  // Hydrate the server-rendered markup. False when rendering over a view
+  // that was already mounted.
+  hydrate?: boolean
 }
 
-type Hydrate<Props = Record<string, any>> = (input: HydrateInput<Props>) => void
+// View that's been rendered into the target
+export type View = {
+  destroy(): void
+  // Swap the page for another one within the same frames, keeping the frames
+  // mounted. Returns false if the view can't swap the page.
+  swap?(input: HydrateInput): boolean
+}
+
+type Hydrate<Props = Record<string, any>> = (
+  input: HydrateInput<Props>
+) => View | void
 
 /**
  * Mount function
@@ -24,28 +38,172 @@ type MountInput = {
   hot?: Hot
 }
 
+// Pages that have been loaded, keyed by their path
+const pages: Record<string, MountInput> = {}
+
+// Page that's currently shown
+let current: { input: MountInput; props: Record<string, any> } | undefined
+let view: View | void
+// Hot reload stream of the page that's currently shown
+let hot: Hot | undefined
+
 export function mount(input: MountInput): void {
-  const props = getProps(document.getElementById("bud_props"))
-  input.createView({
-    page: input.components[input.page],
-    frames: input.frames.map((frame) => input.components[frame]),
-    error: input.error ? input.components[input.error] : undefined,
-    target: input.target,
-    props: props,
-  })
+  pages[input.page] = input
   if (input.hot) {
     input.hot.listen(() => {
-      input.createView({
+      // Only re-render the page if it's still being shown
+      if (current && current.input === input) {
+        show(input, current.props)
+      }
+    })
+  }
+  // Pages loaded during client-side navigation are shown by the navigator
+  if (current) {
+    return
+  }
+  connect(input)
+  show(input, getProps(document.getElementById("bud_props")))
+  new Navigator(async (page: Page) => {
+    await loadStyles(page.styles || [])
+    await import(page.client)
+    const next = pages[page.path]
+    if (!next) {
+      throw new Error(`livebud: unable to find page "${page.path}"`)
+    }
+    connect(next)
+    navigate(next, page.props)
+  })
+}
+
+// Connect to the page's hot reload stream, closing the previous page's stream.
+// Each page bundle brings its own stream, but browsers only allow a few open
+// connections per origin, so we only keep one open at a time.
+function connect(input: MountInput) {
+  if (hot && hot !== input.hot) {
+    hot.close()
+  }
+  hot = input.hot
+  if (hot) {
+    hot.open()
+  }
+}
+
+// Navigate to the page. If it's within the same frames as the current page,
+// only the page is swapped, so the frames keep their state.
+function navigate(input: MountInput, props: Record<string, any>) {
+  if (
+    view &&
+    view.swap &&
+    current &&
+    current.input.createView === input.createView
+  ) {
+    const frames = input.frames.map((frame) => input.components[frame])
+    const error = input.error ? input.components[input.error] : undefined
+    try {
+      const swapped = view.swap({
         page: input.components[input.page],
-        frames: input.frames.map((frame) => input.components[frame]),
-        error: input.error ? input.components[input.error] : undefined,
+        frames: frames,
+        error: error,
         target: input.target,
         props: props,
       })
+      if (swapped) {
+        current = { input, props }
+        return
+      }
+    } catch (err) {
+      // Show the page from scratch, falling back to the error view if it
+      // fails again
+    }
+  }
+  show(input, props)
+}
+
+// Show the page, replacing the page that's currently shown
+function show(input: MountInput, props: Record<string, any>) {
+  // Only the first page is rendered on the server
+  const hydrate = !current
+  if (view) {
+    view.destroy()
+  }
+  current = { input, props }
+  const frames = input.frames.map((frame) => input.components[frame])
+  const error = input.error ? input.components[input.error] : undefined
+  try {
+    view = input.createView({
+      page: input.components[input.page],
+      frames: frames,
+      error: error,
+      target: input.target,
+      props: props,
+      hydrate: hydrate,
     })
+  } catch (err) {
+    if (!error) {
+      throw err
+    }
+    // Fallback to the nearest error view
+    console.error(err)
+    view = showError(input, error, frames, { ...props, error: toError(err) })
   }
 }
 
+// Show the error view within the frames. If the frames are what's failing, show
+// the error view on its own.
+function showError(
+  input: MountInput,
+  error: any,
+  frames: any[],
+  props: Record<string, any>
+) {
+  try {
+    return input.createView({
+      page: error,
+      frames: frames,
+      target: input.target,
+      props: props,
+    })
+  } catch (err) {
+    if (frames.length === 0) {
+      throw err
+    }
+    return input.createView({
+      page: error,
+      frames: [],
+      target: input.target,
+      props: props,
+    })
+  }
+}
+
+// Errors are passed into the error view as props
+function toError(err: any) {
+  return {
+    message: err && err.message ? err.message : String(err),
+    stack: err && err.stack ? err.stack : "",
+  }
+}
+
+// Load the stylesheets that haven't been loaded yet
+async function loadStyles(styles: string[]) {
+  const linked = new Set<string | null>()
+  const links = document.querySelectorAll('link[rel="stylesheet"]')
+  links.forEach((link) => linked.add(link.getAttribute("href")))
+  const loading = styles
+    .filter((href) => !linked.has(href))
+    .map((href) => {
+      return new Promise((resolve) => {
+        const link = document.createElement("link")
+        link.rel = "stylesheet"
+        link.href = href
+        link.onload = resolve
+        link.onerror = resolve
+        document.head.appendChild(link)
+      })
+    })
+  await Promise.all(loading)
+}
+
 function getProps(node: HTMLElement | null) {
   if (!node || !node.textContent) {
     return {}
@@ -0,0 +1,42 @@
+import Hot from "../hot"
+
+type HydrateInput = {
+  // Islands keyed by their path (e.g. /bud/view/Counter.island.svelte)
+  components: Record<string, any>
+  hot?: Hot
+}
+
+// Hydrate the islands within a page in islands mode. The server renders each
+// island within a <bud-island> marker along with the props it was rendered
+// with. The rest of the page stays as it was rendered on the server.
+export function hydrate(input: HydrateInput): void {
+  const islands = document.querySelectorAll("bud-island")
+  islands.forEach((island) => {
+    const path = island.getAttribute("data-component") || ""
+    const Component = input.components[path]
+    if (!Component) {
+      console.warn(`livebud: unable to find island "${path}"`)
+      return
+    }
+    new Component({
+      target: island,
+      props: getProps(island.getAttribute("data-props")),
+      hydrate: true,
+    })
+  })
+  // The page is mostly static, so reload the whole page when it changes
+  if (input.hot) {
+    input.hot.listen(() => location.reload())
+  }
+}
+
+function getProps(data: string | null) {
+  if (!data) {
+    return {}
+  }
+  try {
+    return JSON.parse(data)
+  } catch (err) {
+    return {}
+  }
+}
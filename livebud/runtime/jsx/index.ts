@@ -1,11 +1,24 @@
-import { HydrateInput } from ".."
+import { HydrateInput, View } from ".."
 import ReactDOM from "react-dom"
 import React from "react"
 
-export default function createView(input: HydrateInput) {
+export default function createView(input: HydrateInput): View {
+  // Frames are ordered from the outermost to the innermost frame
   let component = React.createElement(input.page, input.props)
-  for (let frame of input.frames) {
-    component = React.createElement(frame, input.props, component)
+  for (let i = input.frames.length - 1; i >= 0; i--) {
+    component = React.createElement(input.frames[i], input.props, component)
+  }
+  const target = input.target
+  if (input.hydrate) {
+    ReactDOM.hydrate(component, target)
+  } else {
+    ReactDOM.render(component, target)
+  }
+  return {
+    destroy() {
+      if (target) {
+        ReactDOM.unmountComponentAtNode(target)
+      }
+    },
   }
-  ReactDOM.hydrate(component, input.target)
 }
@@ -0,0 +1,190 @@
+/**
+ * Client-side navigation
+ */
+
+export type Page = {
+  path: string
+  client: string
+  styles?: string[]
+  props: Record<string, any>
+}
+
+type Render = (page: Page) => Promise<void>
+
+type Scroll = {
+  x: number
+  y: number
+}
+
+type Loaded = {
+  url: string
+  page: Page
+}
+
+// Media type that views respond to with the page's data instead of HTML
+const pageType = "application/vnd.bud.page+json"
+
+export default class Navigator {
+  private prefetched: Record<string, Promise<Loaded | undefined>> = {}
+  private scrolls: Record<string, Scroll> = {}
+  private key: string
+  private visits = 0
+
+  constructor(private readonly render: Render) {
+    // Track each history entry with a key, so we can restore its scroll
+    // position when going back and forward
+    this.key = (history.state && history.state.key) || createKey()
+    history.replaceState({ ...history.state, key: this.key }, "")
+    if ("scrollRestoration" in history) {
+      history.scrollRestoration = "manual"
+    }
+    document.addEventListener("click", this.onclick)
+    document.addEventListener("mouseover", this.onhover)
+    document.addEventListener("touchstart", this.onhover, { passive: true })
+    window.addEventListener("popstate", this.onpopstate)
+  }
+
+  // Navigate to the URL, adding it to the history
+  async navigate(href: string) {
+    this.scrolls[this.key] = { x: window.scrollX, y: window.scrollY }
+    await this.visit(href, true)
+  }
+
+  // Prefetch the page, so navigating to it is instant
+  prefetch(href: string) {
+    const url = stripHash(href)
+    if (!this.prefetched[url]) {
+      this.prefetched[url] = load(url)
+    }
+  }
+
+  private onclick = (e: MouseEvent) => {
+    if (
+      e.defaultPrevented ||
+      e.button !== 0 ||
+      e.metaKey ||
+      e.ctrlKey ||
+      e.shiftKey ||
+      e.altKey
+    ) {
+      return
+    }
+    const anchor = findAnchor(e.target)
+    if (!anchor || !isNavigable(anchor)) {
+      return
+    }
+    e.preventDefault()
+    this.navigate(anchor.href).catch((err) => console.error(err))
+  }
+
+  private onhover = (e: Event) => {
+    const anchor = findAnchor(e.target)
+    if (!anchor || !isNavigable(anchor)) {
+      return
+    }
+    this.prefetch(anchor.href)
+  }
+
+  private onpopstate = (e: PopStateEvent) => {
+    this.scrolls[this.key] = { x: window.scrollX, y: window.scrollY }
+    this.key = (e.state && e.state.key) || createKey()
+    this.visit(location.href, false).catch((err) => console.error(err))
+  }
+
+  private async visit(href: string, push: boolean) {
+    const visit = ++this.visits
+    const url = stripHash(href)
+    const loaded = await (this.prefetched[url] || load(url))
+    // Prefetched pages are only used once to keep the props fresh
+    delete this.prefetched[url]
+    // Another visit started while this page was loading
+    if (visit !== this.visits) {
+      return
+    }
+    // Fallback to a full page load
+    if (!loaded) {
+      push ? location.assign(href) : location.reload()
+      return
+    }
+    if (push) {
+      this.key = createKey()
+      const hash = new URL(href, location.href).hash
+      history.pushState({ key: this.key }, "", loaded.url + hash)
+    }
+    await this.render(loaded.page)
+    restoreScroll(this.scrolls[this.key], location.hash)
+  }
+}
+
+// Load the page's data
+async function load(url: string): Promise<Loaded | undefined> {
+  try {
+    const res = await fetch(url, {
+      headers: { Accept: pageType },
+      credentials: "same-origin",
+    })
+    const contentType = res.headers.get("Content-Type") || ""
+    if (!res.ok || !contentType.startsWith(pageType)) {
+      return undefined
+    }
+    return { url: res.url || url, page: await res.json() }
+  } catch (err) {
+    return undefined
+  }
+}
+
+function findAnchor(target: EventTarget | null): HTMLAnchorElement | null {
+  if (!(target instanceof Element)) {
+    return null
+  }
+  const anchor = target.closest("a[href]")
+  return anchor instanceof HTMLAnchorElement ? anchor : null
+}
+
+// Only navigate to pages within the app
+function isNavigable(anchor: HTMLAnchorElement): boolean {
+  if (
+    (anchor.target && anchor.target !== "_self") ||
+    anchor.hasAttribute("download") ||
+    anchor.hasAttribute("data-bud-reload") ||
+    (anchor.getAttribute("rel") || "").split(/\s+/).includes("external")
+  ) {
+    return false
+  }
+  const url = new URL(anchor.href, location.href)
+  if (url.origin !== location.origin || url.pathname.startsWith("/bud/")) {
+    return false
+  }
+  // Let the browser jump to anchors on the same page
+  if (
+    url.hash &&
+    url.pathname === location.pathname &&
+    url.search === location.search
+  ) {
+    return false
+  }
+  return true
+}
+
+function restoreScroll(scroll: Scroll | undefined, hash: string) {
+  if (scroll) {
+    window.scrollTo(scroll.x, scroll.y)
+    return
+  }
+  const target = hash && document.getElementById(decodeURIComponent(hash.slice(1)))
+  if (target) {
+    target.scrollIntoView()
+    return
+  }
+  window.scrollTo(0, 0)
+}
+
+function stripHash(href: string): string {
+  const url = new URL(href, location.href)
+  url.hash = ""
+  return url.href
+}
+
+function createKey(): string {
+  return Math.random().toString(36).slice(2)
+}
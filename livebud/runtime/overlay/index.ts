@@ -0,0 +1,172 @@
+/**
+ * Error overlay
+ *
+ * Shows build, compile and runtime errors on top of the page during
+ * development. Compiler warnings are shown the same way, unless there's an
+ * error showing. The overlay can be dismissed and is cleared once the app is
+ * ready again.
+ */
+
+export type OverlayError = {
+  title?: string
+  message: string
+  file?: string
+  line?: number
+  column?: number
+  frame?: string
+}
+
+const id = "bud_overlay"
+
+const styles = `
+:host {
+  all: initial;
+  position: fixed;
+  inset: 0;
+  z-index: 2147483647;
+  display: flex;
+  align-items: flex-start;
+  justify-content: center;
+  background: rgba(0, 0, 0, 0.66);
+  overflow-y: auto;
+  font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace;
+}
+.window {
+  position: relative;
+  margin: 60px 20px;
+  padding: 24px 28px;
+  max-width: 960px;
+  width: 100%;
+  box-sizing: border-box;
+  background: #181818;
+  color: #e8e8e8;
+  border-top: 6px solid #ff5555;
+  border-radius: 6px;
+  box-shadow: 0 16px 40px rgba(0, 0, 0, 0.4);
+  font-size: 14px;
+  line-height: 1.5;
+}
+.title {
+  font-size: 18px;
+  font-weight: bold;
+  margin-bottom: 8px;
+}
+.warning {
+  border-top-color: #e3b341;
+}
+.warning .title {
+  color: #e3b341;
+}
+.file {
+  color: #8ab4f8;
+  margin-bottom: 8px;
+}
+.message {
+  color: #ff7b72;
+  white-space: pre-wrap;
+  margin: 0 0 16px;
+}
+.frame {
+  background: #0f0f0f;
+  padding: 12px 16px;
+  border-radius: 4px;
+  overflow-x: auto;
+  white-space: pre;
+  margin: 0;
+}
+.close {
+  position: absolute;
+  top: 12px;
+  right: 16px;
+  border: 0;
+  background: none;
+  color: #999;
+  font-size: 20px;
+  cursor: pointer;
+}
+.tip {
+  color: #999;
+  font-size: 12px;
+  margin-top: 16px;
+}
+`
+
+// Show the error, replacing any error that's already showing
+export function show(err: OverlayError) {
+  render(err, "error")
+}
+
+// Show the warning, unless an error is showing. Warnings don't block the page,
+// so they can be dismissed like errors.
+export function warn(warning: OverlayError) {
+  if (showing()) {
+    return
+  }
+  render(warning, "warning")
+}
+
+function render(err: OverlayError, level: "error" | "warning") {
+  clear()
+  const host = document.createElement("div")
+  host.id = id
+  host.setAttribute("data-level", level)
+  const root = host.attachShadow({ mode: "open" })
+  const style = document.createElement("style")
+  style.textContent = styles
+  root.appendChild(style)
+  const win = element("div", level === "error" ? "window" : "window warning")
+  const close = element("button", "close", "×")
+  close.setAttribute("aria-label", "Dismiss")
+  close.addEventListener("click", clear)
+  win.appendChild(close)
+  if (err.title) {
+    win.appendChild(element("div", "title", err.title))
+  }
+  if (err.file) {
+    const location = err.line ? `:${err.line}:${err.column || 1}` : ""
+    win.appendChild(element("div", "file", err.file + location))
+  }
+  win.appendChild(element("pre", "message", err.message))
+  if (err.frame) {
+    win.appendChild(element("pre", "frame", err.frame))
+  }
+  const tip =
+    level === "error"
+      ? "Fix the error and save to reload."
+      : "Warnings don't stop the page from loading."
+  win.appendChild(
+    element("div", "tip", tip + " Click outside or press Esc to dismiss.")
+  )
+  // Clicking outside of the window dismisses the overlay
+  host.addEventListener("click", (e) => {
+    if (e.target === host) clear()
+  })
+  document.addEventListener("keydown", onkeydown)
+  document.body.appendChild(host)
+}
+
+// Returns true if an error is showing
+export function showing(): boolean {
+  const host = document.getElementById(id)
+  return !!host && host.getAttribute("data-level") === "error"
+}
+
+// Clear the error if it's showing
+export function clear() {
+  const host = document.getElementById(id)
+  if (host) {
+    host.remove()
+  }
+  document.removeEventListener("keydown", onkeydown)
+}
+
+function onkeydown(e: KeyboardEvent) {
+  if (e.key === "Escape") clear()
+}
+
+function element(tag: string, className: string, text?: string) {
+  const el = document.createElement(tag)
+  el.className = className
+  if (text) el.textContent = text
+  return el
+}
@@ -1,20 +1,146 @@
-import { HydrateInput } from ".."
+import { HydrateInput, View } from ".."
 
-// TODO:
-// - Support frames
-// - Handle errors
-export default function createView(input: HydrateInput) {
-  if (input.target != null) {
+export default function createView(input: HydrateInput): View {
+  const hydrate = !!input.hydrate
+  if (input.target != null && !hydrate) {
     // TODO: for some reason Svelte isn't able to re-hydrate over itself during
     // a live reload. I wonder if they've figured this out in SvelteKit, but you
     // end up with a runtime error: "Cannot read properties of null (reading
     // 'removeChild')". Encountering this error will depend on your Svelte code.
-    // For now, we'll clear the DOM in our target before hydrating.
+    // For now, we'll clear the DOM in our target when rendering over a view
+    // that was already mounted.
     input.target.innerHTML = ""
   }
-  new input.page({
+  // Without frames, there's nothing to keep mounted between pages
+  if (input.frames.length === 0) {
+    const component = new input.page({
+      target: input.target,
+      props: input.props,
+      hydrate: hydrate,
+    })
+    return {
+      destroy() {
+        component.$destroy()
+      },
+    }
+  }
+  // Frames are ordered from the outermost to the innermost frame, so we nest
+  // the page into the innermost frame's default slot first
+  const page = new Slot(input.page, input.props)
+  const slots: Slot[] = []
+  let inner = page
+  for (let i = input.frames.length - 1; i > 0; i--) {
+    inner = new Slot(input.frames[i], frameProps(input.props, inner))
+    slots.unshift(inner)
+  }
+  const root = new input.frames[0]({
     target: input.target,
-    props: input.props,
-    hydrate: true,
+    props: frameProps(input.props, inner),
+    hydrate: hydrate,
   })
+  let frames = input.frames
+  return {
+    destroy() {
+      root.$destroy()
+    },
+    swap(next: HydrateInput) {
+      if (
+        next.target !== input.target ||
+        !sameComponents(next.frames, frames)
+      ) {
+        return false
+      }
+      page.swap(next.page, next.props)
+      // Keep the frames' props in sync with the page
+      root.$set(next.props)
+      for (let slot of slots) {
+        slot.instance.$set(next.props)
+      }
+      frames = next.frames
+      return true
+    },
+  }
+}
+
+// frameProps renders the inner slot into the frame's default slot
+function frameProps(props: Record<string, any>, inner: Slot) {
+  return {
+    ...props,
+    $$slots: { default: [inner.fragment()] },
+    $$scope: { ctx: [] },
+  }
+}
+
+function sameComponents(a: any[], b: any[]): boolean {
+  return a.length === b.length && a.every((component, i) => component === b[i])
+}
+
+// Slot renders a component into its parent's default slot. This is how Svelte
+// renders <Frame><Page /></Frame> when the components aren't known until
+// runtime. The component can be swapped while its parent stays mounted.
+class Slot {
+  instance: any
+  private target: Node | undefined
+  // Empty text node that marks where the component goes within the parent
+  private marker: Node | undefined
+
+  constructor(private component: any, private props: Record<string, any>) {}
+
+  // fragment returns the slot fragment that the parent mounts
+  fragment() {
+    const slot = this
+    return function () {
+      return {
+        c() {},
+        l() {},
+        m(target: Node, anchor?: Node) {
+          slot.mount(target, anchor)
+        },
+        p() {},
+        d() {
+          slot.destroy()
+        },
+      }
+    }
+  }
+
+  // swap the component for another one in the same place
+  swap(component: any, props: Record<string, any>) {
+    if (this.instance) {
+      this.instance.$destroy()
+      this.instance = undefined
+    }
+    this.component = component
+    this.props = props
+    if (this.target) {
+      this.instance = new component({
+        target: this.target,
+        anchor: this.marker,
+        props: props,
+      })
+    }
+  }
+
+  private mount(target: Node, anchor?: Node) {
+    this.target = target
+    this.marker = document.createTextNode("")
+    target.insertBefore(this.marker, anchor || null)
+    this.instance = new this.component({
+      target,
+      anchor: this.marker,
+      props: this.props,
+    })
+  }
+
+  private destroy() {
+    if (this.instance) {
+      this.instance.$destroy()
+      this.instance = undefined
+    }
+    if (this.marker && this.marker.parentNode) {
+      this.marker.parentNode.removeChild(this.marker)
+    }
+    this.target = undefined
+    this.marker = undefined
+  }
 }
@@ -0,0 +1,218 @@
+/**
+ * Imports
+ */
+
+import assert from "internal/assert"
+import createView from "."
+
+describe("svelte/createView", () => {
+  before(() => {
+    ;(global as any).document = { createTextNode: () => new FakeNode() }
+  })
+
+  after(() => {
+    delete (global as any).document
+  })
+
+  it("keeps the frames mounted when navigating", () => {
+    const Frame = component("Frame")
+    const Users = component("Users")
+    const Posts = component("Posts")
+    const target = new FakeNode() as any
+    const view = createView({
+      page: Users,
+      frames: [Frame],
+      props: { title: "users" },
+      target,
+    })
+    const frame = mounted(Frame)[0]
+    frame.count = 3
+    assert.deepEqual(names(target), ["Frame", "Users", ""])
+    // Navigate to another page within the same frame
+    const swapped = view.swap!({
+      page: Posts,
+      frames: [Frame],
+      props: { title: "posts" },
+      target,
+    })
+    assert.equal(swapped, true)
+    assert.deepEqual(names(target), ["Frame", "Posts", ""])
+    assert.equal(mounted(Frame).length, 1)
+    assert.equal(mounted(Frame)[0], frame)
+    assert.equal(frame.count, 3)
+    assert.equal(frame.props.title, "posts")
+    assert.equal(mounted(Users).length, 0)
+    view.destroy()
+    assert.deepEqual(names(target), [])
+  })
+
+  it("keeps the outer frames mounted when navigating", () => {
+    const Layout = component("Layout")
+    const Frame = component("Frame")
+    const Users = component("Users")
+    const Posts = component("Posts")
+    const target = new FakeNode() as any
+    const view = createView({
+      page: Users,
+      frames: [Layout, Frame],
+      props: {},
+      target,
+    })
+    const layout = mounted(Layout)[0]
+    const frame = mounted(Frame)[0]
+    assert.deepEqual(names(target), ["Layout", "Frame", "Users", "", ""])
+    const swapped = view.swap!({
+      page: Posts,
+      frames: [Layout, Frame],
+      props: {},
+      target,
+    })
+    assert.equal(swapped, true)
+    assert.deepEqual(names(target), ["Layout", "Frame", "Posts", "", ""])
+    assert.equal(mounted(Layout)[0], layout)
+    assert.equal(mounted(Frame)[0], frame)
+  })
+
+  it("hydrates over the server-rendered markup", () => {
+    const Frame = component("Frame")
+    const Users = component("Users")
+    const target = new FakeNode() as any
+    target.innerHTML = "<main><h1>users</h1></main>"
+    createView({
+      page: Users,
+      frames: [Frame],
+      props: {},
+      target,
+      hydrate: true,
+    })
+    assert.equal(target.innerHTML, "<main><h1>users</h1></main>")
+    assert.equal(mounted(Frame)[0].hydrate, true)
+  })
+
+  it("clears the target when rendering over a mounted view", () => {
+    const Users = component("Users")
+    const target = new FakeNode() as any
+    target.innerHTML = "<h1>users</h1>"
+    createView({
+      page: Users,
+      frames: [],
+      props: {},
+      target,
+    })
+    assert.equal(target.innerHTML, "")
+    assert.equal(mounted(Users)[0].hydrate, false)
+  })
+
+  it("doesn't swap pages within different frames", () => {
+    const Frame = component("Frame")
+    const Other = component("Other")
+    const Users = component("Users")
+    const target = new FakeNode() as any
+    const view = createView({
+      page: Users,
+      frames: [Frame],
+      props: {},
+      target,
+    })
+    const swapped = view.swap!({
+      page: Users,
+      frames: [Other],
+      props: {},
+      target,
+    })
+    assert.equal(swapped, false)
+    assert.equal(mounted(Frame).length, 1)
+  })
+})
+
+/**
+ * Fake DOM node that keeps track of its children
+ */
+
+class FakeNode {
+  name = ""
+  parentNode: FakeNode | null = null
+  childNodes: FakeNode[] = []
+  innerHTML = ""
+
+  insertBefore(node: FakeNode, anchor: FakeNode | null) {
+    node.parentNode = this
+    const i = anchor ? this.childNodes.indexOf(anchor) : -1
+    if (i < 0) {
+      this.childNodes.push(node)
+    } else {
+      this.childNodes.splice(i, 0, node)
+    }
+  }
+
+  removeChild(node: FakeNode) {
+    node.parentNode = null
+    this.childNodes = this.childNodes.filter((child) => child !== node)
+  }
+}
+
+// names of the nodes in the tree, depth first
+function names(node: FakeNode): string[] {
+  return node.childNodes.reduce(
+    (list: string[], child) => list.concat(child.name, names(child)),
+    []
+  )
+}
+
+/**
+ * Fake Svelte component that renders its default slot
+ */
+
+type Options = {
+  target: FakeNode
+  anchor?: FakeNode
+  props: Record<string, any>
+  hydrate?: boolean
+}
+
+const instances = new Map<any, any[]>()
+
+function mounted(Component: any): any[] {
+  return instances.get(Component) || []
+}
+
+function component(name: string) {
+  class Component {
+    count = 0
+    props: Record<string, any>
+    hydrate: boolean
+    private node = new FakeNode()
+    private slot: any
+
+    constructor(options: Options) {
+      this.props = options.props
+      this.hydrate = !!options.hydrate
+      this.node.name = name
+      options.target.insertBefore(this.node, options.anchor || null)
+      const slots = options.props.$$slots
+      if (slots && slots.default) {
+        this.slot = slots.default[0]()
+        this.slot.m(this.node, null)
+      }
+      instances.set(Component, mounted(Component).concat(this))
+    }
+
+    $set(props: Record<string, any>) {
+      this.props = { ...this.props, ...props }
+    }
+
+    $destroy() {
+      if (this.slot) {
+        this.slot.d(true)
+      }
+      if (this.node.parentNode) {
+        this.node.parentNode.removeChild(this.node)
+      }
+      instances.set(
+        Component,
+        mounted(Component).filter((instance) => instance !== this)
+      )
+    }
+  }
+  return Component
+}
@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"net/http"
 
+	"github.com/livebud/bud/framework/view/ssr"
 	"github.com/livebud/bud/package/virtual"
 
 	"github.com/livebud/bud/package/budhttp"
@@ -20,7 +21,9 @@ import (
 	"github.com/livebud/bud/package/router"
 )
 
-func New(fsys fs.FS, bus pubsub.Client, log log.Interface, vm js.VM) *Server {
+// New bud server. The graph is used to hot swap the modules affected by a
+// change and can be nil.
+func New(fsys fs.FS, bus pubsub.Client, log log.Interface, vm js.VM, graph hot.Graph) *Server {
 	router := router.New()
 	server := &Server{
 		Handler: router,
@@ -34,7 +37,9 @@ func New(fsys fs.FS, bus pubsub.Client, log log.Interface, vm js.VM) *Server {
 	router.Post("/bud/view/:route*", http.HandlerFunc(server.render))
 	router.Get("/open/:path*", http.HandlerFunc(server.open))
 	// Routes that are directly requested by the browser to
-	router.Get("/bud/hot/:page*", hot.New(log, bus))
+	hotServer := hot.New(log, bus)
+	hotServer.Graph = graph
+	router.Get("/bud/hot/:page*", hotServer)
 	// Private routes between the app and bud
 	router.Post("/bud/events", http.HandlerFunc(server.publish))
 	return server
@@ -70,6 +75,7 @@ func (s *Server) render(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		s.publishError(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -77,7 +83,16 @@ func (s *Server) render(w http.ResponseWriter, r *http.Request) {
 	expr := fmt.Sprintf(`%s; bud.render(%q, %s)`, script, route, body)
 	result, err := s.vm.Eval("_ssr.js", expr)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		// Point the error at the views rather than at the bundle
+		err = ssr.Remap(script, err)
+		herr := hot.NewError(err)
+		herr.LoadFrame(s.fsys)
+		s.publishError(herr)
+		message := err.Error()
+		if herr.Frame != "" {
+			message += "\n\n" + herr.Frame
+		}
+		http.Error(w, message, http.StatusInternalServerError)
 		return
 	}
 	w.Write([]byte(result))
@@ -92,6 +107,7 @@ func (s *Server) open(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), 404)
 			return
 		}
+		s.publishError(err)
 		http.Error(w, err.Error(), 500)
 		return
 	}
@@ -105,6 +121,16 @@ func (s *Server) open(w http.ResponseWriter, r *http.Request) {
 	s.log.Debug("devserver: opened", "file", path)
 }
 
+// publishError sends build and render errors to the browser's error overlay
+func (s *Server) publishError(err error) {
+	data, err := json.Marshal(hot.NewError(err))
+	if err != nil {
+		s.log.Error("devserver: unable to marshal error", "err", err)
+		return
+	}
+	s.bus.Publish("frontend:error", data)
+}
+
 func (s *Server) publish(w http.ResponseWriter, r *http.Request) {
 	// Read the body
 	body, err := io.ReadAll(r.Body)
@@ -7,6 +7,8 @@ import (
 	"io"
 	"io/fs"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"strings"
 
 	"github.com/livebud/bud/framework/view/ssr"
@@ -20,6 +22,7 @@ type Client interface {
 	Render(route string, props interface{}) (*ssr.Response, error)
 	Publish(topic string, data []byte) error
 	Open(name string) (fs.File, error)
+	Proxy(w http.ResponseWriter, r *http.Request)
 }
 
 // Try tries loading a dev client from an environment variable or returns an
@@ -50,13 +53,33 @@ func Load(log log.Interface, addr string) (Client, error) {
 	return &client{
 		baseURL:    url.String(),
 		httpClient: httpClient,
+		proxy:      reverseProxy(url, transport),
 		log:        log,
 	}, nil
 }
 
+// reverseProxy to the bud server. Responses are flushed immediately, so event
+// streams pass through as they're written.
+func reverseProxy(target *url.URL, transport http.RoundTripper) *httputil.ReverseProxy {
+	host := target.Host
+	// Unix domain sockets don't have a host, but requests still need one
+	if host == "" {
+		host = "bud"
+	}
+	return &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+			r.URL.Host = host
+		},
+		Transport:     transport,
+		FlushInterval: -1,
+	}
+}
+
 type client struct {
 	baseURL    string
 	httpClient *http.Client
+	proxy      *httputil.ReverseProxy
 	log        log.Interface
 }
 
@@ -111,6 +134,25 @@ func (c *client) Open(name string) (fs.File, error) {
 	return virtual.UnmarshalJSON(body)
 }
 
+// Proxy the request through to the bud server
+func (c *client) Proxy(w http.ResponseWriter, r *http.Request) {
+	c.log.Debug("budhttp: client proxying", "path", r.URL.Path)
+	c.proxy.ServeHTTP(w, r)
+}
+
+// Hot proxies the hot reload event streams to the bud server, so the browser
+// only ever needs to reach the app's origin. This keeps hot reloading working
+// inside containers, over forwarded ports and behind reverse proxies.
+func Hot(client Client, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bud/hot" && !strings.HasPrefix(r.URL.Path, "/bud/hot/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client.Proxy(w, r)
+	})
+}
+
 type Event struct {
 	Topic string `json:"topic,omitempty"`
 	Data  []byte `json:"data,omitempty"`
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"io/fs"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
@@ -23,6 +24,7 @@ import (
 	"github.com/livebud/bud/package/budhttp"
 	"github.com/livebud/bud/package/budhttp/budsvr"
 	"github.com/livebud/bud/package/gomod"
+	"github.com/livebud/bud/package/hot"
 	v8 "github.com/livebud/bud/package/js/v8"
 	"github.com/livebud/bud/package/svelte"
 )
@@ -49,7 +51,7 @@ func loadServer(bus pubsub.Client, dir string) (*httptest.Server, error) {
 	bfs.FileServer("bud/view", dom.New(module, transforms.DOM))
 	bfs.FileServer("bud/node_modules", dom.NodeModules(module))
 	bfs.FileGenerator("bud/view/_ssr.js", ssr.New(module, transforms.SSR))
-	handler := budsvr.New(bfs, bus, log, vm)
+	handler := budsvr.New(bfs, bus, log, vm, nil)
 	return httptest.NewServer(handler), nil
 }
 
@@ -240,3 +242,34 @@ func TestEvents(t *testing.T) {
 		t.Fatalf("missing event")
 	}
 }
+
+func TestHotProxy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	is := is.New(t)
+	log := testlog.New()
+	dir := t.TempDir()
+	td := testdir.New(dir)
+	is.NoErr(td.Write(ctx))
+	ps := pubsub.New()
+	server, err := loadServer(ps, dir)
+	is.NoErr(err)
+	defer server.Close()
+	client, err := budhttp.Load(log, server.URL)
+	is.NoErr(err)
+	// The app proxies the hot reload stream through to bud
+	app := httptest.NewServer(budhttp.Hot(client, http.NotFoundHandler()))
+	defer app.Close()
+	stream, err := hot.Dial(log, app.URL+"/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	defer stream.Close()
+	ps.Publish("backend:update", nil)
+	event, err := stream.Next(ctx)
+	is.NoErr(err)
+	is.Equal(string(event.Data), `{"reload":true}`)
+	// Other requests are passed through to the app
+	res, err := app.Client().Get(app.URL + "/bud/hotdog")
+	is.NoErr(err)
+	defer res.Body.Close()
+	is.Equal(res.StatusCode, http.StatusNotFound)
+}
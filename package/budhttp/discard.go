@@ -3,6 +3,7 @@ package budhttp
 import (
 	"fmt"
 	"io/fs"
+	"net/http"
 
 	"github.com/livebud/bud/framework/view/ssr"
 )
@@ -25,3 +26,8 @@ func (discard) Open(name string) (fs.File, error) {
 func (discard) Publish(topic string, data []byte) error {
 	return nil
 }
+
+// Proxy responds with a 404, since there's no bud server to proxy to
+func (discard) Proxy(w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
@@ -0,0 +1,138 @@
+package hot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Error is sent to the browser as an "overlay" event and shown on top of the
+// page until the next successful rebuild.
+type Error struct {
+	// Title describes what failed. It's empty for build errors.
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`   // 1-based
+	Column  int    `json:"column,omitempty"` // 1-based
+	Frame   string `json:"frame,omitempty"`
+}
+
+var _ error = (*Error)(nil)
+
+func (e *Error) Error() string {
+	if e.File == "" || e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// Located errors know where they happened within a file
+type Located interface {
+	error
+	// Location returns the file along with the 1-based line and column
+	Location() (file string, line, column int)
+}
+
+// Framed errors include a snippet of the code around where they happened
+type Framed interface {
+	error
+	CodeFrame() string
+}
+
+// Matches the errors from the Go compiler (e.g. view/view.go:5:2: undefined: x)
+var reLocation = regexp.MustCompile(`(?m)^(?:\./)?([^\s:]+):(\d+):(\d+): (.+)$`)
+
+// Matches ANSI color codes
+var reANSI = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// NewError converts err into an error for the overlay. The innermost error
+// that knows its location is used, falling back to parsing the location out of
+// the message.
+func NewError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var herr *Error
+	if errors.As(err, &herr) {
+		return herr
+	}
+	out := &Error{Message: stripANSI(err.Error())}
+	var located Located
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if l, ok := e.(Located); ok {
+			if _, line, _ := l.Location(); line > 0 {
+				located = l
+			}
+		}
+	}
+	if located == nil {
+		if match := reLocation.FindStringSubmatch(out.Message); match != nil {
+			out.File = match[1]
+			out.Line, _ = strconv.Atoi(match[2])
+			out.Column, _ = strconv.Atoi(match[3])
+		}
+		return out
+	}
+	out.Message = stripANSI(located.Error())
+	out.File, out.Line, out.Column = located.Location()
+	if framed, ok := located.(Framed); ok {
+		out.Frame = framed.CodeFrame()
+		// The overlay shows the frame separately
+		out.Message = strings.TrimSpace(strings.TrimSuffix(out.Message, out.Frame))
+	}
+	return out
+}
+
+// LoadFrame reads the code around the error from fsys if the error doesn't
+// have a frame yet.
+func (e *Error) LoadFrame(fsys fs.FS) {
+	if e.Frame != "" || e.File == "" || e.Line == 0 {
+		return
+	}
+	code, err := fs.ReadFile(fsys, path.Clean(e.File))
+	if err != nil {
+		return
+	}
+	e.Frame = codeFrame(code, e.Line, e.Column)
+}
+
+// codeFrame returns the lines surrounding line with a marker under the column
+func codeFrame(code []byte, line, column int) string {
+	lines := bytes.Split(bytes.TrimRight(code, "\n"), []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	start, end := line-2, line+2
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	width := len(strconv.Itoa(end))
+	frame := new(strings.Builder)
+	for i := start; i <= end; i++ {
+		text := strings.ReplaceAll(string(lines[i-1]), "\t", "  ")
+		fmt.Fprintf(frame, "%*d: %s\n", width, i, text)
+		if i == line && column > 0 {
+			// Account for the expanded tabs before the column
+			prefix := string(lines[i-1])
+			if column-1 < len(prefix) {
+				prefix = prefix[:column-1]
+			}
+			offset := len(prefix) + strings.Count(prefix, "\t")
+			frame.WriteString(strings.Repeat(" ", width+2+offset) + "^\n")
+		}
+	}
+	return strings.TrimSuffix(frame.String(), "\n")
+}
+
+func stripANSI(s string) string {
+	return reANSI.ReplaceAllString(s, "")
+}
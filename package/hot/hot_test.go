@@ -2,14 +2,18 @@ package hot_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 
+	"github.com/livebud/bud/internal/esmeta"
 	"github.com/livebud/bud/internal/is"
 	"github.com/livebud/bud/internal/pubsub"
 	"github.com/livebud/bud/package/hot"
@@ -77,6 +81,79 @@ func TestPage(t *testing.T) {
 	testServer.Close()
 }
 
+func TestModules(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	metafile, err := esmeta.Parse(`{
+		"inputs": {
+			"view/util.js": {"bytes": 10},
+			"view/Nav.svelte": {
+				"bytes": 10,
+				"imports": [{"path": "view/util.js", "kind": "import-statement"}]
+			},
+			"view/index.svelte": {
+				"bytes": 10,
+				"imports": [{"path": "view/Nav.svelte", "kind": "import-statement"}]
+			}
+		}
+	}`)
+	is.NoErr(err)
+	graph := esmeta.NewGraph()
+	graph.Add(metafile)
+	ps := pubsub.New()
+	hotServer := hot.New(log, ps)
+	hotServer.Now = func() time.Time { return now }
+	hotServer.Graph = graph
+	testServer := httptest.NewServer(hotServer)
+	hotClient, err := hot.Dial(log, testServer.URL+"/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	// Changed components are sent as is
+	ps.Publish("frontend:update", []byte(`["view/Nav.svelte"]`))
+	event, err := hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(string(event.Data), `{"scripts":["/bud/view/index.svelte?ts=1628088960000"],"modules":["view/Nav.svelte"]}`)
+	// Other files are traced to the components that import them
+	ps.Publish("frontend:update", []byte(`["view/util.js"]`))
+	event, err = hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(string(event.Data), `{"scripts":["/bud/view/index.svelte?ts=1628088960000"],"modules":["view/Nav.svelte"]}`)
+	// Files outside of the graph can't be hot swapped
+	ps.Publish("frontend:update", []byte(`["view/util.js","public/app.js"]`))
+	event, err = hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(string(event.Data), `{"scripts":["/bud/view/index.svelte?ts=1628088960000"]}`)
+	is.NoErr(hotClient.Close())
+	testServer.Close()
+}
+
+func TestStyles(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	ps := pubsub.New()
+	hotServer := hot.New(log, ps)
+	hotServer.Now = func() time.Time { return now }
+	testServer := httptest.NewServer(hotServer)
+	hotClient, err := hot.Dial(log, testServer.URL+"/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	ps.Publish("frontend:css", []byte(`["view/index.svelte","public/css/app.css","view/Nav.svelte"]`))
+	event, err := hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "css")
+	is.Equal(string(event.Data), `{"stylesheets":["/bud/","/css/app.css"]}`)
+	// Reload every stylesheet if we don't know what changed
+	ps.Publish("frontend:css", nil)
+	event, err = hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "css")
+	is.Equal(string(event.Data), `{"stylesheets":["/"]}`)
+	is.NoErr(hotClient.Close())
+	testServer.Close()
+}
+
 func TestReload(t *testing.T) {
 	is := is.New(t)
 	log := testlog.New()
@@ -196,3 +273,83 @@ func TestDrainBeforeClose(t *testing.T) {
 	ps.Publish("frontend:update", nil)
 	is.NoErr(hotClient.Close())
 }
+
+func TestOverlay(t *testing.T) {
+	is := is.New(t)
+	log := testlog.New()
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	ps := pubsub.New()
+	hotServer := hot.New(log, ps)
+	testServer := httptest.NewServer(hotServer)
+	hotClient, err := hot.Dial(log, testServer.URL+"/bud/hot/view/index.svelte")
+	is.NoErr(err)
+	ps.Publish("app:error", []byte(`{"message":"undefined: x","file":"controller/controller.go","line":5,"column":2}`))
+	event, err := hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "overlay")
+	is.Equal(string(event.Data), `{"message":"undefined: x","file":"controller/controller.go","line":5,"column":2}`)
+	// Plain messages are wrapped
+	ps.Publish("frontend:error", []byte(`unable to render`))
+	event, err = hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "overlay")
+	is.Equal(string(event.Data), `{"message":"unable to render"}`)
+	// Warnings have their own event, since they don't block the page
+	ps.Publish("frontend:warning", []byte(`{"title":"Svelte warnings","message":"1:1 A11y: <img> element should have an alt attribute (a11y-missing-attribute)","file":"view/index.svelte"}`))
+	event, err = hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "warning")
+	is.In(string(event.Data), `"title":"Svelte warnings"`)
+	// The overlay is cleared once the app is ready
+	ps.Publish("app:ready", nil)
+	event, err = hotClient.Next(ctx)
+	is.NoErr(err)
+	is.Equal(event.Type, "ready")
+	is.NoErr(hotClient.Close())
+	testServer.Close()
+}
+
+type locatedError struct {
+	err error
+}
+
+func (e *locatedError) Error() string {
+	return "view/index.svelte unexpected token\n1: <h1>\n   ^"
+}
+
+func (e *locatedError) Location() (string, int, int) {
+	return "view/index.svelte", 1, 4
+}
+
+func (e *locatedError) CodeFrame() string {
+	return "1: <h1>\n   ^"
+}
+
+func (e *locatedError) Unwrap() error {
+	return e.err
+}
+
+func TestNewError(t *testing.T) {
+	is := is.New(t)
+	// Go compiler errors are parsed
+	herr := hot.NewError(errors.New("gobuild: unable to build. # app.com/bud/internal/app\ncontroller/controller.go:3:2: undefined: x"))
+	is.Equal(herr.File, "controller/controller.go")
+	is.Equal(herr.Line, 3)
+	is.Equal(herr.Column, 2)
+	herr.LoadFrame(fstest.MapFS{
+		"controller/controller.go": &fstest.MapFile{Data: []byte("package controller\nfunc f() {\n\tx()\n}\n")},
+	})
+	is.Equal(herr.Frame, "1: package controller\n2: func f() {\n3:   x()\n     ^\n4: }")
+	// The innermost located error is used
+	herr = hot.NewError(fmt.Errorf("budfs: open %q. %w", "bud/view/_index.svelte.js", &locatedError{errors.New("\x1b[31mboom\x1b[0m")}))
+	is.Equal(herr.File, "view/index.svelte")
+	is.Equal(herr.Line, 1)
+	is.Equal(herr.Column, 4)
+	is.Equal(herr.Message, "view/index.svelte unexpected token")
+	is.Equal(herr.Frame, "1: <h1>\n   ^")
+	// Other errors only have a message, without colors
+	herr = hot.NewError(errors.New("\x1b[31mboom\x1b[0m"))
+	is.Equal(herr.Message, "boom")
+	is.Equal(herr.File, "")
+}
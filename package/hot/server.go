@@ -2,8 +2,10 @@ package hot
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -14,13 +16,28 @@ import (
 
 // New server-sent event (SSE) server
 func New(log log.Interface, ps pubsub.Subscriber) *Server {
-	return &Server{log, ps, time.Now}
+	return &Server{log: log, ps: ps, Now: time.Now}
 }
 
 type Server struct {
-	log log.Interface
-	ps  pubsub.Subscriber
-	Now func() time.Time // Used for testing
+	log   log.Interface
+	ps    pubsub.Subscriber
+	Now   func() time.Time // Used for testing
+	Graph Graph            // Finds the modules affected by a change. Can be nil.
+}
+
+// Graph of the imports between modules
+type Graph interface {
+	Importers(path string, stop func(path string) bool) []string
+}
+
+// Update is sent to the browser when the frontend changes
+type Update struct {
+	// Scripts to re-import before re-rendering the page
+	Scripts []string `json:"scripts"`
+	// Modules that changed, so components can be swapped while keeping their
+	// state. Empty if the change can't be hot swapped.
+	Modules []string `json:"modules,omitempty"`
 }
 
 func pagePath(url string) string {
@@ -51,12 +68,23 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	subscription := s.ps.Subscribe(topics...)
 	s.log.Debug("hot: subscribed to topics", "topics", topics)
+	// Subscribe to style changes, which are swapped without reloading
+	styleSubscription := s.ps.Subscribe("frontend:css")
+	defer styleSubscription.Close()
+	// Subscribe to errors, which are shown in an overlay until the app is ready
+	errorSubscription := s.ps.Subscribe("app:error", "frontend:error")
+	defer errorSubscription.Close()
+	// Subscribe to compiler warnings, which don't block the page
+	warningSubscription := s.ps.Subscribe("frontend:warning")
+	defer warningSubscription.Close()
+	readySubscription := s.ps.Subscribe("app:ready")
+	defer readySubscription.Close()
 	ctx := r.Context()
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-subscription.Wait():
+		case changes := <-subscription.Wait():
 			s.log.Debug("hot: got event", "topic", "frontend:update")
 			if pagePath == "" {
 				s.log.Debug("hot: no page path, triggering a full reload")
@@ -65,16 +93,68 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 			// Add /bud/ because we'll be requesting a generated file
 			scriptPath := fmt.Sprintf("%s?ts=%d", "/bud/"+pagePath, s.Now().UnixMilli())
+			data, err := json.Marshal(&Update{
+				Scripts: []string{scriptPath},
+				Modules: s.modules(changes),
+			})
+			if err != nil {
+				s.log.Error("hot: unable to marshal update", "err", err)
+				continue
+			}
 			event := &Event{
-				Data: []byte(fmt.Sprintf(`{"scripts":[%q]}`, scriptPath)),
+				Data: data,
 			}
 			w.Write(event.Format().Bytes())
 			flusher.Flush()
 
-		// TODO: Create a new event type. EventSourcing has a concept of event types
-		// which can be differentiated by the browser.
+		// Style changes are sent with their own event type, so the browser can
+		// listen for them separately.
 		//
 		// See: https://html.spec.whatwg.org/multipage/server-sent-events.html#server-sent-events-intro
+		case changes := <-styleSubscription.Wait():
+			s.log.Debug("hot: got event", "topic", "frontend:css")
+			data, err := json.Marshal(&StyleUpdate{
+				Stylesheets: stylesheets(changes),
+			})
+			if err != nil {
+				s.log.Error("hot: unable to marshal style update", "err", err)
+				continue
+			}
+			event := &Event{
+				Type: "css",
+				Data: data,
+			}
+			w.Write(event.Format().Bytes())
+			flusher.Flush()
+
+		case data := <-errorSubscription.Wait():
+			s.log.Debug("hot: got event", "topic", "error")
+			// Named "overlay" because "error" is reserved for connection errors
+			event := &Event{
+				Type: "overlay",
+				Data: errorData(data),
+			}
+			w.Write(event.Format().Bytes())
+			flusher.Flush()
+
+		case data := <-warningSubscription.Wait():
+			s.log.Debug("hot: got event", "topic", "frontend:warning")
+			event := &Event{
+				Type: "warning",
+				Data: errorData(data),
+			}
+			w.Write(event.Format().Bytes())
+			flusher.Flush()
+
+		case <-readySubscription.Wait():
+			s.log.Debug("hot: got event", "topic", "app:ready")
+			event := &Event{
+				Type: "ready",
+				Data: []byte(`{}`),
+			}
+			w.Write(event.Format().Bytes())
+			flusher.Flush()
+
 		case <-s.ps.Subscribe("backend:update").Wait():
 			s.log.Debug("hot: got event", "topic", "page:reload")
 			reload(flusher, w)
@@ -82,6 +162,95 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// StyleUpdate is sent to the browser as a "css" event when only styles change
+type StyleUpdate struct {
+	// Stylesheets to reload. Paths ending in a slash reload every stylesheet
+	// within that directory.
+	Stylesheets []string `json:"stylesheets"`
+}
+
+// stylesheets maps the changed files to the URLs of the stylesheets to reload.
+// Public files are served from the root, while the styles within views are
+// bundled into the stylesheets under /bud/.
+func stylesheets(changes []byte) (urls []string) {
+	var paths []string
+	if err := json.Unmarshal(changes, &paths); err != nil || len(paths) == 0 {
+		return []string{"/"}
+	}
+	seen := map[string]bool{}
+	for _, p := range paths {
+		url := "/bud/"
+		if strings.HasPrefix(p, "public/") {
+			url = strings.TrimPrefix(p, "public")
+		}
+		if !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// modules finds the Svelte components affected by the changed files. Changes
+// to other files are traced up to the nearest components that import them. If
+// any change can't be traced to a component, nil is returned and the browser
+// re-renders the page instead.
+func (s *Server) modules(changes []byte) (modules []string) {
+	if s.Graph == nil || len(changes) == 0 {
+		return nil
+	}
+	var paths []string
+	if err := json.Unmarshal(changes, &paths); err != nil {
+		s.log.Debug("hot: unable to parse the changed paths", "err", err)
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, changed := range paths {
+		components := []string{changed}
+		if !isComponent(changed) {
+			components = s.Graph.Importers(changed, isComponent)
+		}
+		found := false
+		for _, component := range components {
+			if !isComponent(component) {
+				continue
+			}
+			found = true
+			if !seen[component] {
+				seen[component] = true
+				modules = append(modules, component)
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return modules
+}
+
+// isComponent returns true for components that can be hot swapped
+func isComponent(p string) bool {
+	switch path.Ext(p) {
+	case ".svelte", ".md", ".mdx":
+		return true
+	default:
+		return false
+	}
+}
+
+// errorData ensures the error is structured, wrapping plain messages
+func errorData(data []byte) []byte {
+	if json.Valid(data) {
+		return data
+	}
+	message := string(data)
+	if message == "" {
+		message = "unknown error"
+	}
+	out, _ := json.Marshal(&Error{Message: message})
+	return out
+}
+
 func reload(flusher http.Flusher, w http.ResponseWriter) {
 	event := &Event{
 		Data: []byte(`{"reload":true}`),
@@ -0,0 +1,273 @@
+package goja
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/livebud/bud/package/js"
+)
+
+type Value = goja.Value
+type Error = js.Error
+
+func Eval(path, code string) (string, error) {
+	vm, err := Load()
+	if err != nil {
+		return "", err
+	}
+	return vm.Eval(path, code)
+}
+
+// Load a pure Go VM. Unlike the V8 VM, it doesn't need cgo, so apps can be
+// statically linked and cross-compiled.
+func Load() (*VM, error) {
+	vm := &VM{
+		runtime: goja.New(),
+		timers:  map[int64]*timer{},
+	}
+	if err := vm.inject(); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+func Compile(path, code string) (*VM, error) {
+	vm, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.Script(path, code); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+type VM struct {
+	// The runtime isn't safe to use concurrently
+	mu      sync.Mutex
+	runtime *goja.Runtime
+	// Pending setTimeout calls
+	timers  map[int64]*timer
+	timerID int64
+}
+
+var _ js.VM = (*VM)(nil)
+
+// Compile a script into the context
+func (vm *VM) Script(path, code string) error {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	if _, err := vm.runtime.RunScript(path, code); err != nil {
+		return jsError(err)
+	}
+	return nil
+}
+
+func (vm *VM) Eval(path, expr string) (string, error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	value, err := vm.runtime.RunScript(path, expr)
+	if err != nil {
+		return "", jsError(err)
+	}
+	// Handle promises
+	promise, ok := value.Export().(*goja.Promise)
+	if !ok {
+		return value.String(), nil
+	}
+	// Run the timers until the promise settles
+	for promise.State() == goja.PromiseStatePending {
+		ran, err := vm.runTimer()
+		if err != nil {
+			return "", err
+		}
+		if !ran {
+			return "", fmt.Errorf("goja: promise returned by %q never settled", path)
+		}
+	}
+	if promise.State() == goja.PromiseStateRejected {
+		return "", rejection(promise.Result())
+	}
+	return promise.Result().String(), nil
+}
+
+// Close the VM, dropping any pending timers
+func (vm *VM) Close() {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	vm.timers = map[int64]*timer{}
+}
+
+// inject the globals that the V8 VM provides, along with ones that throw for
+// what's not supported yet
+func (vm *VM) inject() error {
+	globals := map[string]interface{}{
+		"console":         vm.console(),
+		"setTimeout":      vm.setTimeout,
+		"clearTimeout":    vm.clearTimeout,
+		"setInterval":     vm.setInterval,
+		"clearInterval":   vm.clearTimeout,
+		"fetch":           vm.unsupported("fetch"),
+		"URL":             vm.unsupportedClass("URL"),
+		"URLSearchParams": vm.unsupportedClass("URLSearchParams"),
+	}
+	for name, value := range globals {
+		if err := vm.runtime.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// console log, warn, error support
+func (vm *VM) console() *goja.Object {
+	console := vm.runtime.NewObject()
+	console.Set("log", vm.print(os.Stdout))
+	console.Set("warn", vm.print(os.Stderr))
+	console.Set("error", vm.print(os.Stderr))
+	return console
+}
+
+func (vm *VM) print(w io.Writer) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		args := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = arg.String()
+		}
+		fmt.Fprintln(w, strings.Join(args, " "))
+		return goja.Undefined()
+	}
+}
+
+// unsupported functions throw when they're called, rather than when they're
+// referenced, so feature detection still works
+func (vm *VM) unsupported(name string) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		panic(vm.runtime.NewTypeError(unsupportedMessage(name)))
+	}
+}
+
+func (vm *VM) unsupportedClass(name string) func(call goja.ConstructorCall) *goja.Object {
+	return func(call goja.ConstructorCall) *goja.Object {
+		panic(vm.runtime.NewTypeError(unsupportedMessage(name)))
+	}
+}
+
+func unsupportedMessage(name string) string {
+	return fmt.Sprintf("%s is not supported by the goja javascript VM. Build with --js=v8 to use it", name)
+}
+
+// timer scheduled by setTimeout or setInterval
+type timer struct {
+	due  time.Time
+	fn   goja.Callable
+	args []goja.Value
+	// Interval between calls. It's zero for timeouts.
+	interval time.Duration
+}
+
+func (vm *VM) setTimeout(call goja.FunctionCall) goja.Value {
+	return vm.schedule("setTimeout", call, false)
+}
+
+func (vm *VM) setInterval(call goja.FunctionCall) goja.Value {
+	return vm.schedule("setInterval", call, true)
+}
+
+func (vm *VM) schedule(name string, call goja.FunctionCall, repeat bool) goja.Value {
+	fn, ok := goja.AssertFunction(call.Argument(0))
+	if !ok {
+		panic(vm.runtime.NewTypeError(name + ": callback must be a function"))
+	}
+	delay := time.Duration(call.Argument(1).ToInteger()) * time.Millisecond
+	if delay < 0 {
+		delay = 0
+	}
+	t := &timer{
+		due: time.Now().Add(delay),
+		fn:  fn,
+	}
+	if len(call.Arguments) > 2 {
+		t.args = call.Arguments[2:]
+	}
+	if repeat {
+		// Avoid spinning on intervals without a delay
+		t.interval = delay
+		if t.interval == 0 {
+			t.interval = time.Millisecond
+		}
+	}
+	vm.timerID++
+	vm.timers[vm.timerID] = t
+	return vm.runtime.ToValue(vm.timerID)
+}
+
+func (vm *VM) clearTimeout(call goja.FunctionCall) goja.Value {
+	delete(vm.timers, call.Argument(0).ToInteger())
+	return goja.Undefined()
+}
+
+// runTimer waits for the next timer and runs it. It returns false when there
+// are no timers left.
+func (vm *VM) runTimer() (bool, error) {
+	var next int64
+	for id, timer := range vm.timers {
+		if next == 0 || timer.due.Before(vm.timers[next].due) ||
+			(timer.due.Equal(vm.timers[next].due) && id < next) {
+			next = id
+		}
+	}
+	if next == 0 {
+		return false, nil
+	}
+	timer := vm.timers[next]
+	delete(vm.timers, next)
+	time.Sleep(time.Until(timer.due))
+	// Reschedule intervals before calling them, so they can clear themselves
+	if timer.interval > 0 {
+		timer.due = timer.due.Add(timer.interval)
+		vm.timers[next] = timer
+	}
+	if _, err := timer.fn(goja.Undefined(), timer.args...); err != nil {
+		return true, jsError(err)
+	}
+	return true, nil
+}
+
+// jsError converts exceptions into errors that keep their stack trace
+func jsError(err error) error {
+	exception, ok := err.(*goja.Exception)
+	if !ok || exception.Value() == nil {
+		return err
+	}
+	return &js.Error{
+		Message: exception.Value().String(),
+		Stack:   formatStack(exception.String()),
+	}
+}
+
+// rePC matches the program counter that goja adds after each location
+var rePC = regexp.MustCompile(`(:\d+:\d+)\(\d+\)`)
+
+// formatStack formats the stack trace like V8 does, so the locations can be
+// mapped back to the original source code
+func formatStack(stack string) string {
+	return rePC.ReplaceAllString(strings.TrimSpace(stack), "$1")
+}
+
+// rejection converts the reason a promise was rejected into an error
+func rejection(reason goja.Value) error {
+	err := &js.Error{Message: reason.String()}
+	if object, ok := reason.(*goja.Object); ok {
+		if stack := object.Get("stack"); stack != nil && !goja.IsUndefined(stack) {
+			err.Stack = formatStack(stack.String())
+		}
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package goja_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/package/js"
+	"github.com/livebud/bud/package/js/goja"
+)
+
+func TestCompile(t *testing.T) {
+	is := is.New(t)
+	vm, err := goja.Compile("math.js", `const multiply = (a, b) => a * b`)
+	is.NoErr(err)
+	defer vm.Close()
+	value, err := vm.Eval("run.js", "multiply(3, 2)")
+	is.NoErr(err)
+	is.Equal("6", value)
+}
+
+func TestEval(t *testing.T) {
+	is := is.New(t)
+	result, err := goja.Eval("TestEval.js", "2*5")
+	is.NoErr(err)
+	is.Equal("10", result)
+}
+
+func TestConsoleLog(t *testing.T) {
+	is := is.New(t)
+	_, err := goja.Eval("TestConsole.js", `console.log("a", 3, { hi: "world" })`)
+	is.NoErr(err)
+}
+
+func TestConsoleWarn(t *testing.T) {
+	is := is.New(t)
+	_, err := goja.Eval("TestConsole.js", `console.warn("a", 3, { hi: "world" })`)
+	is.NoErr(err)
+}
+
+func TestConsoleError(t *testing.T) {
+	is := is.New(t)
+	_, err := goja.Eval("TestConsole.js", `console.error("a", 3, { hi: "world" })`)
+	is.NoErr(err)
+}
+
+func TestFetchUnsupported(t *testing.T) {
+	is := is.New(t)
+	_, err := goja.Eval("TestFetch.js", `fetch("http://google.com").then(res => res.status)`)
+	is.True(err != nil)
+	is.In(err.Error(), "fetch is not supported by the goja javascript VM")
+	// Feature detection still works
+	res, err := goja.Eval("TestFetch.js", `typeof fetch`)
+	is.NoErr(err)
+	is.Equal(res, "function")
+}
+
+func TestURLUnsupported(t *testing.T) {
+	is := is.New(t)
+	_, err := goja.Eval("TestURL.js", `(new URL("http://google.com/hi")).host`)
+	is.True(err != nil)
+	is.In(err.Error(), "URL is not supported by the goja javascript VM")
+}
+
+func TestSetClearInterval(t *testing.T) {
+	is := is.New(t)
+	res, err := goja.Eval("TestSetClearInterval.js", `let id = setInterval(() => clearInterval(id), 500)`)
+	is.NoErr(err)
+	is.Equal(res, "undefined")
+}
+
+func TestSetClearTimeout(t *testing.T) {
+	is := is.New(t)
+	res, err := goja.Eval("TestSetClearTimeout.js", `let id = setTimeout(() => clearTimeout(id), 500)`)
+	is.NoErr(err)
+	is.Equal(res, "undefined")
+}
+
+func TestPromise(t *testing.T) {
+	is := is.New(t)
+	res, err := goja.Eval("TestPromise.js", `new Promise((resolve) => setTimeout(() => resolve("done"), 10))`)
+	is.NoErr(err)
+	is.Equal(res, "done")
+	res, err = goja.Eval("TestPromise.js", `(async () => { await null; return 1 + 2 })()`)
+	is.NoErr(err)
+	is.Equal(res, "3")
+}
+
+func TestPromiseInterval(t *testing.T) {
+	is := is.New(t)
+	res, err := goja.Eval("TestPromiseInterval.js", `new Promise((resolve) => {
+		let count = 0
+		let id = setInterval(() => {
+			if (++count < 3) return
+			clearInterval(id)
+			resolve(count)
+		}, 1)
+	})`)
+	is.NoErr(err)
+	is.Equal(res, "3")
+}
+
+func TestPromiseRejected(t *testing.T) {
+	is := is.New(t)
+	_, err := goja.Eval("TestPromiseRejected.js", `Promise.reject(new Error("boom"))`)
+	is.True(err != nil)
+	is.Equal(err.Error(), "Error: boom")
+}
+
+func TestPromiseNeverSettled(t *testing.T) {
+	is := is.New(t)
+	_, err := goja.Eval("TestPromiseNeverSettled.js", `new Promise(() => {})`)
+	is.True(err != nil)
+	is.In(err.Error(), "never settled")
+}
+
+func TestErrorStack(t *testing.T) {
+	is := is.New(t)
+	vm, err := goja.Compile("render.js", "function render() {\n  throw new Error(\"boom\")\n}")
+	is.NoErr(err)
+	_, err = vm.Eval("run.js", "render()")
+	is.True(err != nil)
+	var jsErr *js.Error
+	is.True(errors.As(err, &jsErr))
+	is.Equal(jsErr.Message, "Error: boom")
+	is.In(jsErr.Stack, "Error: boom")
+	is.In(jsErr.Stack, "at render (render.js:2:9)")
+}
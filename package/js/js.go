@@ -5,3 +5,15 @@ type VM interface {
 	Script(path, script string) error
 	Eval(path, expression string) (string, error)
 }
+
+// Error thrown while evaluating javascript
+type Error struct {
+	Message string
+	// Stack trace starting with the message. It's empty when the VM doesn't
+	// know the stack.
+	Stack string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
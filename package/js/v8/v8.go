@@ -12,7 +12,7 @@ import (
 )
 
 type Value = v8go.Value
-type Error = v8go.JSError
+type Error = js.Error
 
 func Eval(path, code string) (string, error) {
 	vm, err := Load()
@@ -105,7 +105,7 @@ func (vm *VM) Script(path, code string) error {
 	}
 	// Bind to the context
 	if _, err := script.Run(vm.context); err != nil {
-		return err
+		return jsError(err)
 	}
 	return nil
 }
@@ -113,7 +113,7 @@ func (vm *VM) Script(path, code string) error {
 func (vm *VM) Eval(path, expr string) (string, error) {
 	value, err := vm.context.RunScript(expr, path)
 	if err != nil {
-		return "", err
+		return "", jsError(err)
 	}
 	// Handle promises
 	if value.IsPromise() {
@@ -130,6 +130,15 @@ func (vm *VM) Eval(path, expr string) (string, error) {
 	return value.String(), nil
 }
 
+// jsError converts exceptions into errors that keep their stack trace
+func jsError(err error) error {
+	jsErr, ok := err.(*v8go.JSError)
+	if !ok {
+		return err
+	}
+	return &js.Error{Message: jsErr.Message, Stack: jsErr.StackTrace}
+}
+
 func (vm *VM) Close() {
 	vm.context.Close()
 	vm.isolate.TerminateExecution()
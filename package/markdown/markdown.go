@@ -0,0 +1,802 @@
+// Package markdown renders markdown into HTML and turns markdown views into
+// Svelte components.
+//
+// The renderer supports the commonly used subset of CommonMark alongside
+// GitHub's tables and strikethrough: headings, paragraphs, emphasis, code,
+// links, images, lists, blockquotes, thematic breaks and HTML.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Render markdown into HTML
+func Render(source []byte) []byte {
+	r := &renderer{}
+	out := new(strings.Builder)
+	r.blocks(out, splitLines(string(source)), false)
+	return []byte(out.String())
+}
+
+// renderer renders markdown. In MDX, {expressions} are passed through as-is
+// and components can be used alongside HTML.
+type renderer struct {
+	mdx bool
+}
+
+func splitLines(source string) []string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+	return strings.Split(strings.TrimRight(source, "\n"), "\n")
+}
+
+var (
+	reATXHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	reThematic     = regexp.MustCompile(`^ {0,3}(?:(?:\*[ ]*){3,}|(?:-[ ]*){3,}|(?:_[ ]*){3,})$`)
+	reSetext1      = regexp.MustCompile(`^ {0,3}=+[ ]*$`)
+	reSetext2      = regexp.MustCompile(`^ {0,3}-+[ ]*$`)
+	reFence        = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ ]*([^`]*)$")
+	reListItem     = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])( +|$)`)
+	reHTMLBlock    = regexp.MustCompile(`^ {0,3}(?:<[A-Za-z][A-Za-z0-9\-.]*(?::[A-Za-z][A-Za-z0-9\-]*)?(?:[\s/>]|$)|</[A-Za-z][A-Za-z0-9\-.:]*\s*>|<!--|<!|<\?)`)
+	reTableDivider = regexp.MustCompile(`^ {0,3}\|?[ ]*:?-+:?[ ]*(?:\|[ ]*:?-+:?[ ]*)*\|?[ ]*$`)
+)
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// indentation returns the number of leading spaces
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// dedent removes up to n leading spaces
+func dedent(line string, n int) string {
+	i := 0
+	for i < n && i < len(line) && line[i] == ' ' {
+		i++
+	}
+	return line[i:]
+}
+
+// interrupts returns true if the line starts a block that interrupts a
+// paragraph
+func (r *renderer) interrupts(line string) bool {
+	if reATXHeading.MatchString(line) || reThematic.MatchString(line) ||
+		reFence.MatchString(line) || r.isHTMLBlock(line) {
+		return true
+	}
+	trimmed := strings.TrimLeft(line, " ")
+	if indentation(line) < 4 && strings.HasPrefix(trimmed, ">") {
+		return true
+	}
+	// Only bullets and lists starting at 1 interrupt paragraphs
+	if m := reListItem.FindStringSubmatch(line); m != nil && m[3] != "" {
+		marker := m[2]
+		return !isOrdered(marker) || strings.HasPrefix(marker, "1") && len(marker) == 2
+	}
+	return false
+}
+
+func (r *renderer) isHTMLBlock(line string) bool {
+	if reHTMLBlock.MatchString(line) {
+		return true
+	}
+	// Svelte blocks like {#if} and {#each} can be used in MDX
+	return r.mdx && strings.HasPrefix(strings.TrimLeft(line, " "), "{")
+}
+
+func isOrdered(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+// blocks renders a list of lines. Paragraphs in tight lists aren't wrapped.
+func (r *renderer) blocks(out *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case reFence.MatchString(line):
+			i = r.fencedCode(out, lines, i)
+		case reATXHeading.MatchString(line):
+			m := reATXHeading.FindStringSubmatch(line)
+			r.heading(out, len(m[1]), m[2])
+			i++
+		case reThematic.MatchString(line):
+			out.WriteString("<hr />\n")
+			i++
+		case indentation(line) < 4 && strings.HasPrefix(strings.TrimLeft(line, " "), ">"):
+			i = r.blockquote(out, lines, i)
+		case reListItem.MatchString(line):
+			i = r.list(out, lines, i)
+		case r.isHTMLBlock(line):
+			i = r.htmlBlock(out, lines, i)
+		case indentation(line) >= 4:
+			i = r.indentedCode(out, lines, i)
+		default:
+			i = r.paragraph(out, lines, i, tight)
+		}
+	}
+}
+
+func (r *renderer) heading(out *strings.Builder, level int, text string) {
+	fmt.Fprintf(out, "<h%d>%s</h%d>\n", level, r.inline(strings.TrimSpace(text)), level)
+}
+
+func (r *renderer) fencedCode(out *strings.Builder, lines []string, i int) int {
+	m := reFence.FindStringSubmatch(lines[i])
+	indent, fence, info := len(m[1]), m[2], strings.TrimSpace(m[3])
+	var code []string
+	i++
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		code = append(code, dedent(lines[i], indent))
+	}
+	out.WriteString("<pre><code")
+	if info != "" {
+		language := strings.Fields(info)[0]
+		out.WriteString(` class="language-` + escapeAttr(unescapeText(language)) + `"`)
+	}
+	out.WriteString(">")
+	for _, line := range code {
+		out.WriteString(r.escapeCode(line) + "\n")
+	}
+	out.WriteString("</code></pre>\n")
+	return i
+}
+
+func (r *renderer) indentedCode(out *strings.Builder, lines []string, i int) int {
+	var code []string
+	for ; i < len(lines); i++ {
+		if !isBlank(lines[i]) && indentation(lines[i]) < 4 {
+			break
+		}
+		code = append(code, dedent(lines[i], 4))
+	}
+	// Trailing blank lines aren't part of the code
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+	out.WriteString("<pre><code>")
+	for _, line := range code {
+		out.WriteString(r.escapeCode(line) + "\n")
+	}
+	out.WriteString("</code></pre>\n")
+	return i
+}
+
+func (r *renderer) blockquote(out *strings.Builder, lines []string, i int) int {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		if indentation(line) < 4 && strings.HasPrefix(trimmed, ">") {
+			trimmed = strings.TrimPrefix(trimmed, ">")
+			inner = append(inner, strings.TrimPrefix(trimmed, " "))
+			continue
+		}
+		// Lazy continuation of a paragraph within the blockquote
+		if !isBlank(line) && len(inner) > 0 && !isBlank(inner[len(inner)-1]) && !r.interrupts(line) {
+			inner = append(inner, line)
+			continue
+		}
+		break
+	}
+	out.WriteString("<blockquote>\n")
+	r.blocks(out, inner, false)
+	out.WriteString("</blockquote>\n")
+	return i
+}
+
+// listItem is an item within a list
+type listItem struct {
+	lines []string
+}
+
+func (r *renderer) list(out *strings.Builder, lines []string, i int) int {
+	first := reListItem.FindStringSubmatch(lines[i])
+	marker := first[2]
+	ordered := isOrdered(marker)
+	delimiter := marker[len(marker)-1:]
+	var items []*listItem
+	var item *listItem
+	contentIndent := 0
+	loose := false
+	blank := false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			blank = true
+			if item != nil {
+				item.lines = append(item.lines, "")
+			}
+			continue
+		}
+		if m := reListItem.FindStringSubmatch(line); m != nil && indentation(line) < contentIndent || m != nil && item == nil {
+			// A different kind of marker starts a new list
+			if isOrdered(m[2]) != ordered || m[2][len(m[2])-1:] != delimiter {
+				break
+			}
+			if blank && item != nil {
+				loose = true
+			}
+			blank = false
+			item = &listItem{}
+			items = append(items, item)
+			spaces := len(m[3])
+			// Content starting with 5+ spaces is indented code, which starts 1
+			// space after the marker
+			if spaces > 4 {
+				spaces = 1
+			}
+			if m[3] == "" {
+				spaces = 1
+			}
+			contentIndent = len(m[1]) + len(m[2]) + spaces
+			item.lines = append(item.lines, dedent(line[len(m[1])+len(m[2]):], spaces))
+			continue
+		}
+		if indentation(line) >= contentIndent {
+			if blank {
+				// Blank lines between the blocks of an item make the list loose
+				if hasContent(item.lines) {
+					loose = true
+				}
+			}
+			blank = false
+			item.lines = append(item.lines, dedent(line, contentIndent))
+			continue
+		}
+		// Lazy continuation of a paragraph within the item
+		if !blank && !r.interrupts(line) {
+			item.lines = append(item.lines, strings.TrimLeft(line, " "))
+			continue
+		}
+		break
+	}
+	if ordered {
+		start, _ := strconv.Atoi(strings.TrimRight(marker, ".)"))
+		if start != 1 {
+			fmt.Fprintf(out, "<ol start=\"%d\">\n", start)
+		} else {
+			out.WriteString("<ol>\n")
+		}
+	} else {
+		out.WriteString("<ul>\n")
+	}
+	for _, item := range items {
+		inner := new(strings.Builder)
+		r.blocks(inner, item.lines, !loose)
+		content := strings.TrimSuffix(inner.String(), "\n")
+		if !loose && !strings.Contains(content, "\n") {
+			out.WriteString("<li>" + content + "</li>\n")
+			continue
+		}
+		out.WriteString("<li>")
+		if loose || strings.HasPrefix(content, "<") && !strings.HasPrefix(content, "<code") {
+			out.WriteString("\n")
+		}
+		out.WriteString(content + "\n</li>\n")
+	}
+	if ordered {
+		out.WriteString("</ol>\n")
+	} else {
+		out.WriteString("</ul>\n")
+	}
+	return i
+}
+
+// hasContent returns true if there are non-blank lines
+func hasContent(lines []string) bool {
+	for _, line := range lines {
+		if !isBlank(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlBlock passes HTML through as-is until the next blank line
+func (r *renderer) htmlBlock(out *strings.Builder, lines []string, i int) int {
+	for ; i < len(lines) && !isBlank(lines[i]); i++ {
+		out.WriteString(lines[i] + "\n")
+	}
+	return i
+}
+
+func (r *renderer) paragraph(out *strings.Builder, lines []string, i int, tight bool) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+		if len(text) > 0 {
+			// Setext headings underline the paragraph
+			if reSetext1.MatchString(line) {
+				r.heading(out, 1, strings.Join(text, "\n"))
+				return i + 1
+			} else if reSetext2.MatchString(line) {
+				r.heading(out, 2, strings.Join(text, "\n"))
+				return i + 1
+			} else if r.interrupts(line) {
+				break
+			}
+		}
+		// Tables start with a header row followed by a divider
+		if len(text) == 0 && strings.Contains(line, "|") && i+1 < len(lines) && reTableDivider.MatchString(lines[i+1]) {
+			return r.table(out, lines, i)
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+	content := r.inline(strings.TrimRight(strings.Join(text, "\n"), " "))
+	if tight {
+		out.WriteString(content + "\n")
+		return i
+	}
+	out.WriteString("<p>" + content + "</p>\n")
+	return i
+}
+
+func (r *renderer) table(out *strings.Builder, lines []string, i int) int {
+	header := splitRow(lines[i])
+	dividers := splitRow(lines[i+1])
+	aligns := make([]string, len(header))
+	for j := range aligns {
+		if j >= len(dividers) {
+			break
+		}
+		divider := strings.TrimSpace(dividers[j])
+		left, right := strings.HasPrefix(divider, ":"), strings.HasSuffix(divider, ":")
+		switch {
+		case left && right:
+			aligns[j] = "center"
+		case left:
+			aligns[j] = "left"
+		case right:
+			aligns[j] = "right"
+		}
+	}
+	cell := func(tag string, j int, text string) string {
+		if aligns[j] != "" {
+			return fmt.Sprintf("<%s align=\"%s\">%s</%s>", tag, aligns[j], r.inline(text), tag)
+		}
+		return fmt.Sprintf("<%s>%s</%s>", tag, r.inline(text), tag)
+	}
+	out.WriteString("<table>\n<thead>\n<tr>\n")
+	for j, text := range header {
+		out.WriteString(cell("th", j, text) + "\n")
+	}
+	out.WriteString("</tr>\n</thead>\n")
+	i += 2
+	body := false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) || r.interrupts(line) {
+			break
+		}
+		if !body {
+			out.WriteString("<tbody>\n")
+			body = true
+		}
+		row := splitRow(line)
+		out.WriteString("<tr>\n")
+		for j := range header {
+			text := ""
+			if j < len(row) {
+				text = row[j]
+			}
+			out.WriteString(cell("td", j, text) + "\n")
+		}
+		out.WriteString("</tr>\n")
+	}
+	if body {
+		out.WriteString("</tbody>\n")
+	}
+	out.WriteString("</table>\n")
+	return i
+}
+
+// splitRow splits a table row into cells, ignoring escaped pipes
+func splitRow(line string) (cells []string) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	cell := new(strings.Builder)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '|' {
+			cell.WriteByte('|')
+			i++
+			continue
+		}
+		if line[i] == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(line[i])
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+var (
+	reAutolink   = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.\-]{1,31}:[^<>\s]*)>`)
+	reEmail      = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_{|}~\-]+@[A-Za-z0-9](?:[A-Za-z0-9\-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9\-]{0,61}[A-Za-z0-9])?)*)>`)
+	reInlineHTML = regexp.MustCompile(`^(?:<[A-Za-z][A-Za-z0-9\-.:]*(?:\s+[A-Za-z_:@][^\s=<>]*(?:\s*=\s*(?:"[^"]*"|'[^']*'|\{[^}]*\}|[^\s"'=<>` + "`" + `]+))?)*\s*/?>|</[A-Za-z][A-Za-z0-9\-.:]*\s*>|<!--[\s\S]*?-->)`)
+	reEntity     = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9A-Fa-f]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+)
+
+// inline renders the inline markdown within a block
+func (r *renderer) inline(text string) string {
+	out := new(strings.Builder)
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch c {
+		case '\\':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				out.WriteString("<br />\n")
+				i += 2
+				continue
+			}
+			if i+1 < len(text) && isPunct(text[i+1]) {
+				out.WriteString(r.escapeCode(string(text[i+1])))
+				i += 2
+				continue
+			}
+		case '`':
+			if n, code, ok := codeSpan(text[i:]); ok {
+				out.WriteString("<code>" + r.escapeCode(code) + "</code>")
+				i += n
+				continue
+			}
+			// Unmatched backticks are literal
+			run := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			out.WriteString(text[i : i+run])
+			i += run
+			continue
+		case '!':
+			if i+1 < len(text) && text[i+1] == '[' {
+				if n, label, dest, title, ok := link(text[i+1:]); ok {
+					out.WriteString(`<img src="` + escapeAttr(dest) + `" alt="` + escapeAttr(plainText(label)) + `"`)
+					if title != "" {
+						out.WriteString(` title="` + escapeAttr(title) + `"`)
+					}
+					out.WriteString(" />")
+					i += n + 1
+					continue
+				}
+			}
+		case '[':
+			if n, label, dest, title, ok := link(text[i:]); ok {
+				out.WriteString(`<a href="` + escapeAttr(dest) + `"`)
+				if title != "" {
+					out.WriteString(` title="` + escapeAttr(title) + `"`)
+				}
+				out.WriteString(">" + r.inline(label) + "</a>")
+				i += n
+				continue
+			}
+		case '<':
+			if m := reAutolink.FindStringSubmatch(text[i:]); m != nil {
+				out.WriteString(`<a href="` + escapeAttr(m[1]) + `">` + escapeText(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+			if m := reEmail.FindStringSubmatch(text[i:]); m != nil {
+				out.WriteString(`<a href="mailto:` + escapeAttr(m[1]) + `">` + escapeText(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+			if m := reInlineHTML.FindString(text[i:]); m != "" {
+				out.WriteString(m)
+				i += len(m)
+				continue
+			}
+		case '&':
+			if m := reEntity.FindString(text[i:]); m != "" {
+				out.WriteString(m)
+				i += len(m)
+				continue
+			}
+		case '{':
+			// Expressions are passed through in MDX
+			if r.mdx {
+				if n := matchBrace(text[i:]); n > 0 {
+					out.WriteString(text[i : i+n])
+					i += n
+					continue
+				}
+			}
+		case '*', '_', '~':
+			if n, html, ok := r.emphasis(text, i); ok {
+				out.WriteString(html)
+				i += n
+				continue
+			}
+			// Write the whole run, so the closing delimiters aren't confused
+			run := len(text[i:]) - len(strings.TrimLeft(text[i:], string(c)))
+			out.WriteString(text[i : i+run])
+			i += run
+			continue
+		case '\n':
+			// Two trailing spaces make a hard break
+			if strings.HasSuffix(out.String(), "  ") {
+				trimmed := strings.TrimRight(out.String(), " ")
+				out.Reset()
+				out.WriteString(trimmed + "<br />\n")
+			} else {
+				trimmed := strings.TrimRight(out.String(), " ")
+				out.Reset()
+				out.WriteString(trimmed + "\n")
+			}
+			i++
+			// Leading spaces on the next line are ignored
+			for i < len(text) && text[i] == ' ' {
+				i++
+			}
+			continue
+		}
+		out.WriteString(escapeText(string(c)))
+		i++
+	}
+	return out.String()
+}
+
+// emphasis renders emphasis, strong emphasis and strikethrough starting at i
+func (r *renderer) emphasis(text string, i int) (n int, html string, ok bool) {
+	c := text[i]
+	run := len(text[i:]) - len(strings.TrimLeft(text[i:], string(c)))
+	// Opening delimiters must be followed by a non-space
+	if i+run >= len(text) || unicode.IsSpace(rune(text[i+run])) {
+		return 0, "", false
+	}
+	// Underscores don't emphasize within words
+	if c == '_' && i > 0 && isAlnum(text[i-1]) {
+		return 0, "", false
+	}
+	if c == '~' {
+		if run != 2 {
+			return 0, "", false
+		}
+		end := closing(text, i+2, "~~")
+		if end < 0 {
+			return 0, "", false
+		}
+		return end + 2 - i, "<del>" + r.inline(text[i+2:end]) + "</del>", true
+	}
+	// Try the longest delimiter first
+	for size := min(run, 3); size > 0; size-- {
+		delimiter := strings.Repeat(string(c), size)
+		end := closing(text, i+size, delimiter)
+		if end < 0 {
+			continue
+		}
+		if c == '_' && end+size < len(text) && isAlnum(text[end+size]) {
+			continue
+		}
+		inner := r.inline(text[i+size : end])
+		switch size {
+		case 3:
+			html = "<em><strong>" + inner + "</strong></em>"
+		case 2:
+			html = "<strong>" + inner + "</strong>"
+		default:
+			html = "<em>" + inner + "</em>"
+		}
+		return end + size - i, html, true
+	}
+	return 0, "", false
+}
+
+// closing finds the closing delimiter, which must follow a non-space and not
+// be part of a longer run. Code spans are skipped.
+func closing(text string, from int, delimiter string) int {
+	for j := from; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+			continue
+		case '`':
+			if n, _, ok := codeSpan(text[j:]); ok {
+				j += n - 1
+			}
+			continue
+		}
+		if text[j] != delimiter[0] {
+			continue
+		}
+		// Skip delimiters that are part of a different sized run
+		run := len(text[j:]) - len(strings.TrimLeft(text[j:], delimiter[:1]))
+		if run != len(delimiter) || j == from || unicode.IsSpace(rune(text[j-1])) {
+			j += run - 1
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// codeSpan parses a code span at the start of the text
+func codeSpan(text string) (n int, code string, ok bool) {
+	run := len(text) - len(strings.TrimLeft(text, "`"))
+	delimiter := text[:run]
+	for j := run; j < len(text); {
+		k := strings.Index(text[j:], delimiter)
+		if k < 0 {
+			return 0, "", false
+		}
+		start := j + k
+		end := start + run
+		// The closing run must be exactly as long as the opening run
+		if end < len(text) && text[end] == '`' {
+			j = end + len(text[end:]) - len(strings.TrimLeft(text[end:], "`"))
+			continue
+		}
+		code = strings.ReplaceAll(text[run:start], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+		return end, code, true
+	}
+	return 0, "", false
+}
+
+// link parses [label](destination "title") at the start of the text
+func link(text string) (n int, label, dest, title string, ok bool) {
+	end := matchBracket(text)
+	if end < 0 || end+1 >= len(text) || text[end+1] != '(' {
+		return 0, "", "", "", false
+	}
+	label = text[1:end]
+	rest := text[end+2:]
+	close := strings.IndexByte(rest, ')')
+	// Allow balanced parentheses within the destination
+	depth := 0
+	for j := 0; j < len(rest); j++ {
+		if rest[j] == '\\' {
+			j++
+			continue
+		}
+		if rest[j] == '(' {
+			depth++
+		} else if rest[j] == ')' {
+			if depth == 0 {
+				close = j
+				break
+			}
+			depth--
+		}
+	}
+	if close < 0 {
+		return 0, "", "", "", false
+	}
+	inner := strings.TrimSpace(rest[:close])
+	if strings.HasPrefix(inner, "<") {
+		if k := strings.IndexByte(inner, '>'); k > 0 {
+			dest = inner[1:k]
+			inner = strings.TrimSpace(inner[k+1:])
+		}
+	} else if k := strings.IndexAny(inner, " \n"); k >= 0 {
+		dest = inner[:k]
+		inner = strings.TrimSpace(inner[k:])
+	} else {
+		dest = inner
+		inner = ""
+	}
+	if inner != "" {
+		if len(inner) < 2 || !strings.ContainsAny(inner[:1], `"'(`) {
+			return 0, "", "", "", false
+		}
+		title = inner[1 : len(inner)-1]
+	}
+	return end + 2 + close + 1, label, unescapeText(dest), unescapeText(title), true
+}
+
+// matchBracket returns the index of the bracket closing the opening bracket
+func matchBracket(text string) int {
+	depth := 0
+	for j := 0; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '`':
+			if n, _, ok := codeSpan(text[j:]); ok {
+				j += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// matchBrace returns the length of the balanced {expression}, or 0 if it's
+// not closed
+func matchBrace(text string) int {
+	depth := 0
+	for j := 0; j < len(text); j++ {
+		switch text[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return 0
+}
+
+// plainText strips the markdown from image descriptions
+func plainText(text string) string {
+	return strings.NewReplacer("*", "", "_", "", "`", "", "[", "", "]", "").Replace(unescapeText(text))
+}
+
+func isPunct(c byte) bool {
+	return c < 128 && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func isAlnum(c byte) bool {
+	return c < 128 && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)))
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// unescapeText removes backslash escapes and decodes entities
+func unescapeText(text string) string {
+	out := new(strings.Builder)
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && isPunct(text[i+1]) {
+			i++
+		}
+		out.WriteByte(text[i])
+	}
+	return html.UnescapeString(out.String())
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func escapeAttr(text string) string {
+	return attrEscaper.Replace(text)
+}
+
+var braceEscaper = strings.NewReplacer("{", "&#123;", "}", "&#125;")
+
+// escapeCode escapes code and escaped characters. Braces are escaped in MDX,
+// so they're not mistaken for expressions.
+func (r *renderer) escapeCode(code string) string {
+	code = escapeText(code)
+	if r.mdx {
+		code = braceEscaper.Replace(code)
+	}
+	return code
+}
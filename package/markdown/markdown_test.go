@@ -0,0 +1,108 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/package/markdown"
+)
+
+func render(source string) string {
+	return strings.TrimSpace(string(markdown.Render([]byte(source))))
+}
+
+func TestHeadings(t *testing.T) {
+	is := is.New(t)
+	is.Equal(render("# Hello *world*"), "<h1>Hello <em>world</em></h1>")
+	is.Equal(render("### Three ###"), "<h3>Three</h3>")
+	is.Equal(render("Title\n====="), "<h1>Title</h1>")
+	is.Equal(render("Subtitle\n---"), "<h2>Subtitle</h2>")
+	is.Equal(render("#hashtag"), "<p>#hashtag</p>")
+}
+
+func TestInline(t *testing.T) {
+	is := is.New(t)
+	is.Equal(render("**bold** and _em_ and ***both***"), "<p><strong>bold</strong> and <em>em</em> and <em><strong>both</strong></em></p>")
+	is.Equal(render("*a **b** c* and **a *b* c**"), "<p><em>a <strong>b</strong> c</em> and <strong>a <em>b</em> c</strong></p>")
+	is.Equal(render("snake_case_word ~~gone~~"), "<p>snake_case_word <del>gone</del></p>")
+	is.Equal(render("`a < b` and `` `tick` ``"), "<p><code>a &lt; b</code> and <code>`tick`</code></p>")
+	is.Equal(render(`\*literal\* & &amp; <3`), "<p>*literal* &amp; &amp; &lt;3</p>")
+	is.Equal(render("line  \nbreak\nsoft"), "<p>line<br />\nbreak\nsoft</p>")
+}
+
+func TestLinks(t *testing.T) {
+	is := is.New(t)
+	is.Equal(render(`[About *us*](/about "About")`), `<p><a href="/about" title="About">About <em>us</em></a></p>`)
+	is.Equal(render(`![A *hero*](/hero.png)`), `<p><img src="/hero.png" alt="A hero" /></p>`)
+	is.Equal(render(`<https://livebud.com> <hi@livebud.com>`), `<p><a href="https://livebud.com">https://livebud.com</a> <a href="mailto:hi@livebud.com">hi@livebud.com</a></p>`)
+	is.Equal(render(`[not a link]`), `<p>[not a link]</p>`)
+	is.Equal(render(`[wiki](https://en.wikipedia.org/wiki/Go_(language))`), `<p><a href="https://en.wikipedia.org/wiki/Go_(language)">wiki</a></p>`)
+}
+
+func TestLists(t *testing.T) {
+	is := is.New(t)
+	is.Equal(render("- one\n- two\n  - nested\n- three"), "<ul>\n<li>one</li>\n<li>two\n<ul>\n<li>nested</li>\n</ul>\n</li>\n<li>three</li>\n</ul>")
+	is.Equal(render("3. c\n4. d"), "<ol start=\"3\">\n<li>c</li>\n<li>d</li>\n</ol>")
+	is.Equal(render("- a\n\n- b"), "<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>")
+	is.Equal(render("- a\n+ b"), "<ul>\n<li>a</li>\n</ul>\n<ul>\n<li>b</li>\n</ul>")
+}
+
+func TestBlocks(t *testing.T) {
+	is := is.New(t)
+	is.Equal(render("> quote\ncontinued"), "<blockquote>\n<p>quote\ncontinued</p>\n</blockquote>")
+	is.Equal(render("```go\nfunc main() {}\n```"), "<pre><code class=\"language-go\">func main() {}\n</code></pre>")
+	is.Equal(render("    <code>\n    indented"), "<pre><code>&lt;code&gt;\nindented\n</code></pre>")
+	is.Equal(render("***"), "<hr />")
+	is.Equal(render("<div class=\"note\">\n*raw*\n</div>"), "<div class=\"note\">\n*raw*\n</div>")
+	is.Equal(render("| a | b |\n|:--|--:|\n| 1 | 2 \\| 3 |"), "<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2 | 3</td>\n</tr>\n</tbody>\n</table>")
+}
+
+func TestFrontMatter(t *testing.T) {
+	is := is.New(t)
+	matter, body, err := markdown.FrontMatter([]byte("---\ntitle: Hello\ntags: [a, b]\n---\n# Hello"))
+	is.NoErr(err)
+	is.Equal(matter["title"], "Hello")
+	is.Equal(matter["tags"], []interface{}{"a", "b"})
+	is.Equal(string(body), "# Hello")
+	matter, body, err = markdown.FrontMatter([]byte("\n---\ntitle: Hi\n---\n"))
+	is.NoErr(err)
+	is.Equal(matter["title"], "Hi")
+	is.Equal(string(body), "")
+	matter, body, err = markdown.FrontMatter([]byte("# No front matter"))
+	is.NoErr(err)
+	is.Equal(len(matter), 0)
+	is.Equal(string(body), "# No front matter")
+	matter, body, err = markdown.FrontMatter([]byte("---\ntitle: Hello\n"))
+	is.True(err != nil)
+	is.Equal(err.Error(), "markdown: front matter isn't closed")
+	is.Equal(matter, nil)
+	is.Equal(body, nil)
+}
+
+func TestSvelte(t *testing.T) {
+	is := is.New(t)
+	code, err := markdown.Svelte("view/about.md", []byte("---\ntitle: About\ndescription: All about us\n---\n# {title}\n\n`{code}`"))
+	is.NoErr(err)
+	is.In(string(code), `export const metadata = {"description":"All about us","title":"About"}`)
+	is.In(string(code), `export let description = metadata["description"]`)
+	is.In(string(code), `export let title = metadata["title"]`)
+	is.In(string(code), "<svelte:head>\n  <title>{title}</title>\n  <meta name=\"description\" content={description} />\n</svelte:head>")
+	// Braces aren't expressions in markdown
+	is.In(string(code), "<h1>&#123;title&#125;</h1>")
+	is.In(string(code), "<p><code>&#123;code&#125;</code></p>")
+}
+
+func TestSvelteMDX(t *testing.T) {
+	is := is.New(t)
+	code, err := markdown.Svelte("view/about.mdx", []byte("---\ntitle: About\n---\nimport Counter from \"./Counter.svelte\"\nexport let title = \"Override\"\n\n# {title}\n\n<Counter count={1} />\n\nInline <Counter /> with `{code}` and {a < b}\n\n```js\nimport x from \"y\"\n```"))
+	is.NoErr(err)
+	is.In(string(code), "<script>\n  import Counter from \"./Counter.svelte\"\n  export let title = \"Override\"\n</script>")
+	// The title was declared by the MDX
+	is.True(!strings.Contains(string(code), `export let title = metadata["title"]`))
+	is.In(string(code), "<h1>{title}</h1>")
+	is.In(string(code), "<Counter count={1} />")
+	is.In(string(code), "<p>Inline <Counter /> with <code>&#123;code&#125;</code> and {a < b}</p>")
+	// Imports within code blocks aren't hoisted
+	is.In(string(code), "<pre><code class=\"language-js\">import x from \"y\"\n</code></pre>")
+}
@@ -0,0 +1,154 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter splits the YAML front matter from the rest of the markdown
+func FrontMatter(source []byte) (matter map[string]interface{}, body []byte, err error) {
+	source = bytes.ReplaceAll(source, []byte("\r\n"), []byte("\n"))
+	// Blank lines before the front matter are ignored
+	trimmed := bytes.TrimLeft(source, "\n")
+	if !bytes.HasPrefix(trimmed, []byte("---\n")) {
+		return map[string]interface{}{}, source, nil
+	}
+	// Find the line that closes the front matter
+	rest := trimmed[len("---\n"):]
+	end, next := -1, 0
+	for offset := 0; offset <= len(rest); {
+		line := rest[offset:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+		if delimiter := string(bytes.TrimRight(line, " ")); delimiter == "---" || delimiter == "..." {
+			end, next = offset, offset+len(line)
+			break
+		}
+		offset += len(line) + 1
+	}
+	if end < 0 {
+		return nil, nil, fmt.Errorf("markdown: front matter isn't closed")
+	}
+	matter = map[string]interface{}{}
+	if err := yaml.Unmarshal(rest[:end], &matter); err != nil {
+		return nil, nil, fmt.Errorf("markdown: unable to parse front matter. %w", err)
+	}
+	if matter == nil {
+		matter = map[string]interface{}{}
+	}
+	return matter, bytes.TrimPrefix(rest[next:], []byte("\n")), nil
+}
+
+// reHoist matches the MDX lines that belong in the component's script
+var reHoist = regexp.MustCompile(`^(?:import|export)\s`)
+
+// reIdentifier matches front matter keys that can be props
+var reIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Svelte turns a markdown (.md) or MDX (.mdx) view into a Svelte component.
+//
+// The front matter becomes props of the page. Props passed into the page take
+// precedence over the front matter. The front matter is also exported from the
+// component as metadata, so pages can list each other. The title and
+// description are added to the head.
+//
+// MDX is markdown with Svelte. Import and export lines are moved into the
+// component's script, so other components can be used within the markdown.
+// Expressions like {title} are evaluated rather than escaped.
+func Svelte(fpath string, source []byte) ([]byte, error) {
+	matter, body, err := FrontMatter(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w in %q", err, fpath)
+	}
+	r := &renderer{mdx: path.Ext(fpath) == ".mdx"}
+	var hoisted []string
+	lines := splitLines(string(body))
+	if r.mdx {
+		lines, hoisted = hoist(lines)
+	}
+	content := new(strings.Builder)
+	r.blocks(content, lines, false)
+	html := content.String()
+	if !r.mdx {
+		html = braceEscaper.Replace(html)
+	}
+	metadata, err := json.Marshal(matter)
+	if err != nil {
+		return nil, fmt.Errorf("markdown: unable to encode the front matter in %q. %w", fpath, err)
+	}
+	out := new(strings.Builder)
+	out.WriteString("<script context=\"module\">\n")
+	out.WriteString("  export const metadata = " + string(metadata) + "\n")
+	out.WriteString("</script>\n\n")
+	out.WriteString("<script>\n")
+	for _, line := range hoisted {
+		out.WriteString("  " + line + "\n")
+	}
+	keys := make([]string, 0, len(matter))
+	for key := range matter {
+		if reIdentifier.MatchString(key) && key != "metadata" && !declares(hoisted, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out.WriteString(fmt.Sprintf("  export let %s = metadata[%q]\n", key, key))
+	}
+	out.WriteString("</script>\n\n")
+	_, hasTitle := matter["title"]
+	_, hasDescription := matter["description"]
+	if hasTitle || hasDescription {
+		out.WriteString("<svelte:head>\n")
+		if hasTitle {
+			out.WriteString("  <title>{title}</title>\n")
+		}
+		if hasDescription {
+			out.WriteString("  <meta name=\"description\" content={description} />\n")
+		}
+		out.WriteString("</svelte:head>\n\n")
+	}
+	out.WriteString(html)
+	return []byte(out.String()), nil
+}
+
+// hoist the import and export lines outside of code blocks
+func hoist(lines []string) (body, hoisted []string) {
+	fence := ""
+	for _, line := range lines {
+		if m := reFence.FindStringSubmatch(line); m != nil {
+			trimmed := strings.TrimSpace(line)
+			if fence == "" {
+				fence = m[2]
+			} else if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+		}
+		if fence == "" && reHoist.MatchString(line) {
+			hoisted = append(hoisted, line)
+			continue
+		}
+		body = append(body, line)
+	}
+	return body, hoisted
+}
+
+// declares returns true if the hoisted lines already declare the prop
+func declares(hoisted []string, key string) bool {
+	for _, line := range hoisted {
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '=' || r == ';'
+		})
+		if len(fields) >= 3 && fields[0] == "export" && fields[2] == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package markdown
+
+import (
+	"context"
+	"github.com/livebud/bud/framework/transform/transformrt"
+)
+
+// NewTransformables transforms markdown (.md) and MDX (.mdx) into Svelte
+func NewTransformables() []*transformrt.Transformable {
+	return []*transformrt.Transformable{
+		newTransformable(".md"),
+		newTransformable(".mdx"),
+	}
+}
+
+func newTransformable(ext string) *transformrt.Transformable {
+	return &transformrt.Transformable{
+		From: ext,
+		To:   ".svelte",
+		For: transformrt.Platforms{
+			transformrt.PlatformAll: func(ctx context.Context, file *transformrt.File) error {
+				code, err := Svelte(file.Path(), file.Code)
+				if err != nil {
+					return err
+				}
+				file.Code = code
+				return nil
+			},
+		},
+	}
+}
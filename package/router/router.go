@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/livebud/bud/package/router/lex"
 	"github.com/livebud/bud/package/router/radix"
 )
 
@@ -18,6 +19,27 @@ func New() *Router {
 // Router struct
 type Router struct {
 	methods map[string]radix.Tree
+	routes  []*Route
+}
+
+// Route that's been added to the router
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Dynamic is true if the route has slots that are filled in by the request
+// path (e.g. /users/:id)
+func (r *Route) Dynamic() bool {
+	lexer := lex.New(r.Path)
+	for {
+		switch lexer.Next().Type {
+		case lex.SlotToken, lex.QuestionToken, lex.StarToken:
+			return true
+		case lex.EndToken, lex.ErrorToken:
+			return false
+		}
+	}
 }
 
 var _ http.Handler = (*Router)(nil)
@@ -34,7 +56,16 @@ func (rt *Router) add(method, route string, handler http.Handler) error {
 	if _, ok := rt.methods[method]; !ok {
 		rt.methods[method] = radix.New()
 	}
-	return rt.methods[method].Insert(route, handler)
+	if err := rt.methods[method].Insert(route, handler); err != nil {
+		return err
+	}
+	rt.routes = append(rt.routes, &Route{method, route})
+	return nil
+}
+
+// Routes returns the routes in the order they were added
+func (rt *Router) Routes() []*Route {
+	return rt.routes
 }
 
 // Get route
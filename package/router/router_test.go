@@ -223,3 +223,26 @@ func TestAdd(t *testing.T) {
 	is.NoErr(err)
 	is.Equal("id=10", string(body))
 }
+
+func TestRoutes(t *testing.T) {
+	is := is.New(t)
+	router := router.New()
+	is.NoErr(router.Get("/", handler("/")))
+	is.NoErr(router.Get("/users/:id", handler("/users/:id")))
+	is.NoErr(router.Post("/users", handler("/users")))
+	is.NoErr(router.Get("/posts/:slug?", handler("/posts/:slug?")))
+	is.NoErr(router.Get("/files/:path*", handler("/files/:path*")))
+	is.True(router.Get("/users/:ID", handler("/users/:ID")) != nil)
+	routes := router.Routes()
+	is.Equal(len(routes), 5)
+	is.Equal(routes[0].Method, http.MethodGet)
+	is.Equal(routes[0].Path, "/")
+	is.Equal(routes[0].Dynamic(), false)
+	is.Equal(routes[1].Path, "/users/:id")
+	is.Equal(routes[1].Dynamic(), true)
+	is.Equal(routes[2].Method, http.MethodPost)
+	is.Equal(routes[2].Path, "/users")
+	is.Equal(routes[2].Dynamic(), false)
+	is.Equal(routes[3].Dynamic(), true)
+	is.Equal(routes[4].Dynamic(), true)
+}
@@ -5,10 +5,16 @@ package svelte
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
 	_ "embed"
 
+	"github.com/cespare/xxhash"
+	"github.com/livebud/bud/internal/pubsub"
+	"github.com/livebud/bud/package/hot"
 	"github.com/livebud/bud/package/js"
+	"github.com/livebud/bud/package/log"
 )
 
 // compiler.js is used to compile .svelte files into JS & CSS
@@ -20,49 +26,229 @@ func Load(vm js.VM) (*Compiler, error) {
 	if err := vm.Script("svelte/compiler.js", compiler); err != nil {
 		return nil, err
 	}
-	// TODO make dev configurable
-	return &Compiler{vm, true}, nil
+	// Compile in development by default. Production builds turn these off.
+	return &Compiler{
+		VM:  vm,
+		Dev: true,
+		Hot: true,
+		Preprocessors: map[string]Preprocessor{
+			"ts":         TypeScript,
+			"typescript": TypeScript,
+		},
+	}, nil
 }
 
 type Compiler struct {
-	VM  js.VM
+	VM js.VM
+	// Dev compiles components with runtime checks and better error messages
 	Dev bool
+	// Hot registers the DOM components with the hot module runtime
+	Hot bool
+	// Preprocessors by the language of the <script> or <style> block. For
+	// example, "ts" preprocesses <script lang="ts">.
+	Preprocessors map[string]Preprocessor
+	// Cache the compiled components between runs. Can be nil.
+	Cache Cache
+	// Log the compiler warnings. Can be nil.
+	Log log.Interface
+	// Bus publishes the compiler warnings to the browser's overlay in
+	// development. Can be nil.
+	Bus pubsub.Publisher
+	// Strict fails to compile components that have warnings
+	Strict bool
+}
+
+// Cache stores the compiled components
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, data []byte) error
+}
+
+// version of the compiler, so upgrading Svelte invalidates the cache
+var version = strconv.FormatUint(xxhash.Sum64String(compiler), 16)
+
+// compile the component for the target, caching the result. Compile errors
+// are cached too, since compiling the same code would fail the same way.
+func (c *Compiler) compile(path string, code []byte, target string) (string, error) {
+	key := ""
+	if c.Cache != nil {
+		hash := xxhash.New()
+		fmt.Fprintf(hash, "%s:%s:%t:%s:", version, target, c.Dev, path)
+		hash.Write(code)
+		key = target + "-" + strconv.FormatUint(hash.Sum64(), 16)
+		if result, ok := c.Cache.Get(key); ok {
+			return string(result), nil
+		}
+	}
+	expr := fmt.Sprintf(`;__svelte__.compile({ "path": %q, "code": %q, "target": %q, "dev": %t, "css": false })`, path, code, target, c.Dev)
+	result, err := c.VM.Eval(path, expr)
+	if err != nil {
+		return "", err
+	}
+	if c.Cache != nil {
+		// The cache is only an optimization, so failing to write isn't an error
+		c.Cache.Set(key, []byte(result))
+	}
+	return result, nil
 }
 
 type SSR struct {
 	JS  string
 	CSS string
+	// Source map from the JS back to the component
+	Map      string
+	Warnings []*Warning
 }
 
 // Compile server-rendered code
 func (c *Compiler) SSR(path string, code []byte) (*SSR, error) {
-	expr := fmt.Sprintf(`;__svelte__.compile({ "path": %q, "code": %q, "target": "ssr", "dev": %t, "css": false })`, path, code, c.Dev)
-	result, err := c.VM.Eval(path, expr)
+	src, err := c.preprocess(path, code)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.compile(path, src.code, "ssr")
 	if err != nil {
 		return nil, err
 	}
-	out := new(SSR)
-	if err := json.Unmarshal([]byte(result), out); err != nil {
+	var out struct {
+		SSR
+		Error *Error
+	}
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		return nil, err
+	} else if out.Error != nil {
+		return nil, src.locate(out.Error)
+	}
+	src.locateWarnings(out.Warnings)
+	if out.Map, err = src.remap(out.Map); err != nil {
 		return nil, err
 	}
-	return out, nil
+	return &out.SSR, nil
 }
 
 type DOM struct {
 	JS  string
 	CSS string
+	// Source map from the JS back to the component
+	Map      string
+	Warnings []*Warning
 }
 
-// Compile DOM code
+// Compile DOM code. CSS is returned separately rather than injected at runtime
+// so it can be bundled into stylesheets.
 func (c *Compiler) DOM(path string, code []byte) (*DOM, error) {
-	expr := fmt.Sprintf(`;__svelte__.compile({ "path": %q, "code": %q, "target": "dom", "dev": %t, "css": true })`, path, code, c.Dev)
-	result, err := c.VM.Eval(path, expr)
+	src, err := c.preprocess(path, code)
 	if err != nil {
 		return nil, err
 	}
-	out := new(DOM)
-	if err := json.Unmarshal([]byte(result), out); err != nil {
+	result, err := c.compile(path, src.code, "dom")
+	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	var out struct {
+		DOM
+		Error *Error
+	}
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		return nil, err
+	} else if out.Error != nil {
+		return nil, src.locate(out.Error)
+	}
+	src.locateWarnings(out.Warnings)
+	if out.Map, err = src.remap(out.Map); err != nil {
+		return nil, err
+	}
+	return &out.DOM, nil
+}
+
+// Error is a compile error within a component
+type Error struct {
+	Path    string
+	Name    string
+	Message string
+	Line    int // 1-based
+	Column  int // 0-based
+	Frame   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("svelte: %s %s (%d:%d)\n%s", e.Path, e.Message, e.Line, e.Column, e.Frame)
+}
+
+// Location of the error within the component. The column is 1-based.
+func (e *Error) Location() (file string, line, column int) {
+	return e.Path, e.Line, e.Column + 1
+}
+
+// CodeFrame returns the code around the error
+func (e *Error) CodeFrame() string {
+	return e.Frame
+}
+
+// Warning from the compiler, like an a11y hint, an unused export or an unused
+// CSS selector
+type Warning struct {
+	Path    string
+	Code    string
+	Message string
+	Line    int // 1-based
+	Column  int // 0-based
+	Frame   string
+}
+
+func (w *Warning) String() string {
+	return fmt.Sprintf("svelte: %s:%d:%d %s (%s)", w.Path, w.Line, w.Column+1, w.Message, w.Code)
+}
+
+// Warnings fail the compile when the compiler is strict
+type Warnings []*Warning
+
+func (w Warnings) Error() string {
+	lines := make([]string, len(w))
+	for i, warning := range w {
+		lines[i] = warning.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Report the warnings of a component. Warnings are logged, or returned as an
+// error when the compiler is strict.
+func (c *Compiler) Report(warnings []*Warning) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+	if c.Strict {
+		return Warnings(warnings)
+	}
+	if c.Log != nil {
+		for _, warning := range warnings {
+			c.Log.Warn(warning.String())
+		}
+	}
+	if c.Bus != nil {
+		c.publish(warnings)
+	}
+	return nil
+}
+
+// publish the component's warnings to the browser's overlay. Warnings don't
+// stop the page from loading, so they're sent separately from errors.
+func (c *Compiler) publish(warnings []*Warning) {
+	first := warnings[0]
+	messages := make([]string, len(warnings))
+	for i, warning := range warnings {
+		messages[i] = fmt.Sprintf("%d:%d %s (%s)", warning.Line, warning.Column+1, warning.Message, warning.Code)
+	}
+	data, err := json.Marshal(&hot.Error{
+		Title:   "Svelte warnings",
+		Message: strings.Join(messages, "\n"),
+		File:    first.Path,
+		Line:    first.Line,
+		Column:  first.Column + 1,
+		Frame:   first.Frame,
+	})
+	if err != nil {
+		return
+	}
+	c.Bus.Publish("frontend:warning", data)
 }
@@ -26579,17 +26579,44 @@ ${frame}`
   // compiler.ts
   function compile2(input) {
     const { code, path, target, dev, css } = input;
-    const svelte = compile(code, {
-      filename: path,
-      generate: target,
-      hydratable: true,
-      format: "esm",
-      dev,
-      css
-    });
+    let svelte;
+    try {
+      svelte = compile(code, {
+        filename: path,
+        generate: target,
+        hydratable: true,
+        format: "esm",
+        dev,
+        css,
+        cssHash: ({ hash: hash2, filename }) => `svelte-${hash2(filename)}`
+      });
+    } catch (err) {
+      if (!err || !err.start) {
+        throw err;
+      }
+      return JSON.stringify({
+        Error: {
+          Path: path,
+          Name: err.name,
+          Message: err.message,
+          Line: err.start.line,
+          Column: err.start.column,
+          Frame: err.frame
+        }
+      });
+    }
     return JSON.stringify({
       CSS: svelte.css.code,
-      JS: svelte.js.code
+      JS: svelte.js.code,
+      Map: svelte.js.map ? JSON.stringify(svelte.js.map) : "",
+      Warnings: svelte.warnings.map((warning) => ({
+        Path: path,
+        Code: warning.code,
+        Message: warning.message,
+        Line: warning.start ? warning.start.line : 0,
+        Column: warning.start ? warning.start.column : 0,
+        Frame: warning.frame || ""
+      }))
     });
   }
   return __toCommonJS(compiler_exports);
@@ -8,34 +8,81 @@ type Input = {
   css: boolean
 }
 
+type Warning = {
+  Path: string
+  Code: string
+  Message: string
+  Line: number
+  Column: number
+  Frame: string
+}
+
 // Capitalized for Go
 type Output =
   | {
       JS: string
       CSS: string
+      Map: string
+      Warnings: Warning[]
     }
   | {
       Error: {
         Path: string
         Name: string
         Message: string
-        Stack?: string
+        Line: number
+        Column: number
+        Frame: string
       }
     }
 
 // Compile svelte code
 export function compile(input: Input): string {
   const { code, path, target, dev, css } = input
-  const svelte = compileSvelte(code, {
-    filename: path,
-    generate: target,
-    hydratable: true,
-    format: "esm",
-    dev: dev,
-    css: css,
-  })
+  let svelte: ReturnType<typeof compileSvelte>
+  try {
+    svelte = compileSvelte(code, {
+      filename: path,
+      generate: target,
+      hydratable: true,
+      format: "esm",
+      dev: dev,
+      css: css,
+      // Scope the styles by the component's path rather than by its styles, so
+      // class names don't change when only the styles change
+      cssHash: ({ hash, filename }) => `svelte-${hash(filename)}`,
+    })
+  } catch (err: any) {
+    // Compile errors know where in the component they happened
+    if (!err || !err.start) {
+      throw err
+    }
+    return JSON.stringify({
+      Error: {
+        Path: path,
+        Name: err.name,
+        Message: err.message,
+        Line: err.start.line,
+        Column: err.start.column,
+        Frame: err.frame,
+      },
+    } as Output)
+  }
   return JSON.stringify({
     CSS: svelte.css.code,
     JS: svelte.js.code,
+    // Source map from the JS back to the component
+    Map: svelte.js.map ? JSON.stringify(svelte.js.map) : "",
+    // Warnings like a11y hints, unused exports and unused CSS selectors
+    Warnings: svelte.warnings.map(
+      (warning): Warning => ({
+        Path: path,
+        Code: warning.code,
+        Message: warning.message,
+        Line: warning.start ? warning.start.line : 0,
+        Column: warning.start ? warning.start.column : 0,
+        Frame: warning.frame || "",
+      })
+    ),
   } as Output)
 }
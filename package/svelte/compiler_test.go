@@ -1,11 +1,20 @@
 package svelte_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/livebud/bud/framework/transform/transformrt"
 	"github.com/livebud/bud/internal/is"
+	"github.com/livebud/bud/internal/pubsub"
+	"github.com/livebud/bud/package/hot"
 	v8 "github.com/livebud/bud/package/js/v8"
+	"github.com/livebud/bud/package/log"
+	"github.com/livebud/bud/package/markdown"
 	"github.com/livebud/bud/package/svelte"
 )
 
@@ -38,6 +47,23 @@ func TestSSRRecovery(t *testing.T) {
 	is.True(strings.Contains(ssr.JS, `<h1>hi world!</h1>`))
 }
 
+func TestCompileError(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	_, err = compiler.DOM("view/index.svelte", []byte("<h1>hi</h1>\n<p>world</h1>"))
+	is.True(err != nil)
+	var compileErr *svelte.Error
+	is.True(errors.As(err, &compileErr))
+	is.Equal(compileErr.Path, "view/index.svelte")
+	is.Equal(compileErr.Line, 2)
+	is.Equal(compileErr.Column, 8)
+	is.In(compileErr.Message, "</h1> attempted to close an element that was not open")
+	is.In(compileErr.Frame, "<p>world</h1>")
+}
+
 func TestDOM(t *testing.T) {
 	is := is.New(t)
 	vm, err := v8.Load()
@@ -71,4 +97,327 @@ func TestDOMRecovery(t *testing.T) {
 	is.True(strings.Contains(dom.JS, `text("hi world!")`))
 }
 
-// TODO: test compiler.Dev = false
+func TestProduction(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	dom, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.In(dom.JS, `SvelteComponentDev`)
+	compiler.Dev = false
+	compiler.Hot = false
+	dom, err = compiler.DOM("view/index.svelte", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.True(!strings.Contains(dom.JS, `SvelteComponentDev`))
+	// Components aren't registered with the hot module runtime
+	transformer, err := transformrt.Load(svelte.NewTransformable(compiler))
+	is.NoErr(err)
+	code, err := transformer.DOM.Transform(ctx, "view/index.svelte", "view/index.js", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.True(!strings.Contains(string(code), `__bud_register__`))
+}
+
+func TestDOMHot(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	transformer, err := transformrt.Load(svelte.NewTransformable(compiler))
+	is.NoErr(err)
+	code, err := transformer.DOM.Transform(ctx, "view/index.svelte", "view/index.js", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.True(strings.Contains(string(code), `import { register as __bud_register__ } from "livebud/runtime/hmr";`))
+	// Svelte names index components after their directory
+	is.True(strings.Contains(string(code), `export default __bud_register__("view/index.svelte", View);`))
+	// Server-rendered components aren't hot swapped
+	code, err = transformer.SSR.Transform(ctx, "view/index.svelte", "view/index.js", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.True(!strings.Contains(string(code), `__bud_register__`))
+}
+
+func TestDOMStableClass(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	red, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi</h1><style>h1 { color: red }</style>`))
+	is.NoErr(err)
+	blue, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi</h1><style>h1 { color: blue }</style>`))
+	is.NoErr(err)
+	// Changing the styles doesn't change the scoped class names
+	is.Equal(red.JS, blue.JS)
+	is.True(red.CSS != blue.CSS)
+}
+
+func TestDOMNewSelector(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	before, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi</h1><p>there</p><style>h1 { color: red }</style>`))
+	is.NoErr(err)
+	after, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi</h1><p>there</p><style>h1 { color: red } p { color: blue }</style>`))
+	is.NoErr(err)
+	// Svelte only scopes the elements that a selector matches, so adding a
+	// selector changes the scripts
+	is.True(before.JS != after.JS)
+}
+
+func TestDOMHotMarkdown(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	transformer, err := transformrt.Load(append(markdown.NewTransformables(), svelte.NewTransformable(compiler))...)
+	is.NoErr(err)
+	code, err := transformer.DOM.Transform(ctx, "view/about.md", "view/about.js", []byte(`# About`))
+	is.NoErr(err)
+	// Markdown is registered by its source path, which is what the hot server
+	// sends when it changes
+	is.True(strings.Contains(string(code), `export default __bud_register__("view/about.md", About);`))
+}
+
+func TestTypeScript(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	dom, err := compiler.DOM("view/index.svelte", []byte(`
+		<script lang="ts">
+			import Counter from "./Counter.svelte"
+			import type { Props } from "./props"
+			export let name: string = "world"
+			const greet = (props: Props): string => "hi " + name
+		</script>
+		<h1>{greet({ name })}</h1>
+		<Counter />
+	`))
+	is.NoErr(err)
+	// Imports that are only used in the markup are kept
+	is.In(dom.JS, `import Counter from "./Counter.svelte";`)
+	is.True(!strings.Contains(dom.JS, `./props`))
+	is.True(!strings.Contains(dom.JS, `: string`))
+	is.In(dom.JS, `let { name = "world" } = $$props;`)
+}
+
+func TestTypeScriptError(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	// Syntax errors point at the original code
+	_, err = compiler.DOM("view/index.svelte", []byte("<h1>hi</h1>\n<script lang=\"ts\">\n  let name: = \"world\"\n</script>"))
+	is.True(err != nil)
+	var compileErr *svelte.Error
+	is.True(errors.As(err, &compileErr))
+	is.Equal(compileErr.Path, "view/index.svelte")
+	is.Equal(compileErr.Line, 3)
+	is.Equal(compileErr.Column, 12)
+	is.In(compileErr.Frame, `let name: = "world"`)
+	// Compile errors after the script point at the original code too
+	_, err = compiler.DOM("view/index.svelte", []byte("<script lang=\"ts\">\n  type Props = {\n    name: string\n  }\n  export let name: string\n</script>\n<h1>{name}</h1>\n<p>world</h1>"))
+	is.True(err != nil)
+	is.True(errors.As(err, &compileErr))
+	is.Equal(compileErr.Line, 8)
+	is.Equal(compileErr.Column, 8)
+	is.In(compileErr.Frame, "8: <p>world</h1>")
+	// As do compile errors within the script
+	_, err = compiler.DOM("view/index.svelte", []byte("<script lang=\"ts\">\n  type Props = {\n    name: string\n  }\n  export let $name: string\n</script>"))
+	is.True(err != nil)
+	is.True(errors.As(err, &compileErr))
+	is.Equal(compileErr.Line, 5)
+	is.In(compileErr.Frame, "5:   export let $name: string")
+}
+
+func TestPreprocessStyle(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	compiler.Preprocessors["upper"] = svelte.PreprocessorFunc(func(path string, code []byte) (*svelte.Preprocessed, error) {
+		is.Equal(path, "view/index.svelte")
+		return &svelte.Preprocessed{Code: bytes.ToLower(code)}, nil
+	})
+	dom, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi</h1><style lang="upper">H1 { COLOR: RED }</style>`))
+	is.NoErr(err)
+	is.In(dom.CSS, `color:red`)
+	// Missing preprocessors are reported
+	dom, err = compiler.DOM("view/index.svelte", []byte(`<h1>hi</h1><style lang="scss">h1 { color: red }</style>`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `svelte: no preprocessor for <style lang="scss"> in "view/index.svelte"`)
+	is.Equal(dom, nil)
+}
+
+type cache map[string][]byte
+
+func (c cache) Get(key string) ([]byte, bool) {
+	data, ok := c[key]
+	return data, ok
+}
+
+func (c cache) Set(key string, data []byte) error {
+	c[key] = data
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	cache := cache{}
+	compiler.Cache = cache
+	dom, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.Equal(len(cache), 1)
+	// Cached components aren't compiled again
+	for key := range cache {
+		cache[key] = []byte(`{"JS":"cached","CSS":""}`)
+	}
+	cached, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.Equal(cached.JS, "cached")
+	// Changing the code, the target or the options compiles again
+	_, err = compiler.DOM("view/index.svelte", []byte(`<h1>hi world</h1>`))
+	is.NoErr(err)
+	is.Equal(len(cache), 2)
+	ssr, err := compiler.SSR("view/index.svelte", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.In(ssr.JS, `create_ssr_component`)
+	is.Equal(len(cache), 3)
+	compiler.Dev = false
+	prod, err := compiler.DOM("view/index.svelte", []byte(`<h1>hi world!</h1>`))
+	is.NoErr(err)
+	is.True(prod.JS != dom.JS)
+	is.Equal(len(cache), 4)
+}
+
+type handler struct {
+	entries []log.Entry
+}
+
+func (h *handler) Log(entry log.Entry) {
+	h.entries = append(h.entries, entry)
+}
+
+func TestWarnings(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	dom, err := compiler.DOM("view/index.svelte", []byte("<h1>hi</h1>\n<img src=\"/hero.png\">\n<style>p { color: red }</style>"))
+	is.NoErr(err)
+	is.Equal(len(dom.Warnings), 2)
+	is.Equal(dom.Warnings[0].Path, "view/index.svelte")
+	is.Equal(dom.Warnings[0].Code, "a11y-missing-attribute")
+	is.Equal(dom.Warnings[0].Line, 2)
+	is.Equal(dom.Warnings[0].Column, 0)
+	is.In(dom.Warnings[0].Frame, `<img src="/hero.png">`)
+	is.Equal(dom.Warnings[1].Code, "css-unused-selector")
+	is.Equal(dom.Warnings[1].Line, 3)
+	// Warnings within preprocessed code point at the original code
+	ssr, err := compiler.SSR("view/index.svelte", []byte("<script lang=\"ts\">\n  type Props = {\n    name: string\n  }\n  export let name: string\n</script>\n<h1>hi</h1>"))
+	is.NoErr(err)
+	is.Equal(len(ssr.Warnings), 1)
+	is.Equal(ssr.Warnings[0].Code, "unused-export-let")
+	is.Equal(ssr.Warnings[0].Line, 5)
+	is.In(ssr.Warnings[0].Frame, "5:   export let name: string")
+}
+
+func TestReport(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	handler := new(handler)
+	compiler.Log = log.New(handler)
+	transformer, err := transformrt.Load(svelte.NewTransformable(compiler))
+	is.NoErr(err)
+	ctx := context.Background()
+	_, err = transformer.DOM.Transform(ctx, "view/index.svelte", "view/index.js", []byte(`<img src="/hero.png">`))
+	is.NoErr(err)
+	is.Equal(len(handler.entries), 1)
+	is.Equal(handler.entries[0].Level, log.WarnLevel)
+	is.In(handler.entries[0].Message, "svelte: view/index.svelte:1:1")
+	is.In(handler.entries[0].Message, "(a11y-missing-attribute)")
+	// Server-rendered components don't report the same warnings again
+	_, err = transformer.SSR.Transform(ctx, "view/index.svelte", "view/index.js", []byte(`<img src="/hero.png">`))
+	is.NoErr(err)
+	is.Equal(len(handler.entries), 1)
+	// Strict compilers fail on warnings
+	compiler.Strict = true
+	code, err := transformer.DOM.Transform(ctx, "view/index.svelte", "view/index.js", []byte(`<img src="/hero.png">`))
+	is.True(err != nil)
+	var warnings svelte.Warnings
+	is.True(errors.As(err, &warnings))
+	is.Equal(len(warnings), 1)
+	is.Equal(code, nil)
+	is.Equal(len(handler.entries), 1)
+}
+
+func TestReportOverlay(t *testing.T) {
+	is := is.New(t)
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	bus := pubsub.New()
+	compiler.Bus = bus
+	sub := bus.Subscribe("frontend:warning")
+	defer sub.Close()
+	transformer, err := transformrt.Load(svelte.NewTransformable(compiler))
+	is.NoErr(err)
+	ctx := context.Background()
+	_, err = transformer.DOM.Transform(ctx, "view/index.svelte", "view/index.js", []byte(`<img src="/hero.png">`))
+	is.NoErr(err)
+	// Warnings are sent to the browser's overlay
+	data := <-sub.Wait()
+	herr := new(hot.Error)
+	is.NoErr(json.Unmarshal(data, herr))
+	is.Equal(herr.Title, "Svelte warnings")
+	is.Equal(herr.File, "view/index.svelte")
+	is.Equal(herr.Line, 1)
+	is.Equal(herr.Column, 1)
+	is.In(herr.Message, "1:1 ")
+	is.In(herr.Message, "(a11y-missing-attribute)")
+	is.In(herr.Frame, `<img src="/hero.png">`)
+}
+
+func TestIsland(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	vm, err := v8.Load()
+	is.NoErr(err)
+	compiler, err := svelte.Load(vm)
+	is.NoErr(err)
+	transformer, err := transformrt.Load(svelte.NewTransformable(compiler))
+	is.NoErr(err)
+	// Server-rendered islands are marked, so they can be hydrated on their own
+	code, err := transformer.SSR.Transform(ctx, "view/Counter.island.svelte", "view/Counter.island.js", []byte(`<button>0</button>`))
+	is.NoErr(err)
+	is.In(string(code), `import { island as __bud_island__ } from "./bud/view/_svelte.js";`)
+	is.In(string(code), `export default __bud_island__("/bud/view/Counter.island.svelte", Counter_island);`)
+	// Other components aren't
+	code, err = transformer.SSR.Transform(ctx, "view/Counter.svelte", "view/Counter.js", []byte(`<button>0</button>`))
+	is.NoErr(err)
+	is.True(!strings.Contains(string(code), `__bud_island__`))
+	// The browser imports islands like any other component
+	code, err = transformer.DOM.Transform(ctx, "view/Counter.island.svelte", "view/Counter.island.js", []byte(`<button>0</button>`))
+	is.NoErr(err)
+	is.True(!strings.Contains(string(code), `__bud_island__`))
+}
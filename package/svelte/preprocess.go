@@ -0,0 +1,327 @@
+package svelte
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+	"github.com/livebud/bud/framework/transform/transformrt"
+	"github.com/livebud/bud/internal/sourcemap"
+)
+
+// Preprocessor preprocesses a <script> or <style> block that's written in
+// another language, like TypeScript or SCSS
+type Preprocessor interface {
+	Preprocess(path string, code []byte) (*Preprocessed, error)
+}
+
+// Preprocessed code with an optional source map back to the original code
+type Preprocessed struct {
+	Code []byte
+	Map  []byte
+}
+
+// PreprocessorFunc turns a function into a preprocessor
+type PreprocessorFunc func(path string, code []byte) (*Preprocessed, error)
+
+func (fn PreprocessorFunc) Preprocess(path string, code []byte) (*Preprocessed, error) {
+	return fn(path, code)
+}
+
+// TypeScript strips the types from <script lang="ts"> blocks
+var TypeScript Preprocessor = PreprocessorFunc(typescript)
+
+func typescript(path string, code []byte) (*Preprocessed, error) {
+	result := esbuild.Transform(string(code), esbuild.TransformOptions{
+		Loader:     esbuild.LoaderTS,
+		Target:     esbuild.ESNext,
+		Sourcefile: path,
+		Sourcemap:  esbuild.SourceMapExternal,
+		// Imports may only be used within the markup, so they can't be removed.
+		// Types must be imported with "import type".
+		TsconfigRaw: `{"compilerOptions":{"preserveValueImports":true}}`,
+	})
+	if len(result.Errors) > 0 {
+		msg := result.Errors[0]
+		err := &Error{Path: path, Name: "TypeScriptError", Message: msg.Text}
+		if msg.Location != nil {
+			err.Line = msg.Location.Line
+			err.Column = msg.Location.Column
+		}
+		return nil, err
+	}
+	return &Preprocessed{result.Code, result.Map}, nil
+}
+
+// Transformed preprocesses blocks with the transforms from one extension into
+// another (e.g. ".scss" into ".css"), so transforms in transform/ can also
+// preprocess the blocks within components.
+func Transformed(transformer transformrt.Transformer, from, to string) Preprocessor {
+	return PreprocessorFunc(func(path string, code []byte) (*Preprocessed, error) {
+		code, err := transformer.Transform(context.Background(), path+from, path+to, code)
+		if err != nil {
+			return nil, err
+		}
+		return &Preprocessed{Code: code}, nil
+	})
+}
+
+// reBlock matches the top-level <script> and <style> blocks, skipping comments
+var reBlock = regexp.MustCompile(`(?s)<!--.*?-->|<(script)(\s[^>]*)?>(.*?)</script>|<(style)(\s[^>]*)?>(.*?)</style>`)
+
+// reLang matches the attribute that sets the language of a block
+var reLang = regexp.MustCompile(`\s(lang|type)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+// preprocessed component
+type preprocessed struct {
+	path   string
+	code   []byte
+	blocks []*block
+	// Original code, used to locate errors
+	source []byte
+}
+
+// block that was preprocessed
+type block struct {
+	// Where the block's contents start in the original and preprocessed code.
+	// Lines are 1-based and columns are 0-based.
+	line, column       int
+	outLine, outColumn int
+	// Number of lines in the original and preprocessed contents
+	lines, outLines int
+	sourcemap       *sourcemap.Map
+}
+
+// preprocess the <script> and <style> blocks that have a language
+func (c *Compiler) preprocess(path string, code []byte) (*preprocessed, error) {
+	p := &preprocessed{path: path, source: code}
+	out := new(bytes.Buffer)
+	last := 0
+	for _, match := range reBlock.FindAllSubmatchIndex(code, -1) {
+		// Skip comments
+		if match[2] < 0 && match[8] < 0 {
+			continue
+		}
+		tag, attrs, contents := match[2:4], match[4:6], match[6:8]
+		if match[2] < 0 {
+			tag, attrs, contents = match[8:10], match[10:12], match[12:14]
+		}
+		name := string(code[tag[0]:tag[1]])
+		var attr []byte
+		if attrs[0] >= 0 {
+			attr = code[attrs[0]:attrs[1]]
+		}
+		lang, attr := language(attr)
+		if isPlain(name, lang) {
+			continue
+		}
+		preprocessor, ok := c.Preprocessors[lang]
+		if !ok {
+			return nil, fmt.Errorf("svelte: no preprocessor for <%s lang=%q> in %q", name, lang, path)
+		}
+		result, err := preprocessor.Preprocess(path, code[contents[0]:contents[1]])
+		if err != nil {
+			return nil, p.locateBlock(err, code, contents[0])
+		}
+		// Write up until the contents, dropping the language attribute
+		out.Write(code[last:tag[0]])
+		out.WriteString(name)
+		out.Write(attr)
+		out.WriteString(">")
+		b := &block{}
+		b.line, b.column = position(code, contents[0])
+		b.outLine, b.outColumn = position(out.Bytes(), out.Len())
+		b.lines = bytes.Count(code[contents[0]:contents[1]], []byte("\n")) + 1
+		b.outLines = bytes.Count(result.Code, []byte("\n")) + 1
+		if len(result.Map) > 0 {
+			sm, err := sourcemap.Parse(result.Map)
+			if err != nil {
+				return nil, err
+			}
+			b.sourcemap = sm
+		}
+		out.Write(result.Code)
+		p.blocks = append(p.blocks, b)
+		last = contents[1]
+	}
+	if len(p.blocks) == 0 {
+		p.code = code
+		return p, nil
+	}
+	out.Write(code[last:])
+	p.code = out.Bytes()
+	return p, nil
+}
+
+// language of the block, along with the attributes without the language
+func language(attrs []byte) (lang string, rest []byte) {
+	match := reLang.FindSubmatchIndex(attrs)
+	if match == nil {
+		return "", attrs
+	}
+	for i := 4; i < len(match); i += 2 {
+		if match[i] >= 0 {
+			lang = string(attrs[match[i]:match[i+1]])
+			break
+		}
+	}
+	// Types are mime types (e.g. text/typescript). Other types like "module"
+	// aren't languages.
+	if string(attrs[match[2]:match[3]]) == "type" {
+		if !strings.HasPrefix(lang, "text/") {
+			return "", attrs
+		}
+		lang = strings.TrimPrefix(lang, "text/")
+	}
+	rest = append(append([]byte{}, attrs[:match[0]]...), attrs[match[1]:]...)
+	return lang, rest
+}
+
+// isPlain returns true if the block doesn't need preprocessing
+func isPlain(name, lang string) bool {
+	switch lang {
+	case "":
+		return true
+	case "js", "javascript":
+		return name == "script"
+	case "css":
+		return name == "style"
+	default:
+		return false
+	}
+}
+
+// position of the offset. The line is 1-based and the column is 0-based.
+func position(code []byte, offset int) (line, column int) {
+	before := code[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = offset - (bytes.LastIndexByte(before, '\n') + 1)
+	return line, column
+}
+
+// locateBlock moves errors within a block's contents to where the block is in
+// the component
+func (p *preprocessed) locateBlock(err error, code []byte, offset int) error {
+	compileErr, ok := err.(*Error)
+	if !ok || compileErr.Line == 0 {
+		return err
+	}
+	line, column := position(code, offset)
+	if compileErr.Line == 1 {
+		compileErr.Column += column
+	}
+	compileErr.Line += line - 1
+	compileErr.Path = p.path
+	compileErr.Frame = codeFrame(p.source, compileErr.Line, compileErr.Column)
+	return compileErr
+}
+
+// locate moves errors in the preprocessed code back to where they are in the
+// original code
+func (p *preprocessed) locate(err *Error) *Error {
+	if len(p.blocks) == 0 {
+		return err
+	}
+	line, column := p.original(err.Line, err.Column)
+	err.Line, err.Column = line, column
+	err.Frame = codeFrame(p.source, line, column)
+	return err
+}
+
+// locateWarnings moves the warnings in the preprocessed code back to where
+// they are in the original code
+func (p *preprocessed) locateWarnings(warnings []*Warning) {
+	if len(p.blocks) == 0 {
+		return
+	}
+	for _, warning := range warnings {
+		if warning.Line == 0 {
+			continue
+		}
+		warning.Line, warning.Column = p.original(warning.Line, warning.Column)
+		warning.Frame = codeFrame(p.source, warning.Line, warning.Column)
+	}
+}
+
+// remap the compiler's source map, so it points at the original code rather
+// than the preprocessed code
+func (p *preprocessed) remap(data string) (string, error) {
+	if len(p.blocks) == 0 || data == "" {
+		return data, nil
+	}
+	sm, err := sourcemap.Parse([]byte(data))
+	if err != nil {
+		return "", err
+	}
+	sm.Remap(p.original)
+	out, err := sm.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// original position of a position within the preprocessed code
+func (p *preprocessed) original(line, column int) (int, int) {
+	delta := 0
+	for _, b := range p.blocks {
+		if line < b.outLine {
+			break
+		}
+		if line < b.outLine+b.outLines {
+			// Within the block's contents
+			line, column := line-b.outLine+1, column
+			if line == 1 {
+				column -= b.outColumn
+			}
+			if b.sourcemap != nil {
+				if pos, ok := b.sourcemap.Find(line, column); ok {
+					line, column = pos.Line, pos.Column
+				}
+			}
+			if line > b.lines {
+				line = b.lines
+			}
+			if line == 1 {
+				column += b.column
+			}
+			return b.line + line - 1, column
+		}
+		delta += b.lines - b.outLines
+	}
+	return line + delta, column
+}
+
+// codeFrame formats the code around the line like the Svelte compiler does
+func codeFrame(code []byte, line, column int) string {
+	lines := strings.Split(string(code), "\n")
+	start, end := line-3, line+2
+	if start < 0 {
+		start = 0
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	if start >= end {
+		return ""
+	}
+	digits := len(strconv.Itoa(end + 1))
+	frame := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		text := strings.ReplaceAll(lines[i], "\t", "  ")
+		frame = append(frame, fmt.Sprintf("%*d: %s", digits, i+1, text))
+		if i+1 == line {
+			prefix := lines[i]
+			if column < len(prefix) {
+				prefix = prefix[:column]
+			}
+			indent := digits + 2 + len(strings.ReplaceAll(prefix, "\t", "  "))
+			frame = append(frame, strings.Repeat(" ", indent)+"^")
+		}
+	}
+	return strings.Join(frame, "\n")
+}
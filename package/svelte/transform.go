@@ -1,7 +1,14 @@
 package svelte
 
 import (
+	"context"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"github.com/livebud/bud/framework/transform/transformrt"
+	"github.com/livebud/bud/internal/sourcemap"
 )
 
 func NewTransformable(compiler *Compiler) *Transformable {
@@ -10,22 +17,42 @@ func NewTransformable(compiler *Compiler) *Transformable {
 		To:   ".js",
 		For: transformrt.Platforms{
 			// DOM transform (browser)
-			transformrt.PlatformDOM: func(file *transformrt.File) error {
+			transformrt.PlatformDOM: func(ctx context.Context, file *transformrt.File) error {
 				dom, err := compiler.DOM(file.Path(), file.Code)
 				if err != nil {
 					return err
 				}
+				// Only report the DOM warnings, since the SSR compiles the same
+				// component with the same warnings
+				if err := compiler.Report(dom.Warnings); err != nil {
+					return err
+				}
 				file.Code = []byte(dom.JS)
+				file.CSS = []byte(dom.CSS)
+				// Register the component by its source, so markdown pages can be
+				// hot swapped too
+				if compiler.Hot {
+					file.Code = registerHot(file.Source(), file.Code)
+				}
 				return nil
 			},
 
 			// SSR transform (server)
-			transformrt.PlatformSSR: func(file *transformrt.File) error {
+			transformrt.PlatformSSR: func(ctx context.Context, file *transformrt.File) error {
 				ssr, err := compiler.SSR(file.Path(), file.Code)
 				if err != nil {
 					return err
 				}
 				file.Code = []byte(ssr.JS)
+				// Mark the islands, so they can be hydrated on their own
+				if isIsland(file.Source()) {
+					file.Code = registerIsland(file.Source(), file.Code)
+				}
+				// Inline the source map, so render errors can be traced back to the
+				// component
+				if ssr.Map != "" {
+					file.Code = append(file.Code, "\n"+sourcemap.Inline([]byte(ssr.Map))+"\n"...)
+				}
 				return nil
 			},
 		},
@@ -33,3 +60,34 @@ func NewTransformable(compiler *Compiler) *Transformable {
 }
 
 type Transformable = transformrt.Transformable
+
+var reExportDefault = regexp.MustCompile(`(?m)^export default ([A-Za-z_$][A-Za-z0-9_$]*);\s*$`)
+
+// registerHot registers the component with the hot module runtime, so the
+// component can be swapped while the page is running. The module ID is the
+// component's path, which is what the hot server sends when it changes.
+func registerHot(path string, code []byte) []byte {
+	return reExportDefault.ReplaceAllFunc(code, func(match []byte) []byte {
+		name := reExportDefault.FindSubmatch(match)[1]
+		return []byte(`import { register as __bud_register__ } from "livebud/runtime/hmr";` + "\n" +
+			`export default __bud_register__(` + strconv.Quote(path) + `, ` + string(name) + `);` + "\n")
+	})
+}
+
+// isIsland returns true for interactive components that can be hydrated on
+// their own (e.g. view/Counter.island.svelte)
+func isIsland(fpath string) bool {
+	base := path.Base(fpath)
+	return strings.HasSuffix(strings.TrimSuffix(base, path.Ext(base)), ".island")
+}
+
+// registerIsland wraps the server-rendered island, so pages in islands mode
+// render it within a marker that the browser hydrates. The island is found by
+// its path, which is also how the page's client imports it.
+func registerIsland(fpath string, code []byte) []byte {
+	return reExportDefault.ReplaceAllFunc(code, func(match []byte) []byte {
+		name := reExportDefault.FindSubmatch(match)[1]
+		return []byte(`import { island as __bud_island__ } from "./bud/view/_svelte.js";` + "\n" +
+			`export default __bud_island__(` + strconv.Quote("/bud/"+fpath) + `, ` + string(name) + `);` + "\n")
+	})
+}